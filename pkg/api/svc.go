@@ -33,10 +33,18 @@ type BFFService interface {
 }
 
 type Config struct {
-	Listen             string     `mapstructure:"listen"`
-	RateLimits         RateLimits `mapstructure:"rate_limits"`
-	MaxRequests        uint       `mapstructure:"max_requests"`
-	MaxRequestsPerConn uint       `mapstructure:"max_requests_per_conn"`
+	Listen             string            `mapstructure:"listen"`
+	ConnContext        ConnContextConfig `mapstructure:"conn_context"`
+	RateLimits         RateLimits        `mapstructure:"rate_limits"`
+	MaxRequests        uint              `mapstructure:"max_requests"`
+	MaxRequestsPerConn uint              `mapstructure:"max_requests_per_conn"`
+}
+
+// ConnContextConfig is the allowlist of request headers and query parameters of client connections
+// that are provided to request templates as `conn.headers` and `conn.query`.
+type ConnContextConfig struct {
+	Headers []string `mapstructure:"headers"`
+	Query   []string `mapstructure:"query"`
 }
 
 type RateLimits struct {
@@ -100,6 +108,7 @@ func NewSevice(cfg *Config, handler BFFService) (*Service, error) {
 	endpoint.Use(middleware.NewQueryParamsMiddleware())
 	endpoint.Use(middleware.NewHeadersMiddleware())
 	endpoint.Use(httpmid.NewClientIPMiddleware(httpmid.CloudFront))
+	endpoint.Use(middleware.NewConnInfoMiddleware(cfg.ConnContext.Headers, cfg.ConnContext.Query))
 
 	s.server = server.NewServer(cfg.Listen)
 	s.server.AddChannel(endpoint)
@@ -4,22 +4,38 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/api"
 	"github.com/ksysoev/deriv-api-bff/pkg/config"
 	"github.com/ksysoev/deriv-api-bff/pkg/config/source"
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/derivschema"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/handlerfactory"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/passthrough"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
 	"github.com/ksysoev/deriv-api-bff/pkg/prov/deriv"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/http"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/secrets"
 	"github.com/ksysoev/deriv-api-bff/pkg/repo"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Otel      OtelConfig    `mapstructure:"otel"`
-	APISource source.Config `mapstructure:"api_source"`
-	Deriv     deriv.Config  `mapstructure:"deriv"`
-	Server    api.Config    `mapstructure:"server"`
+	Otel        OtelConfig         `mapstructure:"otel"`
+	APISource   source.Config      `mapstructure:"api_source"`
+	Deriv       deriv.Config       `mapstructure:"deriv"`
+	HTTP        http.Config        `mapstructure:"http"`
+	PassThrough passthrough.Config `mapstructure:"passthrough"`
+	Secrets     secrets.Config     `mapstructure:"secrets"`
+	Server      api.Config         `mapstructure:"server"`
+	Validation  ValidationConfig   `mapstructure:"validation"`
+}
+
+type ValidationConfig struct {
+	AssertFormats bool `mapstructure:"assert_formats"`
 }
 
 // initConfig initializes the configuration by reading from the specified config file.
@@ -60,12 +76,12 @@ func uploadConfig(ctx context.Context, cfg *Config) error {
 	calls := repo.NewCallsRepository()
 	requestHandler := core.NewService(calls, nil, nil)
 
-	sourceOpts, err := source.CreateOptions(&cfg.APISource)
+	cfgOpts, err := configOptions(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create config source: %w", err)
+		return err
 	}
 
-	svc, err := config.New(requestHandler, sourceOpts...)
+	svc, err := config.New(requestHandler, cfgOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create config service: %w", err)
 	}
@@ -82,15 +98,15 @@ func uploadConfig(ctx context.Context, cfg *Config) error {
 // It returns an error if the configuration is invalid or if there is a failure in creating the config source or service.
 // It returns nil if the configuration is successfully verified and handlers are loaded.
 func verifyConfig(ctx context.Context, cfg *Config) error {
-	sourceOpts, err := source.CreateOptions(&cfg.APISource)
+	cfgOpts, err := configOptions(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create config source: %w", err)
+		return err
 	}
 
 	calls := repo.NewCallsRepository()
 	requestHandler := core.NewService(calls, nil, nil)
 
-	svc, err := config.New(requestHandler, sourceOpts...)
+	svc, err := config.New(requestHandler, cfgOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create config service: %w", err)
 	}
@@ -129,6 +145,53 @@ func downloadConfig(ctx context.Context, cfg *Config, path string) error {
 	return nil
 }
 
+// configOptions creates the options for the config service based on the provided configuration.
+// It takes cfg of type *Config.
+// It returns a slice of config.Option and an error.
+// It returns an error if the config source can't be created or Deriv API schemas can't be loaded.
+// If the Deriv schema path is not set, handlers are validated against bundled Deriv API schemas.
+// Connection data referenced by templates is checked against the allowlist of the server configuration.
+// Authentication providers and signers referenced by HTTP backends are checked against the HTTP configuration.
+// Standard formats of params schemas are checked only if enabled in the validation configuration.
+func configOptions(cfg *Config) ([]config.Option, error) {
+	validator.AssertStandardFormats(cfg.Validation.AssertFormats)
+
+	opts, err := source.CreateOptions(&cfg.APISource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config source: %w", err)
+	}
+
+	opts = append(opts, config.WithHandlerOptions(
+		handlerfactory.WithConnContext(cfg.Server.ConnContext.Headers, cfg.Server.ConnContext.Query),
+		handlerfactory.WithHTTPAuth(slices.Collect(maps.Keys(cfg.HTTP.Auth))),
+		handlerfactory.WithHTTPSigners(slices.Collect(maps.Keys(cfg.HTTP.Signers))),
+	))
+
+	schemas, err := derivSchemas(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, config.WithHandlerOptions(handlerfactory.WithDerivSchemas(schemas))), nil
+}
+
+// derivSchemas loads Deriv API request schemas from the configured directory.
+// It takes cfg of type *Config.
+// It returns a pointer to derivschema.Registry and an error if schemas can't be loaded.
+// If the Deriv schema path is not set, bundled Deriv API schemas are returned.
+func derivSchemas(cfg *Config) (*derivschema.Registry, error) {
+	if cfg.Deriv.SchemaPath == "" {
+		return derivschema.Bundled(), nil
+	}
+
+	schemas, err := derivschema.LoadDir(cfg.Deriv.SchemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Deriv API schemas: %w", err)
+	}
+
+	return schemas, nil
+}
+
 // applyArgsToConfig applies command-line arguments to the configuration.
 // It takes arg of type *args and cfg of type *Config.
 // It does not return any values.
@@ -3,10 +3,14 @@ package cmd
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/config/source"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/deriv"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var callsConfig = `
@@ -23,6 +27,14 @@ server:
   listen: ":0"
 deriv:
   endpoint: "wss://localhost/"
+  ping_interval: "30s"
+  idle_timeout: "5m"
+http:
+  timeout: "10s"
+  max_conns_per_host: 50
+  circuit_breaker:
+    failure_threshold: 5
+    open_timeout: "1m"
 api:
   calls:
 api_source:
@@ -56,6 +68,12 @@ func TestInitConfig_Valid(t *testing.T) {
 	assert.NotNil(t, cfg)
 	assert.Equal(t, ":0", cfg.Server.Listen)
 	assert.Equal(t, "wss://localhost/", cfg.Deriv.Endpoint)
+	assert.Equal(t, 30*time.Second, cfg.Deriv.PingInterval)
+	assert.Equal(t, 5*time.Minute, cfg.Deriv.IdleTimeout)
+	assert.Equal(t, 10*time.Second, cfg.HTTP.Timeout)
+	assert.Equal(t, 50, cfg.HTTP.MaxConnsPerHost)
+	assert.Equal(t, 5, cfg.HTTP.CircuitBreaker.FailureThreshold)
+	assert.Equal(t, time.Minute, cfg.HTTP.CircuitBreaker.OpenTimeout)
 	assert.Equal(t, "localhost:2379", cfg.APISource.Etcd.Servers)
 }
 
@@ -220,6 +238,36 @@ func TestVerifyConfig_FailLoadHandlers(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to load handlers")
 }
 
+func TestVerifyConfig_DerivSchemas(t *testing.T) {
+	ctx := context.Background()
+
+	path := createTempConfigFile(t, callsConfig)
+	schemaDir := t.TempDir()
+
+	err := os.Mkdir(filepath.Join(schemaDir, "time"), 0o755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(schemaDir, "time", "send.json"), []byte(`{"type": "object"}`), 0o600)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		APISource: source.Config{
+			Path: path,
+		},
+		Deriv: deriv.Config{
+			SchemaPath: schemaDir,
+		},
+	}
+
+	err = verifyConfig(ctx, cfg)
+	assert.ErrorContains(t, err, "invalid request template in method testMethod")
+
+	cfg.Deriv.SchemaPath = filepath.Join(schemaDir, "missing")
+
+	err = verifyConfig(ctx, cfg)
+	assert.ErrorContains(t, err, "failed to load Deriv API schemas")
+}
+
 func TestApplyArgsToConfig(t *testing.T) {
 	tests := []struct {
 		args   *args
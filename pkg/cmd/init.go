@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/secrets"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -87,11 +88,18 @@ func ServerCommand(arg *args) *cobra.Command {
 				return err
 			}
 
+			secretProv, err := secrets.New(&cfg.Secrets)
+			if err != nil {
+				return fmt.Errorf("failed to create secret provider: %w", err)
+			}
+
+			initLogRedaction(secretProv)
+
 			if err := initMetricProvider(cmd.Context(), &cfg.Otel); err != nil {
 				return err
 			}
 
-			return runServer(cmd.Context(), cfg)
+			return runServer(cmd.Context(), cfg, secretProv)
 		},
 	}
 }
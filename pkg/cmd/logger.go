@@ -3,6 +3,8 @@ package cmd
 import (
 	"log/slog"
 	"os"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/secrets"
 )
 
 // initLogger initializes the default logger for the application using slog.
@@ -34,3 +36,9 @@ func initLogger(arg *args) error {
 
 	return nil
 }
+
+// initLogRedaction wraps the default logger, so values resolved by the secret provider are redacted from logs.
+// It takes prov of type *secrets.Provider.
+func initLogRedaction(prov *secrets.Provider) {
+	slog.SetDefault(slog.New(secrets.NewRedactHandler(slog.Default().Handler(), prov)))
+}
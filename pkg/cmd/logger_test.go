@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"log/slog"
 	"testing"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/secrets"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInitLogger(t *testing.T) {
@@ -54,3 +58,27 @@ func TestInitLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestInitLogRedaction(t *testing.T) {
+	t.Setenv("BFF_TOKEN", "token-value")
+
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	var buf bytes.Buffer
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	prov, err := secrets.New(&secrets.Config{})
+	require.NoError(t, err)
+
+	initLogRedaction(prov)
+
+	_, err = prov.Env("BFF_TOKEN")
+	require.NoError(t, err)
+
+	slog.Info("request", slog.String("url", "/items?token=token-value"))
+
+	assert.Contains(t, buf.String(), "/items?token=[REDACTED]")
+	assert.NotContains(t, buf.String(), "token-value")
+}
@@ -6,30 +6,54 @@ import (
 
 	"github.com/ksysoev/deriv-api-bff/pkg/api"
 	"github.com/ksysoev/deriv-api-bff/pkg/config"
-	"github.com/ksysoev/deriv-api-bff/pkg/config/source"
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/handlerfactory"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/passthrough"
 	"github.com/ksysoev/deriv-api-bff/pkg/prov/deriv"
 	"github.com/ksysoev/deriv-api-bff/pkg/prov/http"
 	"github.com/ksysoev/deriv-api-bff/pkg/prov/router"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/secrets"
 	"github.com/ksysoev/deriv-api-bff/pkg/repo"
 )
 
 // runServer initializes and runs the server with the provided configuration.
-// It takes ctx of type context.Context and cfg of type *config.
+// It takes ctx of type context.Context, cfg of type *config and secretProv of type *secrets.Provider.
 // It returns an error if the request handler creation fails or if the server fails to run.
-func runServer(ctx context.Context, cfg *Config) error {
+// Secrets referenced by request templates are resolved with secretProv, which is reloaded until the context is canceled.
+func runServer(ctx context.Context, cfg *Config, secretProv *secrets.Provider) error {
+	go func() {
+		_ = secretProv.Run(ctx)
+	}()
+
+	schemas, err := derivSchemas(cfg)
+	if err != nil {
+		return err
+	}
+
+	policy, err := passthrough.New(&cfg.PassThrough, passthrough.WithCallParams(schemas))
+	if err != nil {
+		return fmt.Errorf("failed to create passthrough policy: %w", err)
+	}
+
+	httpAPI, err := http.NewService(&cfg.HTTP)
+	if err != nil {
+		return fmt.Errorf("failed to create http service: %w", err)
+	}
+
 	derivAPI := deriv.NewService(&cfg.Deriv)
 	connRegistry := repo.NewConnectionRegistry()
 	calls := repo.NewCallsRepository()
-	beRouter := router.New(derivAPI, http.NewService())
-	requestHandler := core.NewService(calls, beRouter, connRegistry)
+	beRouter := router.New(derivAPI, httpAPI)
+	requestHandler := core.NewService(calls, beRouter, connRegistry, core.WithPassThroughPolicy(policy))
 
-	sourceOpts, err := source.CreateOptions(&cfg.APISource)
+	cfgOpts, err := configOptions(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create config source: %w", err)
+		return err
 	}
 
-	cfgSvc, err := config.New(requestHandler, sourceOpts...)
+	cfgOpts = append(cfgOpts, config.WithHandlerOptions(handlerfactory.WithSecrets(secretProv)))
+
+	cfgSvc, err := config.New(requestHandler, cfgOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create config service: %w", err)
 	}
@@ -6,7 +6,11 @@ import (
 
 	"github.com/ksysoev/deriv-api-bff/pkg/api"
 	"github.com/ksysoev/deriv-api-bff/pkg/config/source"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/passthrough"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/http"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/secrets"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunServer(t *testing.T) {
@@ -24,7 +28,7 @@ func TestRunServer(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := runServer(ctx, cfg)
+	err := runServer(ctx, cfg, newSecretProvider(t, cfg))
 
 	assert.NoError(t, err)
 }
@@ -39,7 +43,7 @@ func TestRunServer_Error(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := runServer(ctx, cfg)
+	err := runServer(ctx, cfg, newSecretProvider(t, cfg))
 
 	assert.Error(t, err)
 }
@@ -79,7 +83,7 @@ func TestRunServer_FailToCreateService(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := runServer(ctx, cfg)
+	err := runServer(ctx, cfg, newSecretProvider(t, cfg))
 
 	assert.Error(t, err)
 	assert.Equal(t, err.Error(), "method 'chain' is repeated in multiple groups")
@@ -101,7 +105,53 @@ func TestRunServer_FailToCreateSources(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := runServer(ctx, cfg)
+	err := runServer(ctx, cfg, newSecretProvider(t, cfg))
 
 	assert.Error(t, err)
 }
+
+func TestRunServer_InvalidPassThroughPolicy(t *testing.T) {
+	cfg := &Config{
+		Server: api.Config{
+			Listen: ":0",
+		},
+		PassThrough: passthrough.Config{
+			Allow: []string{"ticks"},
+			Deny:  []string{"ticks"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runServer(ctx, cfg, newSecretProvider(t, cfg))
+
+	assert.ErrorContains(t, err, "failed to create passthrough policy")
+}
+
+func TestRunServer_InvalidHTTPConfig(t *testing.T) {
+	cfg := &Config{
+		Server: api.Config{
+			Listen: ":0",
+		},
+		HTTP: http.Config{
+			TLS: http.TLSConfig{CAFile: "/nonexistent/ca.crt"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runServer(ctx, cfg, newSecretProvider(t, cfg))
+
+	assert.ErrorContains(t, err, "failed to create http service")
+}
+
+func newSecretProvider(t *testing.T, cfg *Config) *secrets.Provider {
+	t.Helper()
+
+	prov, err := secrets.New(&cfg.Secrets)
+	require.NoError(t, err)
+
+	return prov
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 const (
 	defaultTimeoutSeconds = 5
 	defaultReducerTimeout = 1 * time.Second
+	// definitionsKey is the prefix of keys of definitions entries, which don't have a method to be keyed by.
+	definitionsKey = "_definitions/"
 )
 
 type EtcdConfig struct {
@@ -87,9 +90,11 @@ func (es *EtcdSource) LoadConfig(ctx context.Context) ([]handlerfactory.Config,
 // It takes a context ctx of type context.Context and a slice of handlerfactory.Config cfg.
 // It returns an error if marshalling the config fails or if putting the config into etcd fails.
 // The function creates a context with a timeout for each put operation.
+// API calls are stored under their method names, and definitions entries under _definitions/N keys.
 func (es *EtcdSource) PutConfig(ctx context.Context, cfg []handlerfactory.Config) error {
 	//TODO: add logic for removing keys that are not in the new config
 	indx := make(map[string]struct{}, len(cfg))
+	defs := 0
 
 	for _, c := range cfg {
 		data, err := json.Marshal(c)
@@ -97,8 +102,15 @@ func (es *EtcdSource) PutConfig(ctx context.Context, cfg []handlerfactory.Config
 			return fmt.Errorf("failed to marshal config: %w", err)
 		}
 
+		key := c.Method
+
+		if c.IsDefinitions() {
+			key = definitionsKey + strconv.Itoa(defs)
+			defs++
+		}
+
 		ctx, cancel := context.WithTimeout(ctx, defaultTimeoutSeconds*time.Second)
-		_, err = es.cli.Put(ctx, es.prefix+c.Method, string(data))
+		_, err = es.cli.Put(ctx, es.prefix+key, string(data))
 
 		cancel()
 
@@ -106,7 +118,7 @@ func (es *EtcdSource) PutConfig(ctx context.Context, cfg []handlerfactory.Config
 			return fmt.Errorf("failed to put config: %w", err)
 		}
 
-		indx[c.Method] = struct{}{}
+		indx[key] = struct{}{}
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, defaultTimeoutSeconds*time.Second)
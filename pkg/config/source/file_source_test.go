@@ -8,6 +8,7 @@ import (
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core/handlerfactory"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/processor"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -79,6 +80,36 @@ func TestReadFile(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "YAML file with definitions",
+			fileContent: `
+- definitions:
+    currency:
+      type: string
+- method: balance
+  params:
+    currency:
+      $ref: "#/definitions/currency"
+  backend:
+    - request:
+        balance: 1
+`,
+			expected: []handlerfactory.Config{
+				{
+					Definitions: map[string]any{"currency": map[string]any{"type": "string"}},
+				},
+				{
+					Method: "balance",
+					Params: &validator.Config{"currency": validator.Config{"$ref": "#/definitions/currency"}},
+					Backend: []*processor.Config{
+						{
+							Request: map[string]any{"balance": 1},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
 		{
 			name:        "Invalid YAML file",
 			fileContent: `invalid yaml content`,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"sync"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
@@ -32,6 +33,7 @@ type Service struct {
 	remote RemoteSource
 	cancel context.CancelFunc
 	curCfg []handlerfactory.Config
+	hOpts  []handlerfactory.Option
 	wg     sync.WaitGroup
 	mu     sync.Mutex
 }
@@ -56,6 +58,15 @@ func WithRemoteSource(remote RemoteSource) Option {
 	}
 }
 
+// WithHandlerOptions sets the options that are used to create handlers from the API configuration.
+// It takes a variadic number of handlerfactory.Option and returns an Option.
+// This function modifies the Service to pass the provided options to the handler factory.
+func WithHandlerOptions(opts ...handlerfactory.Option) Option {
+	return func(s *Service) {
+		s.hOpts = append(s.hOpts, opts...)
+	}
+}
+
 // New creates a new Service instance with the provided BFFService and optional configurations.
 // It takes a BFFService instance and a variadic number of Option functions to configure the Service.
 // It returns a pointer to the created Service and an error if neither local nor remote source is provided.
@@ -168,7 +179,7 @@ func (c *Service) processConfig(cfg []handlerfactory.Config) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	handlers, err := createHandlers(cfg)
+	handlers, err := createHandlers(cfg, c.hOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create handlers: %w", err)
 	}
@@ -228,14 +239,21 @@ func (c *Service) WriteConfig(ctx context.Context, path string) error {
 }
 
 // createHandlers initializes a map of handlers based on the provided configuration.
-// It takes a slice of handlerfactory.Config as input.
+// It takes a slice of handlerfactory.Config and a variadic number of handlerfactory.Option as input.
 // It returns a map where the keys are handler names (strings) and the values are core.Handler instances.
 // It returns an error if a handler cannot be created or if there are duplicate handler names in the configuration.
-func createHandlers(cfg []handlerfactory.Config) (map[string]core.Handler, error) {
-	handlers := make(map[string]core.Handler, len(cfg))
+// Shared schemas of definitions entries are compiled once and passed to all handlers.
+func createHandlers(cfg []handlerfactory.Config, opts ...handlerfactory.Option) (map[string]core.Handler, error) {
+	defs, calls, err := handlerfactory.Definitions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(slices.Clone(opts), handlerfactory.WithDefinitions(defs))
+	handlers := make(map[string]core.Handler, len(calls))
 
-	for _, c := range cfg {
-		name, handler, err := handlerfactory.New(c)
+	for _, c := range calls {
+		name, handler, err := handlerfactory.New(c, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create handler: %w", err)
 		}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/core/derivschema"
 	handlerfactory "github.com/ksysoev/deriv-api-bff/pkg/core/handlerfactory"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/processor"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -362,6 +365,34 @@ func TestCreateHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateHandlers_Definitions(t *testing.T) {
+	defs := handlerfactory.Config{
+		Definitions: map[string]any{"symbol": map[string]any{"type": "string", "enum": []string{"R_50", "R_100"}}},
+	}
+	call := handlerfactory.Config{
+		Method: "ticks",
+		Params: &validator.Config{"symbol": map[string]any{"$ref": "#/definitions/symbol"}},
+		Backend: []*processor.Config{
+			{Request: map[string]any{"ticks": "${params.symbol}"}},
+		},
+	}
+
+	handlers, err := createHandlers([]handlerfactory.Config{defs, call})
+	assert.NoError(t, err)
+	assert.Len(t, handlers, 1)
+	assert.Contains(t, handlers, "ticks")
+
+	_, err = createHandlers([]handlerfactory.Config{call})
+	assert.ErrorContains(t, err, "failed to create handler")
+
+	_, err = createHandlers([]handlerfactory.Config{defs, defs, call})
+	assert.ErrorContains(t, err, "definition symbol is declared more than once")
+
+	_, err = createHandlers([]handlerfactory.Config{{Definitions: map[string]any{"symbol": map[string]any{"type": "text"}}}})
+	assert.ErrorContains(t, err, "invalid definitions: invalid definition symbol")
+}
+
 func TestService_ProcessConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -426,6 +457,22 @@ func TestService_ProcessConfig(t *testing.T) {
 	}
 }
 
+func TestService_ProcessConfig_HandlerOptions(t *testing.T) {
+	mockBFFService := NewMockBFFService(t)
+	mockLocalSource := NewMockLocalSource(t)
+
+	schemas, err := derivschema.New(fstest.MapFS{"time/send.json": {Data: []byte(`{"type": "object"}`)}})
+	require.NoError(t, err)
+
+	svc, err := New(mockBFFService, WithLocalSource(mockLocalSource), WithHandlerOptions(handlerfactory.WithDerivSchemas(schemas)))
+	require.NoError(t, err)
+
+	err = svc.processConfig(validConfig)
+
+	assert.ErrorContains(t, err, "request doesn't contain any known Deriv API call")
+	assert.Nil(t, svc.curCfg)
+}
+
 func TestService_Start(t *testing.T) {
 	ctx := context.Background()
 
@@ -0,0 +1,350 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+)
+
+const (
+	ScopeGlobal     = "global"
+	ScopeConnection = "connection"
+
+	defaultMaxEntries = 1000
+)
+
+type Config struct {
+	TTL               string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Scope             string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	MaxEntries        int    `json:"max_entries,omitempty" yaml:"max_entries,omitempty"`
+	MaxBytes          int    `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+	HonorCacheControl bool   `json:"honor_cache_control,omitempty" yaml:"honor_cache_control,omitempty"`
+}
+
+type Parser func([]byte) (*response.Response, error)
+
+// Cache stores responses of a backend by the key derived from the rendered request.
+// Responses cached for the connection scope are evicted when their client connection is closed.
+type Cache struct {
+	lru               *LRU
+	now               func() time.Time
+	conns             map[string]map[string]struct{}
+	scope             string
+	ttl               time.Duration
+	mu                sync.Mutex
+	honorCacheControl bool
+}
+
+type storePolicy struct {
+	etag        string
+	ttl         time.Duration
+	store       bool
+	notModified bool
+}
+
+// Lookup is the result of looking up a rendered request in the cache.
+// It provides the request to send and stores the response once it's parsed.
+// Requests of the connection scope that are made without a client connection are not cached.
+type Lookup struct {
+	cache *Cache
+	req   core.Request
+	stale *entry
+	conn  *core.Conn
+	key   string
+	hit   bool
+}
+
+// New creates a new Cache based on the provided configuration.
+// It takes cfg of type *Config.
+// It returns a pointer to Cache and an error if the configuration is invalid.
+// If the maximum number of entries is not set, the cache is limited to 1000 entries.
+// If the scope is not set, responses are cached per client connection, because requests of authorized clients
+// can be identical while their responses are not, so sharing responses between clients must be requested explicitly.
+func New(cfg *Config) (*Cache, error) {
+	var ttl time.Duration
+
+	if cfg.TTL != "" {
+		var err error
+
+		if ttl, err = time.ParseDuration(cfg.TTL); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid cache ttl %q", cfg.TTL)
+		}
+	}
+
+	if ttl == 0 && !cfg.HonorCacheControl {
+		return nil, fmt.Errorf("cache ttl must be provided")
+	}
+
+	scope := cfg.Scope
+	if scope == "" {
+		scope = ScopeConnection
+	}
+
+	if scope != ScopeGlobal && scope != ScopeConnection {
+		return nil, fmt.Errorf("invalid cache scope %q", cfg.Scope)
+	}
+
+	if cfg.MaxEntries < 0 || cfg.MaxBytes < 0 {
+		return nil, fmt.Errorf("cache size limits must not be negative")
+	}
+
+	maxEntries := cfg.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	return &Cache{
+		lru:               NewLRU(maxEntries, cfg.MaxBytes),
+		now:               time.Now,
+		conns:             make(map[string]map[string]struct{}),
+		scope:             scope,
+		ttl:               ttl,
+		honorCacheControl: cfg.HonorCacheControl,
+	}, nil
+}
+
+// Lookup looks up the rendered request in the cache.
+// It takes ctx of type context.Context, reqID of type string which is the ID of the request waiter, and req of type core.Request.
+// It returns a pointer to Lookup.
+// On a cache hit, the request is replaced by a resolved request that carries the cached response.
+// If the cached response is expired but has an ETag, the HTTP request is made conditional to revalidate it.
+// For the connection scope, the cache is bypassed if ctx doesn't carry the client connection,
+// so the response is never shared between clients.
+func (c *Cache) Lookup(ctx context.Context, reqID string, req core.Request) *Lookup {
+	l := &Lookup{
+		cache: c,
+		req:   req,
+	}
+
+	if c.scope == ScopeConnection {
+		if l.conn = core.ConnFromContext(ctx); l.conn == nil {
+			return l
+		}
+	}
+
+	l.key = c.key(l.conn, reqID, req)
+
+	e, ok := c.lru.Get(l.key)
+	if !ok {
+		return l
+	}
+
+	if c.now().Before(e.expires) {
+		l.hit = true
+		l.req = request.NewResolvedReq(req.Context(), reqID, req.RoutingKey(), req.Data(), e.data)
+
+		return l
+	}
+
+	httpReq, ok := req.(*request.HTTPReq)
+	if !ok || e.etag == "" {
+		c.lru.Delete(l.key)
+		return l
+	}
+
+	httpReq.AddHeader("If-None-Match", e.etag)
+	l.stale = &e
+
+	return l
+}
+
+// key derives the cache key from the rendered request.
+// It takes conn of type *core.Conn, which is nil for the global scope, reqID of type string and req of type core.Request.
+// It returns the key as a string.
+// The request ID is removed from the request, so identical requests of different waiters share the key.
+// For the connection scope, the key includes the ID of the client connection.
+// For HTTP requests, the key includes the headers, the authentication provider and the signer.
+func (c *Cache) key(conn *core.Conn, reqID string, req core.Request) string {
+	h := sha256.New()
+
+	if conn != nil {
+		h.Write([]byte(conn.ID()))
+	}
+
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ReplaceAll(req.RoutingKey(), reqID, "")))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ReplaceAll(string(req.Data()), reqID, "")))
+
+	if httpReq, ok := req.(*request.HTTPReq); ok {
+		h.Write([]byte{0})
+		h.Write([]byte(httpReq.Auth()))
+		h.Write([]byte{0})
+		h.Write([]byte(httpReq.Signer()))
+
+		headers := httpReq.Headers()
+		names := make([]string, 0, len(headers))
+
+		for name := range headers {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		for _, name := range names {
+			h.Write([]byte{0})
+			h.Write([]byte(name + ":" + strings.ReplaceAll(strings.Join(headers[name], ","), reqID, "")))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Request returns the request to send, which is a resolved request on a cache hit.
+func (l *Lookup) Request() core.Request {
+	return l.req
+}
+
+// Parse parses the response with the provided parser and stores it in the cache if it's parsed successfully.
+// It takes parser of type Parser and data of type []byte.
+// It returns a pointer to response.Response and an error if the parser fails.
+// If the response confirms that the stale cached response is still valid, the cached response is parsed and refreshed.
+func (l *Lookup) Parse(parser Parser, data []byte) (*response.Response, error) {
+	if l.hit || l.key == "" {
+		return parser(data)
+	}
+
+	p := l.cache.policy(l, data)
+
+	if p.notModified {
+		data = l.stale.data
+	}
+
+	resp, err := parser(data)
+	if err != nil || !p.store {
+		return resp, err
+	}
+
+	l.cache.lru.Set(entry{
+		key:     l.key,
+		data:    data,
+		etag:    p.etag,
+		expires: l.cache.now().Add(p.ttl),
+	})
+
+	if l.conn != nil {
+		l.cache.track(l.conn, l.key)
+	}
+
+	return resp, nil
+}
+
+// track remembers the key of the response cached for the client connection.
+// It takes conn of type *core.Conn and key of type string.
+// On the first response of the connection, it starts watching the connection to evict its responses once it's closed.
+func (c *Cache) track(conn *core.Conn, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys, ok := c.conns[conn.ID()]
+	if !ok {
+		keys = make(map[string]struct{})
+		c.conns[conn.ID()] = keys
+
+		go c.evictOnClose(conn)
+	}
+
+	keys[key] = struct{}{}
+}
+
+// evictOnClose waits for the client connection to be closed and evicts all responses cached for it.
+// It takes conn of type *core.Conn.
+func (c *Cache) evictOnClose(conn *core.Conn) {
+	<-conn.Context().Done()
+
+	c.mu.Lock()
+	keys := c.conns[conn.ID()]
+	delete(c.conns, conn.ID())
+	c.mu.Unlock()
+
+	for key := range keys {
+		c.lru.Delete(key)
+	}
+}
+
+// policy determines how the response is cached.
+// It takes l of type *Lookup and data of type []byte.
+// It returns a storePolicy for the response.
+// Cache-Control and ETag headers are taken into account only for HTTP responses and only if enabled in the configuration.
+func (c *Cache) policy(l *Lookup, data []byte) storePolicy {
+	if !c.honorCacheControl {
+		return storePolicy{ttl: c.ttl, store: true}
+	}
+
+	if _, ok := l.req.(*request.HTTPReq); !ok {
+		return storePolicy{ttl: c.ttl, store: true}
+	}
+
+	resp, err := response.DecodeHTTPResp(data)
+	if err != nil {
+		return storePolicy{}
+	}
+
+	headers := http.Header(resp.Headers())
+	ttl, store := c.cacheControl(headers.Get("Cache-Control"))
+
+	if resp.StatusCode() == http.StatusNotModified {
+		if l.stale == nil {
+			return storePolicy{}
+		}
+
+		return storePolicy{ttl: ttl, etag: l.stale.etag, store: store, notModified: true}
+	}
+
+	etag := headers.Get("ETag")
+
+	return storePolicy{ttl: ttl, etag: etag, store: store && (ttl > 0 || etag != "")}
+}
+
+// cacheControl parses the Cache-Control header of the response.
+// It takes value of type string.
+// It returns the TTL of the response and false if the response must not be stored.
+// The configured TTL is used if the header doesn't define the max age, and shared max age takes precedence for the global scope.
+func (c *Cache) cacheControl(value string) (time.Duration, bool) {
+	ttl := c.ttl
+	maxAge, sharedMaxAge := -1, -1
+
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+
+		switch name {
+		case "no-store":
+			return 0, false
+		case "private":
+			if c.scope == ScopeGlobal {
+				return 0, false
+			}
+		case "no-cache":
+			maxAge = 0
+		case "max-age":
+			if v, err := strconv.Atoi(strings.Trim(arg, `"`)); err == nil && maxAge != 0 {
+				maxAge = v
+			}
+		case "s-maxage":
+			if v, err := strconv.Atoi(strings.Trim(arg, `"`)); err == nil {
+				sharedMaxAge = v
+			}
+		}
+	}
+
+	if sharedMaxAge >= 0 && c.scope == ScopeGlobal && maxAge != 0 {
+		maxAge = sharedMaxAge
+	}
+
+	if maxAge >= 0 {
+		ttl = time.Duration(maxAge) * time.Second
+	}
+
+	return ttl, true
+}
@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/ksysoev/wasabi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(t *testing.T, cfg *Config) (*Cache, *fakeClock) {
+	t.Helper()
+
+	c, err := New(cfg)
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: time.Now()}
+	c.now = clock.Now
+
+	return c, clock
+}
+
+func parseBody(data []byte) (*response.Response, error) {
+	return response.New(data, nil), nil
+}
+
+func encodeHTTPResp(t *testing.T, status int, headers http.Header, body string) []byte {
+	t.Helper()
+
+	data, err := response.NewHTTPResp(status, headers, []byte(body)).Encode()
+	require.NoError(t, err)
+
+	return data
+}
+
+// newConnContext creates a context that carries a client connection with the given ID.
+// It returns the context and the function that closes the connection.
+func newConnContext(t *testing.T, id string) (context.Context, context.CancelFunc) {
+	t.Helper()
+
+	connCtx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	conn := mocks.NewMockConnection(t)
+	conn.EXPECT().ID().Return(id).Maybe()
+	conn.EXPECT().Context().Return(connCtx).Maybe()
+
+	return core.ContextWithConn(context.Background(), core.NewConnection(conn, func(string) {})), cancel
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		cfg     *Config
+		name    string
+		wantErr string
+	}{
+		{name: "Valid config", cfg: &Config{TTL: "1m", Scope: ScopeConnection, MaxEntries: 10, MaxBytes: 1024}},
+		{name: "Cache-Control without TTL", cfg: &Config{HonorCacheControl: true}},
+		{name: "Missing TTL", cfg: &Config{}, wantErr: "cache ttl must be provided"},
+		{name: "Invalid TTL", cfg: &Config{TTL: "1 minute"}, wantErr: "invalid cache ttl \"1 minute\""},
+		{name: "Negative TTL", cfg: &Config{TTL: "-1m"}, wantErr: "invalid cache ttl \"-1m\""},
+		{name: "Invalid scope", cfg: &Config{TTL: "1m", Scope: "session"}, wantErr: "invalid cache scope \"session\""},
+		{name: "Negative limits", cfg: &Config{TTL: "1m", MaxEntries: -1}, wantErr: "cache size limits must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.cfg)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, c)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, c)
+			}
+		})
+	}
+}
+
+func TestCache_Lookup(t *testing.T) {
+	c, clock := newTestCache(t, &Config{TTL: "1m"})
+	ctx, _ := newConnContext(t, "conn1")
+
+	req := request.NewRequest(ctx, request.TextMessage, []byte(`{"website_status":1,"passthrough":{"_bff":{"req_id":"id1"}}}`))
+
+	l := c.Lookup(ctx, "id1", req)
+	assert.Same(t, req, l.Request())
+
+	resp, err := l.Parse(parseBody, []byte(`{"website_status":{},"passthrough":{"_bff":{"req_id":"id1"}}}`))
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+
+	req = request.NewRequest(ctx, request.TextMessage, []byte(`{"website_status":1,"passthrough":{"_bff":{"req_id":"id2"}}}`))
+
+	l = c.Lookup(ctx, "id2", req)
+
+	resolved, ok := l.Request().(core.ResolvedRequest)
+	require.True(t, ok)
+	assert.Equal(t, "id2", resolved.ID())
+	assert.Equal(t, []byte(`{"website_status":{},"passthrough":{"_bff":{"req_id":"id1"}}}`), resolved.Response())
+
+	_, err = l.Parse(parseBody, resolved.Response())
+	assert.NoError(t, err)
+
+	clock.now = clock.now.Add(time.Minute)
+
+	req = request.NewRequest(ctx, request.TextMessage, []byte(`{"website_status":1,"passthrough":{"_bff":{"req_id":"id3"}}}`))
+
+	l = c.Lookup(ctx, "id3", req)
+	assert.Same(t, req, l.Request())
+	assert.Equal(t, 0, c.lru.Len())
+}
+
+func TestCache_Lookup_ParseError(t *testing.T) {
+	c, _ := newTestCache(t, &Config{TTL: "1m"})
+	ctx := context.Background()
+
+	req := request.NewRequest(ctx, request.TextMessage, []byte(`{"ping":1}`))
+
+	_, err := c.Lookup(ctx, "id1", req).Parse(func([]byte) (*response.Response, error) {
+		return nil, assert.AnError
+	}, []byte(`{"error":{}}`))
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 0, c.lru.Len())
+}
+
+func TestCache_Key(t *testing.T) {
+	ctx := context.Background()
+	ctx1, _ := newConnContext(t, "conn1")
+	ctx2, _ := newConnContext(t, "conn2")
+	conn1, conn2 := core.ConnFromContext(ctx1), core.ConnFromContext(ctx2)
+
+	global, _ := newTestCache(t, &Config{TTL: "1m", Scope: ScopeGlobal})
+	perConn, _ := newTestCache(t, &Config{TTL: "1m"})
+
+	req1 := request.NewHTTPReq(ctx, "GET", "http://localhost/id1", nil, "id1")
+	req1.AddHeader("Authorization", "token")
+
+	req2 := request.NewHTTPReq(ctx, "GET", "http://localhost/id2", nil, "id2")
+	req2.AddHeader("Authorization", "token")
+
+	req3 := request.NewHTTPReq(ctx, "GET", "http://localhost/id3", nil, "id3")
+	req3.AddHeader("Authorization", "other")
+
+	assert.Equal(t, global.key(nil, "id1", req1), global.key(nil, "id2", req2))
+	assert.NotEqual(t, global.key(nil, "id1", req1), global.key(nil, "id3", req3))
+	assert.NotEqual(t, perConn.key(conn1, "id1", req1), perConn.key(conn2, "id2", req2))
+
+	req4 := request.NewHTTPReq(ctx, "GET", "http://localhost/id4", nil, "id4")
+	req4.AddHeader("Authorization", "token")
+	req4.SetAuth("billing")
+
+	assert.NotEqual(t, global.key(nil, "id1", req1), global.key(nil, "id4", req4))
+}
+
+func TestCache_ConnectionScope(t *testing.T) {
+	c, _ := newTestCache(t, &Config{TTL: "1m"})
+
+	noConn := context.Background()
+	l := c.Lookup(noConn, "id1", request.NewRequest(noConn, request.TextMessage, []byte(`{"balance":1}`)))
+
+	_, err := l.Parse(parseBody, []byte(`{"balance":{"balance":10}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.lru.Len())
+
+	ctx1, close1 := newConnContext(t, "conn1")
+	ctx2, _ := newConnContext(t, "conn2")
+
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		l = c.Lookup(ctx, "id1", request.NewRequest(ctx, request.TextMessage, []byte(`{"balance":1}`)))
+
+		_, err = l.Parse(parseBody, []byte(`{"balance":{"balance":10}}`))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, c.lru.Len())
+
+	close1()
+
+	assert.Eventually(t, func() bool { return c.lru.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, ok := c.Lookup(ctx2, "id2", request.NewRequest(ctx2, request.TextMessage, []byte(`{"balance":1}`))).Request().(core.ResolvedRequest)
+	assert.True(t, ok)
+}
+
+func TestCache_CacheControl(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		scope     string
+		wantTTL   time.Duration
+		wantStore bool
+	}{
+		{name: "No header", scope: ScopeGlobal, wantTTL: time.Minute, wantStore: true},
+		{name: "Max age", header: "public, max-age=30", scope: ScopeGlobal, wantTTL: 30 * time.Second, wantStore: true},
+		{name: "Shared max age", header: "max-age=30, s-maxage=60", scope: ScopeGlobal, wantTTL: time.Minute, wantStore: true},
+		{name: "Shared max age for connection scope", header: "max-age=30, s-maxage=60", scope: ScopeConnection, wantTTL: 30 * time.Second, wantStore: true},
+		{name: "No cache", header: "no-cache, max-age=30", scope: ScopeGlobal, wantTTL: 0, wantStore: true},
+		{name: "No store", header: "no-store", scope: ScopeGlobal},
+		{name: "Private", header: "private, max-age=30", scope: ScopeGlobal},
+		{name: "Private for connection scope", header: "private, max-age=30", scope: ScopeConnection, wantTTL: 30 * time.Second, wantStore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCache(t, &Config{TTL: "1m", Scope: tt.scope, HonorCacheControl: true})
+
+			ttl, store := c.cacheControl(tt.header)
+
+			assert.Equal(t, tt.wantStore, store)
+			assert.Equal(t, tt.wantTTL, ttl)
+		})
+	}
+}
+
+func TestCache_HTTPRevalidation(t *testing.T) {
+	c, clock := newTestCache(t, &Config{HonorCacheControl: true})
+	ctx, _ := newConnContext(t, "conn1")
+
+	req := request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, "id1")
+	l := c.Lookup(ctx, "id1", req)
+
+	original := encodeHTTPResp(t, http.StatusOK, http.Header{"Etag": {`"v1"`}, "Cache-Control": {"max-age=10"}}, `{"a":1}`)
+
+	_, err := l.Parse(parseBody, original)
+	require.NoError(t, err)
+
+	_, ok := c.Lookup(ctx, "id2", request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, "id2")).Request().(core.ResolvedRequest)
+	assert.True(t, ok)
+
+	clock.now = clock.now.Add(11 * time.Second)
+
+	req = request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, "id3")
+	l = c.Lookup(ctx, "id3", req)
+
+	assert.Same(t, req, l.Request())
+	assert.Equal(t, []string{`"v1"`}, req.Headers()["If-None-Match"])
+
+	var parsed []byte
+
+	_, err = l.Parse(func(data []byte) (*response.Response, error) {
+		parsed = data
+		return response.New(data, nil), nil
+	}, encodeHTTPResp(t, http.StatusNotModified, http.Header{"Cache-Control": {"max-age=10"}}, ""))
+	require.NoError(t, err)
+
+	assert.Equal(t, original, parsed)
+
+	l = c.Lookup(ctx, "id4", request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, "id4"))
+
+	resolved, ok := l.Request().(core.ResolvedRequest)
+	require.True(t, ok)
+	assert.Equal(t, original, resolved.Response())
+}
+
+func TestCache_HTTPNotCacheable(t *testing.T) {
+	c, _ := newTestCache(t, &Config{HonorCacheControl: true})
+	ctx, _ := newConnContext(t, "conn1")
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "No store", data: encodeHTTPResp(t, http.StatusOK, http.Header{"Cache-Control": {"no-store"}}, `{}`)},
+		{name: "No TTL and ETag", data: encodeHTTPResp(t, http.StatusOK, nil, `{}`)},
+		{name: "Not modified without cached response", data: encodeHTTPResp(t, http.StatusNotModified, nil, ``)},
+		{name: "Invalid envelope", data: []byte(`{}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := c.Lookup(ctx, "id1", request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, "id1"))
+
+			_, err := l.Parse(parseBody, tt.data)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 0, c.lru.Len())
+		})
+	}
+}
+
+func TestCache_DerivIgnoresCacheControl(t *testing.T) {
+	c, _ := newTestCache(t, &Config{TTL: "1m", HonorCacheControl: true})
+	ctx, _ := newConnContext(t, "conn1")
+
+	data, err := json.Marshal(map[string]any{"ping": "pong"})
+	require.NoError(t, err)
+
+	l := c.Lookup(ctx, "id1", request.NewRequest(ctx, request.TextMessage, []byte(`{"ping":1}`)))
+
+	_, err = l.Parse(parseBody, data)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, c.lru.Len())
+}
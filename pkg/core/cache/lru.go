@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	expires time.Time
+	key     string
+	etag    string
+	data    []byte
+}
+
+// LRU is an in-memory cache that evicts the least recently used entries
+// when it exceeds the maximum number of entries or the maximum total size of data.
+type LRU struct {
+	items      map[string]*list.Element
+	ll         *list.List
+	maxEntries int
+	maxBytes   int
+	size       int
+	mu         sync.Mutex
+}
+
+// NewLRU creates a new LRU cache.
+// It takes maxEntries of type int and maxBytes of type int, where zero means no limit.
+// It returns a pointer to LRU.
+func NewLRU(maxEntries, maxBytes int) *LRU {
+	return &LRU{
+		items:      make(map[string]*list.Element),
+		ll:         list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+// Get returns the entry stored by the key and marks it as recently used.
+// It takes key of type string.
+// It returns a copy of the entry and true if it's found.
+func (c *LRU) Get(key string) (entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	c.ll.MoveToFront(el)
+
+	e, _ := el.Value.(*entry)
+
+	return *e, true
+}
+
+// Set stores the entry, replacing the existing entry with the same key, and evicts entries over the limits.
+// It takes e of type entry.
+// Entries that are larger than the size limit are not stored.
+func (c *LRU) Set(e entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[e.key]; ok {
+		c.remove(el)
+	}
+
+	if c.maxBytes > 0 && len(e.data) > c.maxBytes {
+		return
+	}
+
+	c.items[e.key] = c.ll.PushFront(&e)
+	c.size += len(e.data)
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries || c.maxBytes > 0 && c.size > c.maxBytes {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Delete removes the entry stored by the key.
+// It takes key of type string.
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// remove removes the list element from the cache, the caller must hold the lock.
+// It takes el of type *list.Element.
+func (c *LRU) remove(el *list.Element) {
+	e, _ := c.ll.Remove(el).(*entry)
+
+	delete(c.items, e.key)
+	c.size -= len(e.data)
+}
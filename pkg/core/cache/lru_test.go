@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_Get(t *testing.T) {
+	c := NewLRU(2, 0)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Set(entry{key: "a", data: []byte("1")})
+	c.Set(entry{key: "a", data: []byte("2")})
+
+	e, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), e.data)
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 1, c.size)
+}
+
+func TestLRU_MaxEntries(t *testing.T) {
+	c := NewLRU(2, 0)
+
+	c.Set(entry{key: "a", data: []byte("1")})
+	c.Set(entry{key: "b", data: []byte("2")})
+
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Set(entry{key: "c", data: []byte("3")})
+
+	assert.Equal(t, 2, c.Len())
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+}
+
+func TestLRU_MaxBytes(t *testing.T) {
+	c := NewLRU(0, 5)
+
+	c.Set(entry{key: "a", data: []byte("12")})
+	c.Set(entry{key: "b", data: []byte("34")})
+	c.Set(entry{key: "c", data: []byte("56")})
+
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 4, c.size)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Set(entry{key: "d", data: []byte("123456")})
+
+	_, ok = c.Get("d")
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Delete(t *testing.T) {
+	c := NewLRU(0, 0)
+
+	c.Set(entry{key: "a", data: []byte("1")})
+	c.Delete("a")
+	c.Delete("b")
+
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 0, c.size)
+}
@@ -17,9 +17,28 @@ type Conn struct {
 	mu         sync.Mutex
 }
 
+// CorrelationKey is the key in the passthrough object of upstream requests that holds internal correlation data.
+// It's namespaced to avoid collisions with passthrough data of clients and request templates.
+const CorrelationKey = "_bff"
+
+// ConnInfo is data of the client connection, which is provided to request templates as `conn`.
+type ConnInfo struct {
+	Headers map[string]string `json:"headers"`
+	Query   map[string]string `json:"query"`
+	ID      string            `json:"id"`
+	IP      string            `json:"ip"`
+	Lang    string            `json:"lang"`
+}
+
+type connKey struct{}
+
+type connInfoKey struct{}
+
 type respID struct {
 	Passthrough struct {
-		ReqID string `json:"req_id"`
+		Correlation struct {
+			ReqID string `json:"req_id"`
+		} `json:"_bff"`
 	} `json:"passthrough"`
 }
 
@@ -39,6 +58,64 @@ func NewConnection(conn wasabi.Connection, onClose func(id string)) *Conn {
 	}
 }
 
+// ContextWithConn returns a copy of ctx that carries the client connection.
+// It takes ctx of type context.Context and conn of type *Conn.
+// It returns a context.Context.
+func ContextWithConn(ctx context.Context, conn *Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// ConnFromContext returns the client connection stored in ctx.
+// It takes ctx of type context.Context.
+// It returns a pointer to Conn or nil if ctx doesn't carry a connection.
+func ConnFromContext(ctx context.Context) *Conn {
+	conn, _ := ctx.Value(connKey{}).(*Conn)
+	return conn
+}
+
+// ContextWithConnInfo returns a copy of ctx that carries data of the client connection.
+// It takes ctx of type context.Context and info of type *ConnInfo.
+// It returns a context.Context.
+func ContextWithConnInfo(ctx context.Context, info *ConnInfo) context.Context {
+	return context.WithValue(ctx, connInfoKey{}, info)
+}
+
+// ConnInfoFromContext returns data of the client connection stored in ctx.
+// It takes ctx of type context.Context.
+// It returns a pointer to ConnInfo or nil if ctx doesn't carry it.
+func ConnInfoFromContext(ctx context.Context) *ConnInfo {
+	if ctx == nil {
+		return nil
+	}
+
+	info, _ := ctx.Value(connInfoKey{}).(*ConnInfo)
+
+	return info
+}
+
+// Info returns data of the connection for request templates.
+// It returns a pointer to ConnInfo with the ID of the connection and data captured when the connection was established.
+// Headers and query parameters are empty if no data was captured.
+func (c *Conn) Info() *ConnInfo {
+	info := ConnInfo{}
+
+	if captured := ConnInfoFromContext(c.Context()); captured != nil {
+		info = *captured
+	}
+
+	info.ID = c.ID()
+
+	if info.Headers == nil {
+		info.Headers = make(map[string]string)
+	}
+
+	if info.Query == nil {
+		info.Query = make(map[string]string)
+	}
+
+	return &info
+}
+
 // ID returns the unique identifier of the connection.
 // It returns a string which is the ID of the client connection.
 func (c *Conn) ID() string {
@@ -69,7 +146,8 @@ func (c *Conn) WaitResponse() (reqID string, respChan <-chan []byte) {
 // It takes msgType of type wasabi.MessageType and msg of type []byte.
 // It returns an error if the message cannot be unmarshaled into the expected format or if there is an issue sending the message.
 // If the message type is binary, it sends the message directly.
-// If the message contains a req_id, it handles the request-response mechanism by sending the message to the appropriate channel.
+// If the message contains an internal correlation id in its passthrough object, it handles the request-response mechanism
+// by sending the message to the appropriate channel. Other passthrough data is never used for correlation.
 func (c *Conn) Send(msgType wasabi.MessageType, msg []byte) error {
 	if msgType == wasabi.MsgTypeBinary {
 		return c.clientConn.Send(msgType, msg)
@@ -81,11 +159,12 @@ func (c *Conn) Send(msgType wasabi.MessageType, msg []byte) error {
 		return c.clientConn.Send(msgType, msg)
 	}
 
-	if resp.Passthrough.ReqID == "" {
+	reqID := resp.Passthrough.Correlation.ReqID
+	if reqID == "" {
 		return c.clientConn.Send(msgType, msg)
 	}
 
-	if c.DoneRequest(resp.Passthrough.ReqID, msg) {
+	if c.DoneRequest(reqID, msg) {
 		return nil
 	}
 
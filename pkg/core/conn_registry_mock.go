@@ -22,17 +22,17 @@ func (_m *MockConnRegistry) EXPECT() *MockConnRegistry_Expecter {
 	return &MockConnRegistry_Expecter{mock: &_m.Mock}
 }
 
-// GetConnection provides a mock function with given fields: _a0
-func (_m *MockConnRegistry) GetConnection(_a0 wasabi.Connection) *Conn {
-	ret := _m.Called(_a0)
+// GetConnection provides a mock function with given fields: clientConn, wrap
+func (_m *MockConnRegistry) GetConnection(clientConn wasabi.Connection, wrap ConnWrapper) *Conn {
+	ret := _m.Called(clientConn, wrap)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetConnection")
 	}
 
 	var r0 *Conn
-	if rf, ok := ret.Get(0).(func(wasabi.Connection) *Conn); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(wasabi.Connection, ConnWrapper) *Conn); ok {
+		r0 = rf(clientConn, wrap)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*Conn)
@@ -48,14 +48,15 @@ type MockConnRegistry_GetConnection_Call struct {
 }
 
 // GetConnection is a helper method to define mock.On call
-//   - _a0 wasabi.Connection
-func (_e *MockConnRegistry_Expecter) GetConnection(_a0 interface{}) *MockConnRegistry_GetConnection_Call {
-	return &MockConnRegistry_GetConnection_Call{Call: _e.mock.On("GetConnection", _a0)}
+//   - clientConn wasabi.Connection
+//   - wrap ConnWrapper
+func (_e *MockConnRegistry_Expecter) GetConnection(clientConn interface{}, wrap interface{}) *MockConnRegistry_GetConnection_Call {
+	return &MockConnRegistry_GetConnection_Call{Call: _e.mock.On("GetConnection", clientConn, wrap)}
 }
 
-func (_c *MockConnRegistry_GetConnection_Call) Run(run func(_a0 wasabi.Connection)) *MockConnRegistry_GetConnection_Call {
+func (_c *MockConnRegistry_GetConnection_Call) Run(run func(clientConn wasabi.Connection, wrap ConnWrapper)) *MockConnRegistry_GetConnection_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(wasabi.Connection))
+		run(args[0].(wasabi.Connection), args[1].(ConnWrapper))
 	})
 	return _c
 }
@@ -65,7 +66,7 @@ func (_c *MockConnRegistry_GetConnection_Call) Return(_a0 *Conn) *MockConnRegist
 	return _c
 }
 
-func (_c *MockConnRegistry_GetConnection_Call) RunAndReturn(run func(wasabi.Connection) *Conn) *MockConnRegistry_GetConnection_Call {
+func (_c *MockConnRegistry_GetConnection_Call) RunAndReturn(run func(wasabi.Connection, ConnWrapper) *Conn) *MockConnRegistry_GetConnection_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -56,6 +56,36 @@ func TestConn_Context(t *testing.T) {
 	assert.Equal(t, expectedCtx, actualCtx)
 }
 
+func TestConn_Info(t *testing.T) {
+	captured := &ConnInfo{
+		Headers: map[string]string{"User-Agent": "test-agent"},
+		Query:   map[string]string{"app_id": "1089"},
+		IP:      "1.2.3.4",
+	}
+
+	mockConn := mocks.NewMockConnection(t)
+	mockConn.EXPECT().ID().Return("conn-1")
+	mockConn.EXPECT().Context().Return(ContextWithConnInfo(context.Background(), captured))
+
+	info := NewConnection(mockConn, func(_ string) {}).Info()
+
+	assert.Equal(t, &ConnInfo{
+		Headers: map[string]string{"User-Agent": "test-agent"},
+		Query:   map[string]string{"app_id": "1089"},
+		ID:      "conn-1",
+		IP:      "1.2.3.4",
+	}, info)
+	assert.Empty(t, captured.ID)
+
+	mockConn = mocks.NewMockConnection(t)
+	mockConn.EXPECT().ID().Return("conn-2")
+	mockConn.EXPECT().Context().Return(context.Background())
+
+	info = NewConnection(mockConn, func(_ string) {}).Info()
+
+	assert.Equal(t, &ConnInfo{Headers: map[string]string{}, Query: map[string]string{}, ID: "conn-2"}, info)
+}
+
 func TestConn_WaitResponse(t *testing.T) {
 	mockConn := mocks.NewMockConnection(t)
 	conn := NewConnection(mockConn, func(_ string) {})
@@ -121,7 +151,7 @@ func TestConn_Send(t *testing.T) {
 	t.Run("Send non-binary message with req_id", func(t *testing.T) {
 		msgType := wasabi.MsgTypeText
 		reqID := "testID"
-		msg := []byte(`{"passthrough":{"req_id":"testID"},"data":"test"}`)
+		msg := []byte(`{"passthrough":{"_bff":{"req_id":"testID"}},"data":"test"}`)
 		respChan := make(chan []byte, 1)
 
 		conn := NewConnection(mockConn, func(_ string) {})
@@ -136,6 +166,45 @@ func TestConn_Send(t *testing.T) {
 		assert.False(t, exists)
 	})
 
+	t.Run("Send message with client passthrough req_id", func(t *testing.T) {
+		msgType := wasabi.MsgTypeText
+		reqID := "testID"
+		msg := []byte(`{"passthrough":{"req_id":"testID"},"data":"test"}`)
+		respChan := make(chan []byte, 1)
+
+		mockConn.EXPECT().Send(msgType, msg).Return(nil)
+
+		conn := NewConnection(mockConn, func(_ string) {})
+		conn.requests[reqID] = respChan
+
+		err := conn.Send(msgType, msg)
+
+		assert.NoError(t, err)
+		assert.Empty(t, respChan)
+
+		_, exists := conn.requests[reqID]
+		assert.True(t, exists)
+	})
+
+	t.Run("Send message with both client and internal passthrough", func(t *testing.T) {
+		msgType := wasabi.MsgTypeText
+		reqID := "testID"
+		msg := []byte(`{"passthrough":{"req_id":"clientID","key":"value","_bff":{"req_id":"testID"}},"data":"test"}`)
+		respChan := make(chan []byte, 1)
+
+		conn := NewConnection(mockConn, func(_ string) {})
+		conn.requests[reqID] = respChan
+		conn.requests["clientID"] = make(chan []byte, 1)
+
+		err := conn.Send(msgType, msg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, msg, <-respChan)
+
+		_, exists := conn.requests["clientID"]
+		assert.True(t, exists)
+	})
+
 	t.Run("Send incorrect message", func(t *testing.T) {
 		msgType := wasabi.MsgTypeText
 		reqID := "testID"
@@ -164,3 +233,10 @@ func TestConn_Send(t *testing.T) {
 		mockConn.AssertExpectations(t)
 	})
 }
+
+func TestConnFromContext(t *testing.T) {
+	conn := NewConnection(mocks.NewMockConnection(t), func(_ string) {})
+
+	assert.Nil(t, ConnFromContext(context.Background()))
+	assert.Same(t, conn, ConnFromContext(ContextWithConn(context.Background(), conn)))
+}
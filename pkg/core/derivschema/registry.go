@@ -0,0 +1,285 @@
+package derivschema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	schemaFile         = "send.json"
+	placeholderPrefix  = "${"
+	actionPrefix       = "{{"
+	bundledSchemasRoot = "schemas"
+)
+
+//go:embed schemas
+var bundledFS embed.FS
+
+var (
+	bundledOnce sync.Once
+	bundled     *Registry
+)
+
+type Registry struct {
+	schemas map[string]*jsonschema.Schema
+	strict  bool
+}
+
+// New creates a new Registry from Deriv API request schemas stored in the provided file system.
+// It takes fsys of type fs.FS which must follow Deriv API docs layout, where each call has its own
+// directory with a send.json file in it, e.g. ticks/send.json.
+// It returns a pointer to Registry and an error.
+// It returns an error if the file system can't be read or if any of the schemas fails to compile.
+// Registries created with New are strict: requests that don't contain any known call are rejected.
+func New(fsys fs.FS) (*Registry, error) {
+	files, err := fs.Glob(fsys, path.Join("*", schemaFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no request schemas found")
+	}
+
+	c := jsonschema.NewCompiler()
+	calls := make(map[string]string, len(files))
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %s: %w", file, err)
+		}
+
+		url := "file:///" + file
+
+		if err := c.AddResource(url, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to add schema %s: %w", file, err)
+		}
+
+		calls[path.Dir(file)] = url
+	}
+
+	r := &Registry{
+		schemas: make(map[string]*jsonschema.Schema, len(calls)),
+		strict:  true,
+	}
+
+	for call, url := range calls {
+		schema, err := c.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile schema for %s: %w", call, err)
+		}
+
+		r.schemas[call] = schema
+	}
+
+	return r, nil
+}
+
+// LoadDir creates a new Registry from Deriv API request schemas stored in the directory at the given path.
+// It takes dir of type string which is the path to the directory with schemas.
+// It returns a pointer to Registry and an error.
+// It returns an error if the directory doesn't exist or schemas can't be loaded.
+func LoadDir(dir string) (*Registry, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access schema directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("schema path %s is not a directory", dir)
+	}
+
+	return New(os.DirFS(dir))
+}
+
+// Bundled returns the Registry with request schemas of common Deriv API calls embedded into the binary.
+// The bundled registry is not strict: requests to calls without a bundled schema are not validated.
+// It panics if bundled schemas can't be compiled, which indicates a bug in the bundled schemas.
+func Bundled() *Registry {
+	bundledOnce.Do(func() {
+		fsys, err := fs.Sub(bundledFS, bundledSchemasRoot)
+		if err != nil {
+			panic(fmt.Sprintf("failed to open bundled schemas: %v", err))
+		}
+
+		r, err := New(fsys)
+		if err != nil {
+			panic(fmt.Sprintf("failed to load bundled schemas: %v", err))
+		}
+
+		r.strict = false
+		bundled = r
+	})
+
+	return bundled
+}
+
+// Validate checks the Deriv API request template against the schema of the call it targets.
+// It takes req of type map[string]any which is the request template from backend configuration.
+// It returns an error describing all mismatches if the template doesn't conform to the schema.
+// The call is identified by top-level keys of the template that have a known schema. If several calls match,
+// the template is valid if it conforms to any of them. Values containing placeholders are not checked,
+// because their real values are known only at runtime.
+func (r *Registry) Validate(req map[string]any) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+
+	calls := make([]string, 0, 1)
+
+	for key := range req {
+		if _, ok := r.schemas[key]; ok {
+			calls = append(calls, key)
+		}
+	}
+
+	if len(calls) == 0 {
+		if r.strict {
+			return fmt.Errorf("request doesn't contain any known Deriv API call")
+		}
+
+		return nil
+	}
+
+	sort.Strings(calls)
+
+	var firstErr error
+
+	for _, call := range calls {
+		problems, err := validate(r.schemas[call], doc)
+		if err != nil {
+			return err
+		}
+
+		if len(problems) == 0 {
+			return nil
+		}
+
+		if firstErr == nil {
+			firstErr = fmt.Errorf("request doesn't match %s schema: %s", call, strings.Join(problems, "; "))
+		}
+	}
+
+	return firstErr
+}
+
+// Params returns names of top-level fields of requests to the call, including the call key itself.
+// It takes call of type string which is the name of the Deriv API call, e.g. ticks.
+// It returns the sorted names of fields and true, or nil and false if the registry has no schema for the call.
+func (r *Registry) Params(call string) ([]string, bool) {
+	schema, ok := r.schemas[call]
+	if !ok {
+		return nil, false
+	}
+
+	params := make([]string, 0, len(schema.Properties))
+
+	for name := range schema.Properties {
+		params = append(params, name)
+	}
+
+	sort.Strings(params)
+
+	return params, true
+}
+
+// validate validates the document against the schema and collects human readable problems.
+// It takes schema of type *jsonschema.Schema and doc of type any.
+// It returns a slice of problems and an error if validation fails for reasons other than schema mismatch.
+// Problems caused by values with placeholders are skipped.
+func validate(schema *jsonschema.Schema, doc any) ([]string, error) {
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+
+	var errValidation *jsonschema.ValidationError
+	if !errors.As(err, &errValidation) {
+		return nil, fmt.Errorf("failed to validate request: %w", err)
+	}
+
+	var problems []string
+
+	var walk func(e *jsonschema.ValidationError)
+
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+
+			return
+		}
+
+		if hasPlaceholder(lookup(doc, e.InstanceLocation)) {
+			return
+		}
+
+		problems = append(problems, fmt.Sprintf("request%s: %s", e.InstanceLocation, e.Message))
+	}
+
+	walk(errValidation)
+
+	sort.Strings(problems)
+
+	return problems, nil
+}
+
+// lookup resolves a JSON pointer against the decoded JSON document.
+// It takes doc of type any and ptr of type string.
+// It returns the value the pointer refers to or nil if it can't be resolved.
+func lookup(doc any, ptr string) any {
+	if ptr == "" {
+		return doc
+	}
+
+	cur := doc
+
+	for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch v := cur.(type) {
+		case map[string]any:
+			cur = v[token]
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+
+			cur = v[i]
+		default:
+			return nil
+		}
+	}
+
+	return cur
+}
+
+// hasPlaceholder checks if the value is a string with a template placeholder.
+// It takes v of type any.
+// It returns true if the value is a string containing a placeholder or a Go template action, otherwise false.
+func hasPlaceholder(v any) bool {
+	s, ok := v.(string)
+
+	return ok && (strings.Contains(s, placeholderPrefix) || strings.Contains(s, actionPrefix))
+}
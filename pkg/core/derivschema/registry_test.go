@@ -0,0 +1,175 @@
+package derivschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"$schema": "http://json-schema.org/draft-04/schema#",
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["ticks"],
+	"properties": {
+		"ticks": {"type": "string", "pattern": "^\\w{2,30}$"},
+		"subscribe": {"type": "integer", "enum": [1]},
+		"passthrough": {"type": "object"},
+		"req_id": {"type": "integer"}
+	}
+}`
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		fsys    fstest.MapFS
+		name    string
+		wantErr bool
+	}{
+		{
+			name: "Valid schemas",
+			fsys: fstest.MapFS{"ticks/send.json": {Data: []byte(testSchema)}},
+		},
+		{
+			name:    "No schemas",
+			fsys:    fstest.MapFS{"ticks/receive.json": {Data: []byte(testSchema)}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid schema",
+			fsys:    fstest.MapFS{"ticks/send.json": {Data: []byte(`{"type": 1}`)}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid JSON",
+			fsys:    fstest.MapFS{"ticks/send.json": {Data: []byte(`not json`)}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(tt.fsys)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, r)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, r)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "ticks"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ticks", "send.json"), []byte(testSchema), 0o600))
+
+	r, err := LoadDir(dir)
+	require.NoError(t, err)
+	assert.True(t, r.strict)
+	assert.Contains(t, r.schemas, "ticks")
+
+	_, err = LoadDir(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	_, err = LoadDir(filepath.Join(dir, "ticks", "send.json"))
+	assert.Error(t, err)
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	r, err := New(fstest.MapFS{"ticks/send.json": {Data: []byte(testSchema)}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		req     map[string]any
+		name    string
+		wantErr string
+	}{
+		{
+			name: "Valid request",
+			req:  map[string]any{"ticks": "R_50", "subscribe": 1},
+		},
+		{
+			name: "Placeholders are not validated",
+			req:  map[string]any{"ticks": "${params.symbol}", "subscribe": "${params.subscribe}"},
+		},
+		{
+			name: "Go template actions are not validated",
+			req:  map[string]any{"ticks": "{{ .params.symbol }}", "subscribe": "{{ .params.subscribe }}"},
+		},
+		{
+			name:    "Unknown field",
+			req:     map[string]any{"ticks": "R_50", "subscribee": 1},
+			wantErr: "request doesn't match ticks schema: request: additionalProperties 'subscribee' not allowed",
+		},
+		{
+			name:    "Invalid value",
+			req:     map[string]any{"ticks": "R_50", "subscribe": 2},
+			wantErr: "request doesn't match ticks schema: request/subscribe: value must be \"1\"",
+		},
+		{
+			name:    "Unknown call in strict registry",
+			req:     map[string]any{"tick": "R_50"},
+			wantErr: "request doesn't contain any known Deriv API call",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Validate(tt.req)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBundled(t *testing.T) {
+	r := Bundled()
+
+	assert.Same(t, r, Bundled())
+	assert.False(t, r.strict)
+
+	assert.NoError(t, r.Validate(map[string]any{"ping": 1}))
+	assert.NoError(t, r.Validate(map[string]any{"unknown_call": 1}))
+	assert.NoError(t, r.Validate(map[string]any{"ticks_history": "${params.symbol}", "end": "latest", "count": "${params.count}"}))
+	assert.NoError(t, r.Validate(map[string]any{"active_symbols": "brief", "landing_company": "svg"}))
+	assert.Error(t, r.Validate(map[string]any{"ticks_history": "R_50"}))
+	assert.Error(t, r.Validate(map[string]any{"balance": 1, "acount": "all"}))
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{
+		"a":   []any{"x", map[string]any{"b/c": 1}},
+		"d~e": "f",
+	}
+
+	assert.Equal(t, doc, lookup(doc, ""))
+	assert.Equal(t, "x", lookup(doc, "/a/0"))
+	assert.Equal(t, 1, lookup(doc, "/a/1/b~1c"))
+	assert.Equal(t, "f", lookup(doc, "/d~0e"))
+	assert.Nil(t, lookup(doc, "/a/5"))
+	assert.Nil(t, lookup(doc, "/d~0e/g"))
+}
+
+func TestRegistry_Params(t *testing.T) {
+	r := Bundled()
+
+	params, ok := r.Params("ticks")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"passthrough", "req_id", "subscribe", "ticks"}, params)
+
+	params, ok = r.Params("transfer_between_accounts")
+	assert.False(t, ok)
+	assert.Nil(t, params)
+}
@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"iter"
+	"log/slog"
+	"sync/atomic"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/cache"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 type Parser func([]byte) (*response.Response, error)
 
 type Validator interface {
-	Validate(data []byte) error
+	Validate(ctx context.Context, data []byte) (json.RawMessage, error)
 }
 
 type RenderParser interface {
@@ -27,29 +33,92 @@ type WaitComposer interface {
 	Compose() (map[string]any, error)
 }
 
+type Cache interface {
+	Lookup(ctx context.Context, reqID string, req core.Request) *cache.Lookup
+}
+
+type Paginator interface {
+	Paginate(data []byte, fetch core.PageFetcher) (*response.Response, error)
+}
+
 type Handler struct {
-	validator   Validator
-	newComposer func(core.Waiter) WaitComposer
-	processors  []RenderParser
+	validator    Validator
+	renderErrors metric.Int64Counter
+	newComposer  func(core.Waiter) WaitComposer
+	caches       map[string]Cache
+	paginators   map[string]Paginator
+	method       string
+	processors   []RenderParser
+}
+
+type Option func(*Handler)
+
+// WithMethod sets the name of the API call of the handler, which is used as a metric attribute.
+// It takes method of type string.
+// It returns an Option.
+func WithMethod(method string) Option {
+	return func(h *Handler) {
+		h.method = method
+	}
+}
+
+// WithCache sets the cache for responses of the processor with the given name.
+// It takes name of type string and c of type Cache.
+// It returns an Option.
+func WithCache(name string, c Cache) Option {
+	return func(h *Handler) {
+		h.caches[name] = c
+	}
+}
+
+// WithPaginator sets the paginator that fetches all pages of responses of the processor with the given name.
+// It takes name of type string and p of type Paginator.
+// It returns an Option.
+func WithPaginator(name string, p Paginator) Option {
+	return func(h *Handler) {
+		h.paginators[name] = p
+	}
 }
 
 // New creates a new instance of Handler.
 // It takes three parameters: val of type Validator, proc which is a slice of RenderParser, and composeFactory which is a function that takes a core.Waiter and returns a WaitComposer.
+// It also takes a variadic number of Option functions to configure the Handler.
 // It returns a pointer to a Handler.
-func New(val Validator, proc []RenderParser, composeFactory func(core.Waiter) WaitComposer) *Handler {
-	return &Handler{
-		validator:   val,
-		processors:  proc,
-		newComposer: composeFactory,
+// It panics if the metric can't be initialized.
+func New(val Validator, proc []RenderParser, composeFactory func(core.Waiter) WaitComposer, opts ...Option) *Handler {
+	renderErrors, err := otel.GetMeterProvider().Meter("handler").Int64Counter(
+		"template_render_errors",
+		metric.WithDescription("Number of backend requests that failed to render"),
+	)
+	if err != nil {
+		panic("failed to initialize metric" + err.Error())
+	}
+
+	h := &Handler{
+		renderErrors: renderErrors,
+		validator:    val,
+		processors:   proc,
+		newComposer:  composeFactory,
+		caches:       make(map[string]Cache),
+		paginators:   make(map[string]Paginator),
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
 // Handle processes incoming requests and sends them using the provided sender.
 // It takes a context.Context, a map of parameters, a core.Waiter, and a core.Sender.
 // It returns a map containing the composed results and an error if any occurs during validation or sending requests.
 // It returns an error if the validation of parameters fails or if sending a request fails.
+// Requests are rendered with params where missing params are replaced with their default values.
+// If a request can't be rendered, the failure is logged and counted, and an InternalServerError APIError is returned.
 func (h *Handler) Handle(ctx context.Context, params json.RawMessage, waiter core.Waiter, send core.Sender) (map[string]any, error) {
-	if err := h.validator.Validate(params); err != nil {
+	params, err := h.validator.Validate(ctx, params)
+	if err != nil {
 		return nil, err
 	}
 
@@ -58,7 +127,11 @@ func (h *Handler) Handle(ctx context.Context, params json.RawMessage, waiter cor
 
 	comp := h.newComposer(waiter)
 
-	for req := range h.requests(ctx, params, comp) {
+	for req, err := range h.requests(ctx, params, comp, waiter, send) {
+		if err != nil {
+			return nil, err
+		}
+
 		if err := send(req); err != nil {
 			return nil, fmt.Errorf("failed to send request: %w", err)
 		}
@@ -69,29 +142,121 @@ func (h *Handler) Handle(ctx context.Context, params json.RawMessage, waiter cor
 
 // requests generates a sequence of requests based on the provided processors.
 // It takes a context `ctx` for managing request lifecycle, a map `params` containing parameters for the requests, and a `comp` of type WaitComposer for preparing the requests.
-// It returns an iterator function that yields requests of type `request`.
-// The function handles context cancellation and prepares requests using the provided processors. It panics if template execution fails during request rendering.
-func (h *Handler) requests(ctx context.Context, params json.RawMessage, comp WaitComposer) iter.Seq[core.Request] {
-	return func(yield func(core.Request) bool) {
+// It returns an iterator function that yields requests of type `request`, or an APIError if a request can't be rendered.
+// The function handles context cancellation and prepares requests using the provided processors.
+// Requests of processors with a cache are looked up in the cache, and cache hits are yielded as resolved requests.
+// Responses of processors with a paginator are passed to it, so it can fetch the following pages with waiter and send.
+func (h *Handler) requests(
+	ctx context.Context,
+	params json.RawMessage,
+	comp WaitComposer,
+	waiter core.Waiter,
+	send core.Sender,
+) iter.Seq2[core.Request, error] {
+	return func(yield func(core.Request, error) bool) {
 		for _, proc := range h.processors {
 			if ctx.Err() != nil {
 				return
 			}
 
-			reqID, depResuls, err := comp.Prepare(ctx, proc.Name(), proc.Parse)
+			c, cached := h.caches[proc.Name()]
+			parser := proc.Parse
+
+			var (
+				lookup atomic.Pointer[cache.Lookup]
+				deps   atomic.Pointer[map[string]any]
+			)
+
+			if cached {
+				parser = func(data []byte) (*response.Response, error) {
+					return lookup.Load().Parse(proc.Parse, data)
+				}
+			}
+
+			if pager, ok := h.paginators[proc.Name()]; ok {
+				parser = func(data []byte) (*response.Response, error) {
+					return pager.Paginate(data, func(next func(core.Request) (core.Request, error)) ([]byte, error) {
+						return fetchPage(ctx, proc, params, *deps.Load(), waiter, send, next)
+					})
+				}
+			}
+
+			reqID, depResuls, err := comp.Prepare(ctx, proc.Name(), parser)
 			if err != nil {
 				return
 			}
 
+			deps.Store(&depResuls)
+
 			req, err := proc.Render(ctx, reqID, params, depResuls)
 			if err != nil {
-				// TODO: add prevalidating template on startup to avoid this error in runtime
-				panic(fmt.Sprintf("template execution failed: %v", err))
+				yield(nil, h.renderError(ctx, proc.Name(), err))
+				return
 			}
 
-			if !yield(req) {
+			if cached {
+				l := c.Lookup(ctx, reqID, req)
+				lookup.Store(l)
+				req = l.Request()
+			}
+
+			if !yield(req, nil) {
 				return
 			}
 		}
 	}
 }
+
+// renderError records the failure to render the request of the backend.
+// It takes ctx of type context.Context, backend of type string and err of type error.
+// It returns an APIError for the client, which doesn't expose details of the failure.
+func (h *Handler) renderError(ctx context.Context, backend string, err error) error {
+	slog.ErrorContext(ctx, "Failed to render backend request",
+		slog.String("method", h.method),
+		slog.String("backend", backend),
+		slog.Any("error", err),
+	)
+
+	h.renderErrors.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("method", h.method),
+		attribute.String("backend", backend),
+	))
+
+	return core.NewAPIError("InternalServerError", "Sorry, an error occurred while processing your request.", nil)
+}
+
+// fetchPage renders the request of the processor, points it to the next page, sends it and waits for its response.
+// It takes ctx of type context.Context, proc of type RenderParser, params of type json.RawMessage, deps of type map[string]any,
+// waiter of type core.Waiter, send of type core.Sender and next, which points the rendered request to the page.
+// It returns the raw response of the page and an error if the request can't be rendered or sent, or if the context is done.
+func fetchPage(
+	ctx context.Context,
+	proc RenderParser,
+	params json.RawMessage,
+	deps map[string]any,
+	waiter core.Waiter,
+	send core.Sender,
+	next func(core.Request) (core.Request, error),
+) ([]byte, error) {
+	reqID, respChan := waiter()
+
+	req, err := proc.Render(ctx, reqID, params, deps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render page request: %w", err)
+	}
+
+	if req, err = next(req); err != nil {
+		return nil, fmt.Errorf("failed to render page request: %w", err)
+	}
+
+	if err := send(req); err != nil {
+		return nil, fmt.Errorf("failed to send page request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case resp := <-respChan:
+		return resp, nil
+	}
+}
@@ -2,11 +2,17 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/cache"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -34,7 +40,7 @@ func TestHandle_Success(t *testing.T) {
 	mockReq.EXPECT().Data().Return([]byte("data"))
 
 	validator := NewMockValidator(t)
-	validator.EXPECT().Validate(params).Return(nil)
+	validator.EXPECT().Validate(mock.Anything, params).Return(json.RawMessage(params), nil)
 
 	renderParser := NewMockRenderParser(t)
 	renderParser.EXPECT().Name().Return(expectedCallName)
@@ -65,11 +71,53 @@ func TestHandle_Success(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{"key": "value"}, resp)
 }
 
+func TestHandle_ParamsWithDefaults(t *testing.T) {
+	params := []byte(`{"key": "value"}`)
+	withDefaults := json.RawMessage(`{"key": "value", "limit": 10}`)
+
+	expectedResult := map[string]any{"key": "value"}
+	expectedCallName := "test"
+
+	mockReq := core.NewMockRequest(t)
+	mockReq.EXPECT().Data().Return([]byte("data"))
+
+	validator := NewMockValidator(t)
+	validator.EXPECT().Validate(mock.Anything, params).Return(withDefaults, nil)
+
+	renderParser := NewMockRenderParser(t)
+	renderParser.EXPECT().Name().Return(expectedCallName)
+	renderParser.EXPECT().Render(mock.Anything, mock.Anything, []byte(withDefaults), make(map[string]any)).Return(mockReq, nil)
+
+	waitComposer := NewMockWaitComposer(t)
+	waitComposer.EXPECT().Compose().Return(expectedResult, nil)
+	waitComposer.EXPECT().Prepare(mock.Anything, expectedCallName, mock.Anything).Return("1", make(map[string]any), nil)
+
+	handler := New(validator, []RenderParser{renderParser}, func(core.Waiter) WaitComposer {
+		return waitComposer
+	})
+
+	ctx := context.Background()
+
+	echoChan := make(chan []byte, 1)
+	waiter := func() (string, <-chan []byte) {
+		return "1", echoChan
+	}
+
+	sender := func(req core.Request) error {
+		echoChan <- req.Data()
+		return nil
+	}
+
+	resp, err := handler.Handle(ctx, params, waiter, sender)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, resp)
+}
+
 func TestHandle_ValidationError(t *testing.T) {
 	expectedParams := []byte(`{"key": "value"}`)
 
 	validator := NewMockValidator(t)
-	validator.EXPECT().Validate(expectedParams).Return(assert.AnError)
+	validator.EXPECT().Validate(mock.Anything, expectedParams).Return(nil, assert.AnError)
 
 	renderParser := NewMockRenderParser(t)
 	waitComposer := NewMockWaitComposer(t)
@@ -100,7 +148,7 @@ func TestHandle_SendError(t *testing.T) {
 	expectedCallName := "test"
 
 	validator := NewMockValidator(t)
-	validator.EXPECT().Validate(expectedParams).Return(nil)
+	validator.EXPECT().Validate(mock.Anything, expectedParams).Return(json.RawMessage(expectedParams), nil)
 
 	mockReq := core.NewMockRequest(t)
 
@@ -135,7 +183,7 @@ func TestHandle_CancelledContext(t *testing.T) {
 	expectedParams := []byte(`{"key": "value"}`)
 
 	validator := NewMockValidator(t)
-	validator.EXPECT().Validate(expectedParams).Return(nil)
+	validator.EXPECT().Validate(mock.Anything, expectedParams).Return(json.RawMessage(expectedParams), nil)
 
 	renderParser := NewMockRenderParser(t)
 
@@ -168,7 +216,7 @@ func TestHandle_PrepareError(t *testing.T) {
 	expectedCallName := "test"
 
 	validator := NewMockValidator(t)
-	validator.EXPECT().Validate(expectedParams).Return(nil)
+	validator.EXPECT().Validate(mock.Anything, expectedParams).Return(json.RawMessage(expectedParams), nil)
 
 	renderParser := NewMockRenderParser(t)
 	renderParser.EXPECT().Name().Return(expectedCallName)
@@ -196,3 +244,231 @@ func TestHandle_PrepareError(t *testing.T) {
 	assert.ErrorIs(t, err, assert.AnError)
 	assert.Nil(t, resp)
 }
+
+type testComposer struct {
+	waiter  core.Waiter
+	resps   []<-chan []byte
+	parsers []Parser
+}
+
+func (c *testComposer) Prepare(_ context.Context, _ string, parser Parser) (string, map[string]any, error) {
+	reqID, respChan := c.waiter()
+
+	c.resps = append(c.resps, respChan)
+	c.parsers = append(c.parsers, parser)
+
+	return reqID, make(map[string]any), nil
+}
+
+func (c *testComposer) Compose() (map[string]any, error) {
+	for i, respChan := range c.resps {
+		if _, err := c.parsers[i](<-respChan); err != nil {
+			return nil, err
+		}
+	}
+
+	return make(map[string]any), nil
+}
+
+func TestHandle_RenderError(t *testing.T) {
+	expectedParams := []byte(`{"key": "value"}`)
+	expectedCallName := "test"
+
+	validator := NewMockValidator(t)
+	validator.EXPECT().Validate(mock.Anything, expectedParams).Return(json.RawMessage(expectedParams), nil)
+
+	renderParser := NewMockRenderParser(t)
+	renderParser.EXPECT().Name().Return(expectedCallName)
+	renderParser.EXPECT().Render(mock.Anything, "1", expectedParams, make(map[string]any)).Return(nil, assert.AnError)
+
+	waitComposer := NewMockWaitComposer(t)
+	waitComposer.EXPECT().Prepare(mock.Anything, expectedCallName, mock.Anything).Return("1", make(map[string]any), nil)
+
+	handler := New(validator, []RenderParser{renderParser, renderParser}, func(core.Waiter) WaitComposer {
+		return waitComposer
+	}, WithMethod("testMethod"))
+
+	sender := func(_ core.Request) error {
+		t.Fatal("request must not be sent")
+		return nil
+	}
+
+	resp, err := handler.Handle(context.Background(), expectedParams, nil, sender)
+	assert.Nil(t, resp)
+
+	var apiErr *core.APIError
+
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "InternalServerError", apiErr.Code)
+	assert.NotContains(t, apiErr.Message, assert.AnError.Error())
+}
+
+func TestHandle_Cache(t *testing.T) {
+	params := []byte(`{"key": "value"}`)
+	name := "test"
+
+	validator := NewMockValidator(t)
+	validator.EXPECT().Validate(mock.Anything, params).Return(json.RawMessage(params), nil)
+
+	renderParser := NewMockRenderParser(t)
+	renderParser.EXPECT().Name().Return(name)
+	renderParser.EXPECT().Render(mock.Anything, mock.Anything, params, mock.Anything).RunAndReturn(
+		func(ctx context.Context, reqID string, _ []byte, _ map[string]any) (core.Request, error) {
+			return request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, reqID), nil
+		},
+	)
+	renderParser.EXPECT().Parse([]byte(`{"key":"value"}`)).Return(response.New([]byte(`{"key":"value"}`), nil), nil)
+
+	c, err := cache.New(&cache.Config{TTL: "1m", Scope: cache.ScopeGlobal})
+	require.NoError(t, err)
+
+	handler := New(validator, []RenderParser{renderParser}, func(waiter core.Waiter) WaitComposer {
+		return &testComposer{waiter: waiter}
+	}, WithCache(name, c))
+
+	reqID := 0
+	sent := make([]core.Request, 0, 2)
+	resps := make(map[string]chan []byte)
+
+	waiter := func() (string, <-chan []byte) {
+		reqID++
+		id := fmt.Sprintf("%d", reqID)
+		resps[id] = make(chan []byte, 1)
+
+		return id, resps[id]
+	}
+
+	sender := func(req core.Request) error {
+		sent = append(sent, req)
+
+		if r, ok := req.(core.ResolvedRequest); ok {
+			resps[r.ID()] <- r.Response()
+			return nil
+		}
+
+		resps[req.(*request.HTTPReq).ID()] <- []byte(`{"key":"value"}`)
+
+		return nil
+	}
+
+	_, err = handler.Handle(context.Background(), params, waiter, sender)
+	require.NoError(t, err)
+
+	_, err = handler.Handle(context.Background(), params, waiter, sender)
+	require.NoError(t, err)
+
+	require.Len(t, sent, 2)
+	assert.IsType(t, &request.HTTPReq{}, sent[0])
+	assert.IsType(t, &request.ResolvedReq{}, sent[1])
+}
+
+type testPaginator struct {
+	pages int
+}
+
+func (p *testPaginator) Paginate(data []byte, fetch core.PageFetcher) (*response.Response, error) {
+	body := string(data)
+
+	for i := 2; i <= p.pages; i++ {
+		page, err := fetch(func(req core.Request) (core.Request, error) {
+			httpReq := req.(*request.HTTPReq)
+			httpReq.SetURL(fmt.Sprintf("%s?page=%d", httpReq.URL(), i))
+
+			return httpReq, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		body += "," + string(page)
+	}
+
+	return response.New([]byte("["+body+"]"), nil), nil
+}
+
+func TestHandle_Paginate(t *testing.T) {
+	params := []byte(`{"key": "value"}`)
+	name := "test"
+
+	validator := NewMockValidator(t)
+	validator.EXPECT().Validate(mock.Anything, params).Return(json.RawMessage(params), nil)
+
+	renderParser := NewMockRenderParser(t)
+	renderParser.EXPECT().Name().Return(name)
+	renderParser.EXPECT().Render(mock.Anything, mock.Anything, params, make(map[string]any)).RunAndReturn(
+		func(ctx context.Context, reqID string, _ []byte, _ map[string]any) (core.Request, error) {
+			return request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, reqID), nil
+		},
+	).Times(3)
+
+	pager := &testPaginator{pages: 3}
+
+	handler := New(validator, []RenderParser{renderParser}, func(waiter core.Waiter) WaitComposer {
+		return &testComposer{waiter: waiter}
+	}, WithPaginator(name, pager))
+
+	reqID := 0
+	sent := make([]string, 0, 3)
+	resps := make(map[string]chan []byte)
+
+	waiter := func() (string, <-chan []byte) {
+		reqID++
+		id := fmt.Sprintf("%d", reqID)
+		resps[id] = make(chan []byte, 1)
+
+		return id, resps[id]
+	}
+
+	sender := func(req core.Request) error {
+		httpReq := req.(*request.HTTPReq)
+		sent = append(sent, httpReq.URL())
+		resps[httpReq.ID()] <- []byte(httpReq.ID())
+
+		return nil
+	}
+
+	_, err := handler.Handle(context.Background(), params, waiter, sender)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"http://localhost/", "http://localhost/?page=2", "http://localhost/?page=3"}, sent)
+}
+
+func TestHandle_PaginateSendError(t *testing.T) {
+	params := []byte(`{"key": "value"}`)
+	name := "test"
+
+	validator := NewMockValidator(t)
+	validator.EXPECT().Validate(mock.Anything, params).Return(json.RawMessage(params), nil)
+
+	renderParser := NewMockRenderParser(t)
+	renderParser.EXPECT().Name().Return(name)
+	renderParser.EXPECT().Render(mock.Anything, mock.Anything, params, mock.Anything).RunAndReturn(
+		func(ctx context.Context, reqID string, _ []byte, _ map[string]any) (core.Request, error) {
+			return request.NewHTTPReq(ctx, "GET", "http://localhost/", nil, reqID), nil
+		},
+	)
+
+	handler := New(validator, []RenderParser{renderParser}, func(waiter core.Waiter) WaitComposer {
+		return &testComposer{waiter: waiter}
+	}, WithPaginator(name, &testPaginator{pages: 2}))
+
+	respChan := make(chan []byte, 1)
+
+	waiter := func() (string, <-chan []byte) {
+		return "1", respChan
+	}
+
+	sender := func(req core.Request) error {
+		if req.(*request.HTTPReq).URL() != "http://localhost/" {
+			return assert.AnError
+		}
+
+		respChan <- []byte(`1`)
+
+		return nil
+	}
+
+	_, err := handler.Handle(context.Background(), params, waiter, sender)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorContains(t, err, "failed to send page request")
+}
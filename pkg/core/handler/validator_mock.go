@@ -4,7 +4,13 @@
 
 package handler
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	json "encoding/json"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockValidator is an autogenerated mock type for the Validator type
 type MockValidator struct {
@@ -19,22 +25,34 @@ func (_m *MockValidator) EXPECT() *MockValidator_Expecter {
 	return &MockValidator_Expecter{mock: &_m.Mock}
 }
 
-// Validate provides a mock function with given fields: data
-func (_m *MockValidator) Validate(data []byte) error {
-	ret := _m.Called(data)
+// Validate provides a mock function with given fields: ctx, data
+func (_m *MockValidator) Validate(ctx context.Context, data []byte) (json.RawMessage, error) {
+	ret := _m.Called(ctx, data)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Validate")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func([]byte) error); ok {
-		r0 = rf(data)
+	var r0 json.RawMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (json.RawMessage, error)); ok {
+		return rf(ctx, data)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) json.RawMessage); ok {
+		r0 = rf(ctx, data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(json.RawMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, data)
 	} else {
-		r0 = ret.Error(0)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
 // MockValidator_Validate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Validate'
@@ -43,24 +61,25 @@ type MockValidator_Validate_Call struct {
 }
 
 // Validate is a helper method to define mock.On call
+//   - ctx context.Context
 //   - data []byte
-func (_e *MockValidator_Expecter) Validate(data interface{}) *MockValidator_Validate_Call {
-	return &MockValidator_Validate_Call{Call: _e.mock.On("Validate", data)}
+func (_e *MockValidator_Expecter) Validate(ctx interface{}, data interface{}) *MockValidator_Validate_Call {
+	return &MockValidator_Validate_Call{Call: _e.mock.On("Validate", ctx, data)}
 }
 
-func (_c *MockValidator_Validate_Call) Run(run func(data []byte)) *MockValidator_Validate_Call {
+func (_c *MockValidator_Validate_Call) Run(run func(ctx context.Context, data []byte)) *MockValidator_Validate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]byte))
+		run(args[0].(context.Context), args[1].([]byte))
 	})
 	return _c
 }
 
-func (_c *MockValidator_Validate_Call) Return(_a0 error) *MockValidator_Validate_Call {
-	_c.Call.Return(_a0)
+func (_c *MockValidator_Validate_Call) Return(_a0 json.RawMessage, _a1 error) *MockValidator_Validate_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockValidator_Validate_Call) RunAndReturn(run func([]byte) error) *MockValidator_Validate_Call {
+func (_c *MockValidator_Validate_Call) RunAndReturn(run func(context.Context, []byte) (json.RawMessage, error)) *MockValidator_Validate_Call {
 	_c.Call.Return(run)
 	return _c
 }
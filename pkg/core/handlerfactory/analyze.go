@@ -0,0 +1,151 @@
+package handlerfactory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/processor"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/tmpl"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
+)
+
+// checkDeps checks that backends depend only on backends of the same API call.
+// It takes backends of type []*processor.Config.
+// It returns an error if any backend depends on an unknown backend.
+func checkDeps(backends []*processor.Config) error {
+	names := make([]string, 0, len(backends))
+
+	for _, be := range backends {
+		names = append(names, be.Name)
+	}
+
+	for _, be := range backends {
+		for _, dep := range be.DependsOn {
+			if !slices.Contains(names, dep) {
+				return fmt.Errorf("backend %s depends on unknown backend %s", be.Name, dep)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRefs checks that placeholders of templates of the backend refer to data that is provided to templates.
+// It takes valid of type *validator.FieldValidator, be of type *processor.Config, refs, which are references of templates keyed by location,
+// and conn of type *connContext, which is the allowlist of connection data, or nil if it's unknown.
+// It returns an error for the first placeholder that refers to an undeclared param, a backend that is not listed in depends_on,
+// a connection header or query parameter that is not in the allowlist, or a path that can't exist in the template data.
+// Values of placeholders of URLs, headers and query parameters are also checked to be renderable as strings.
+func checkRefs(valid *validator.FieldValidator, be *processor.Config, refs map[string][]tmpl.Ref, conn *connContext) error {
+	locations := make([]string, 0, len(refs))
+
+	for loc := range refs {
+		locations = append(locations, loc)
+	}
+
+	slices.Sort(locations)
+
+	for _, loc := range locations {
+		for _, ref := range refs[loc] {
+			if err := checkRef(valid, be, conn, strings.Split(ref.Path, "."), ref.Raw && loc != "request"); err != nil {
+				return fmt.Errorf("placeholder ${%s} in %s: %w", ref.Path, loc, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRef checks the path of a placeholder.
+// It takes valid of type *validator.FieldValidator, be of type *processor.Config, conn of type *connContext, path of type []string and str,
+// which is true if the value is rendered as is in a string template.
+// It returns an error if the path can't exist in the template data, or the value can't be rendered as a string.
+func checkRef(valid *validator.FieldValidator, be *processor.Config, conn *connContext, path []string, str bool) error {
+	switch path[0] {
+	case "params":
+		if len(path) == 1 {
+			if str {
+				return fmt.Errorf("params is an object and can't be rendered as a string, use to_string to render it as JSON")
+			}
+
+			return nil
+		}
+
+		if err := valid.CheckPath(path[1:]); err != nil {
+			return err
+		}
+
+		if !str {
+			return nil
+		}
+
+		types := valid.Types(path[1:])
+		if len(types) == 0 || slices.ContainsFunc(types, isScalarType) {
+			return nil
+		}
+
+		return fmt.Errorf("can't be rendered as a string: param is %s, use to_string to render it as JSON", strings.Join(types, " or "))
+	case "resp":
+		if len(path) > 1 && !slices.Contains(be.DependsOn, path[1]) {
+			return fmt.Errorf("backend %s is not listed in depends_on", path[1])
+		}
+	case "req_id":
+		if len(path) > 1 {
+			return fmt.Errorf("req_id is a string and has no fields")
+		}
+	case "conn":
+		return checkConnRef(conn, path)
+	case "secret", "env":
+		if len(path) != 2 {
+			return fmt.Errorf("%s must be referenced by name, e.g. ${%s.NAME}", path[0], path[0])
+		}
+	default:
+		return fmt.Errorf("unknown template data %s, expected params, resp, req_id, conn, secret or env", path[0])
+	}
+
+	return nil
+}
+
+// checkConnRef checks the path of a placeholder that refers to data of the client connection.
+// It takes conn of type *connContext, which is the allowlist of connection data, or nil if it's unknown,
+// and path of type []string, where the first segment is conn.
+// It returns an error if the path can't exist in data of the connection, or refers to a header or query parameter that is not in the allowlist.
+func checkConnRef(conn *connContext, path []string) error {
+	if len(path) == 1 {
+		return nil
+	}
+
+	switch path[1] {
+	case "id", "ip", "lang":
+		if len(path) > 2 {
+			return fmt.Errorf("conn.%s is a string and has no fields", path[1])
+		}
+	case "headers", "query":
+		if len(path) > 3 {
+			return fmt.Errorf("conn.%s.%s is a string and has no fields", path[1], path[2])
+		}
+
+		if conn == nil || len(path) < 3 {
+			return nil
+		}
+
+		allowed := conn.headers
+		if path[1] == "query" {
+			allowed = conn.query
+		}
+
+		if !slices.Contains(allowed, path[2]) {
+			return fmt.Errorf("conn.%s.%s is not listed in server.conn_context.%s, so it's always empty", path[1], path[2], path[1])
+		}
+	default:
+		return fmt.Errorf("unknown connection data %s, expected id, ip, lang, headers or query", path[1])
+	}
+
+	return nil
+}
+
+// isScalarType reports whether values of the JSON type can be rendered as strings.
+func isScalarType(t string) bool {
+	return t != "array" && t != "object"
+}
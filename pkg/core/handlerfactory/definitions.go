@@ -0,0 +1,47 @@
+package handlerfactory
+
+import (
+	"fmt"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
+)
+
+// IsDefinitions checks if the entry of the API configuration declares shared schemas instead of an API call.
+func (c *Config) IsDefinitions() bool {
+	return c.Definitions != nil
+}
+
+// Definitions compiles shared schemas declared by definitions entries of the API configuration.
+// It takes cfg of type []Config, which is the API configuration.
+// It returns the compiled shared schemas, the API calls of the configuration, and an error.
+// It returns an error if a definitions entry declares an API call, a shared schema is declared more than once or is invalid.
+func Definitions(cfg []Config) (*validator.Definitions, []Config, error) {
+	defs := make(map[string]any)
+	calls := make([]Config, 0, len(cfg))
+
+	for _, c := range cfg {
+		if !c.IsDefinitions() {
+			calls = append(calls, c)
+			continue
+		}
+
+		if c.Method != "" || c.Params != nil || len(c.Backend) > 0 {
+			return nil, nil, fmt.Errorf("definitions entry can't declare an API call")
+		}
+
+		for name, schema := range c.Definitions {
+			if _, ok := defs[name]; ok {
+				return nil, nil, fmt.Errorf("definition %s is declared more than once", name)
+			}
+
+			defs[name] = schema
+		}
+	}
+
+	compiled, err := validator.NewDefinitions(defs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid definitions: %w", err)
+	}
+
+	return compiled, calls, nil
+}
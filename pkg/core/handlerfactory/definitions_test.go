@@ -0,0 +1,71 @@
+package handlerfactory
+
+import (
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitions(t *testing.T) {
+	call := Config{Method: "ticks", Backend: []*processor.Config{{Request: map[string]any{"ticks": "R_50"}}}}
+
+	tests := []struct {
+		name      string
+		wantErr   string
+		cfg       []Config
+		wantCalls []Config
+	}{
+		{
+			name:      "no definitions",
+			cfg:       []Config{call},
+			wantCalls: []Config{call},
+		},
+		{
+			name: "definitions in several entries",
+			cfg: []Config{
+				{Definitions: map[string]any{"symbol": map[string]any{"type": "string"}}},
+				call,
+				{Definitions: map[string]any{"count": map[string]any{"type": "integer"}}},
+			},
+			wantCalls: []Config{call},
+		},
+		{
+			name: "definitions entry with a method",
+			cfg: []Config{
+				{Method: "ticks", Definitions: map[string]any{"symbol": map[string]any{"type": "string"}}},
+			},
+			wantErr: "definitions entry can't declare an API call",
+		},
+		{
+			name: "duplicate definition",
+			cfg: []Config{
+				{Definitions: map[string]any{"symbol": map[string]any{"type": "string"}}},
+				{Definitions: map[string]any{"symbol": map[string]any{"type": "string"}}},
+			},
+			wantErr: "definition symbol is declared more than once",
+		},
+		{
+			name: "invalid definition",
+			cfg: []Config{
+				{Definitions: map[string]any{"symbol": map[string]any{"type": "text"}}},
+			},
+			wantErr: "invalid definitions: invalid definition symbol",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs, calls, err := Definitions(tt.cfg)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, defs)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
@@ -2,22 +2,117 @@ package handlerfactory
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/cache"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/composer"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/derivschema"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/handler"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/processor"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
 )
 
 type Config struct {
-	Method  string              `json:"method" yaml:"method"`
-	Params  *validator.Config   `json:"params,omitempty" yaml:"params,omitempty"`
-	Backend []*processor.Config `json:"backend" yaml:"backend"`
+	Method      string              `json:"method,omitempty" yaml:"method,omitempty"`
+	Params      *validator.Config   `json:"params,omitempty" yaml:"params,omitempty"`
+	Backend     []*processor.Config `json:"backend,omitempty" yaml:"backend,omitempty"`
+	Definitions map[string]any      `json:"definitions,omitempty" yaml:"definitions,omitempty"`
 }
 
-func New(cfg Config) (string, core.Handler, error) {
-	valid, err := validator.New(cfg.Params)
+type SchemaValidator interface {
+	Validate(req map[string]any) error
+}
+
+type options struct {
+	derivSchemas SchemaValidator
+	secrets      processor.Secrets
+	definitions  *validator.Definitions
+	connContext  *connContext
+	httpAuth     map[string]struct{}
+	httpSigners  map[string]struct{}
+}
+
+// connContext is the allowlist of headers and query parameters of client connections that are provided to templates.
+type connContext struct {
+	headers []string
+	query   []string
+}
+
+type Option func(*options)
+
+// WithDerivSchemas sets the validator for Deriv API request templates.
+// It takes v of type SchemaValidator and returns an Option.
+// If the option is not provided, request templates are validated against bundled Deriv API schemas.
+func WithDerivSchemas(v SchemaValidator) Option {
+	return func(o *options) {
+		o.derivSchemas = v
+	}
+}
+
+// WithSecrets sets the provider of values of `secret` and `env` references of request templates.
+// It takes s of type processor.Secrets and returns an Option.
+// Values are resolved when requests are rendered, so the provider is not used while the configuration is loaded.
+func WithSecrets(s processor.Secrets) Option {
+	return func(o *options) {
+		o.secrets = s
+	}
+}
+
+// WithDefinitions sets shared schemas that params of the API call can reference with $ref, e.g. {"$ref": "#/definitions/currency"}.
+// It takes defs of type *validator.Definitions and returns an Option.
+func WithDefinitions(defs *validator.Definitions) Option {
+	return func(o *options) {
+		o.definitions = defs
+	}
+}
+
+// WithConnContext sets the allowlist of headers and query parameters of client connections that are provided to templates.
+// It takes headers and query of type []string and returns an Option.
+// Placeholders that refer to headers or query parameters that are not in the allowlist are reported as errors,
+// because they are always empty. If the option is not provided, such placeholders are not checked.
+func WithConnContext(headers, query []string) Option {
+	return func(o *options) {
+		o.connContext = &connContext{headers: headers, query: query}
+	}
+}
+
+// WithHTTPAuth sets names of authentication providers configured for HTTP backends.
+// It takes names of type []string and returns an Option.
+// Backends that refer to providers that are not configured are reported as errors, because their requests always fail.
+// Names are case-insensitive, because configuration keys are. If the option is not provided, names are not checked.
+func WithHTTPAuth(names []string) Option {
+	return func(o *options) {
+		o.httpAuth = nameSet(names)
+	}
+}
+
+// WithHTTPSigners sets names of signers configured for HTTP backends.
+// It takes names of type []string and returns an Option.
+// Backends that refer to signers that are not configured are reported as errors, because their requests always fail.
+// Names are case-insensitive, because configuration keys are. If the option is not provided, names are not checked.
+func WithHTTPSigners(names []string) Option {
+	return func(o *options) {
+		o.httpSigners = nameSet(names)
+	}
+}
+
+// New creates a handler for the API call described by the provided configuration.
+// It takes cfg of type Config and a variadic number of Option functions.
+// It returns the method name, the handler and an error.
+// It returns an error if the configuration is invalid, including Deriv API request templates that don't match their schemas.
+func New(cfg Config, opts ...Option) (string, core.Handler, error) {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.derivSchemas == nil {
+		o.derivSchemas = derivschema.Bundled()
+	}
+
+	valid, err := validator.New(cfg.Params, validator.WithDefinitions(o.definitions))
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to create validator: %w", err)
 	}
@@ -36,7 +131,12 @@ func New(cfg Config) (string, core.Handler, error) {
 		}
 	}
 
+	if err := checkDeps(cfg.Backend); err != nil {
+		return "", nil, fmt.Errorf("invalid dependencies in method %s: %w", cfg.Method, err)
+	}
+
 	procs := make([]handler.RenderParser, 0, len(cfg.Backend))
+	hOpts := []handler.Option{handler.WithMethod(cfg.Method)}
 	graph := createDepGraph(cfg.Backend)
 
 	backends, err := topSortDFS(cfg.Backend)
@@ -45,17 +145,53 @@ func New(cfg Config) (string, core.Handler, error) {
 	}
 
 	for _, procCfg := range backends {
-		p, err := processor.New(procCfg)
+		p, err := processor.New(procCfg, processor.WithSecrets(o.secrets))
 		if err != nil {
 			return "", nil, fmt.Errorf("failed to create processor: %w", err)
 		}
 
+		if err := checkRefs(valid, procCfg, p.Refs(), o.connContext); err != nil {
+			return "", nil, fmt.Errorf("invalid template in method %s, backend %s: %w", cfg.Method, procCfg.Name, err)
+		}
+
+		if err := checkProviders(procCfg, o); err != nil {
+			return "", nil, fmt.Errorf("invalid backend %s in method %s: %w", procCfg.Name, cfg.Method, err)
+		}
+
+		if _, ok := p.(*processor.DerivProc); ok {
+			if err := o.derivSchemas.Validate(procCfg.Request); err != nil {
+				return "", nil, fmt.Errorf("invalid request template in method %s, backend %s: %w", cfg.Method, procCfg.Name, err)
+			}
+		}
+
+		if procCfg.Cache != nil {
+			c, err := createCache(p, procCfg.Cache)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid cache in method %s, backend %s: %w", cfg.Method, procCfg.Name, err)
+			}
+
+			hOpts = append(hOpts, handler.WithCache(procCfg.Name, c))
+		}
+
+		if procCfg.Paginate != nil {
+			pager, ok := p.(handler.Paginator)
+
+			switch {
+			case procCfg.Cache != nil:
+				return "", nil, fmt.Errorf("cache is not supported for paginated backends in method %s, backend %s", cfg.Method, procCfg.Name)
+			case !ok:
+				return "", nil, fmt.Errorf("pagination is not supported by backend %s in method %s", procCfg.Name, cfg.Method)
+			}
+
+			hOpts = append(hOpts, handler.WithPaginator(procCfg.Name, pager))
+		}
+
 		procs = append(procs, p)
 	}
 
 	factory := createComposerFactory(graph)
 
-	return cfg.Method, handler.New(valid, procs, factory), nil
+	return cfg.Method, handler.New(valid, procs, factory, hOpts...), nil
 }
 
 // topSortDFS performs a topological sort on a slice of BackendConfig using Depth-First Search (DFS).
@@ -134,3 +270,47 @@ func createComposerFactory(graph map[string][]string) func(core.Waiter) handler.
 		return composer.New(graph, waiter)
 	}
 }
+
+// createCache creates a cache for responses of the processor.
+// It takes p of type processor.Processor and cfg of type *cache.Config.
+// It returns a pointer to cache.Cache and an error if the configuration is invalid.
+// It returns an error if Cache-Control headers are honoured for a non-HTTP backend.
+func createCache(p processor.Processor, cfg *cache.Config) (*cache.Cache, error) {
+	if _, ok := p.(*processor.HTTPProc); !ok && cfg.HonorCacheControl {
+		return nil, fmt.Errorf("honor_cache_control is supported only for HTTP backends")
+	}
+
+	return cache.New(cfg)
+}
+
+// checkProviders checks that the authentication provider and the signer the backend refers to are configured.
+// It takes cfg of type *processor.Config and o of type *options.
+// It returns an error if the provider or the signer is not listed in the configured names.
+func checkProviders(cfg *processor.Config, o *options) error {
+	if cfg.Auth != "" && o.httpAuth != nil {
+		if _, ok := o.httpAuth[strings.ToLower(cfg.Auth)]; !ok {
+			return fmt.Errorf("auth %s is not configured in http.auth", cfg.Auth)
+		}
+	}
+
+	if cfg.Signer != "" && o.httpSigners != nil {
+		if _, ok := o.httpSigners[strings.ToLower(cfg.Signer)]; !ok {
+			return fmt.Errorf("signer %s is not configured in http.signers", cfg.Signer)
+		}
+	}
+
+	return nil
+}
+
+// nameSet creates a set of lowercased names.
+// It takes names of type []string.
+// It returns a map with names as keys, which is empty but not nil if there are no names.
+func nameSet(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+
+	for _, name := range names {
+		set[strings.ToLower(name)] = struct{}{}
+	}
+
+	return set
+}
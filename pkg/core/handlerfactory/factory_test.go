@@ -2,7 +2,10 @@ package handlerfactory
 
 import (
 	"testing"
+	"testing/fstest"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/core/cache"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/derivschema"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/processor"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/validator"
 	"github.com/stretchr/testify/assert"
@@ -316,3 +319,285 @@ func TestCreateComposerFactory(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateHandler_DerivSchemas(t *testing.T) {
+	call := func(req map[string]any) Config {
+		return Config{
+			Method:  "testMethod",
+			Backend: []*processor.Config{{Name: "backend1", Request: req}},
+		}
+	}
+
+	_, handler, err := New(call(map[string]any{"ticks": "R_50", "subscribe": 1}))
+	assert.NoError(t, err)
+	assert.NotNil(t, handler)
+
+	_, handler, err = New(call(map[string]any{"ticks": "R_50", "subscribee": 1}))
+	assert.ErrorContains(t, err, "invalid request template in method testMethod, backend backend1")
+	assert.Nil(t, handler)
+
+	schemas := derivschema.Bundled()
+	strict, err := derivschema.New(fstest.MapFS{"ping/send.json": {Data: []byte(`{"type": "object"}`)}})
+	assert.NoError(t, err)
+
+	_, _, err = New(call(map[string]any{"key1": "value1"}), WithDerivSchemas(schemas))
+	assert.NoError(t, err)
+
+	_, _, err = New(call(map[string]any{"key1": "value1"}), WithDerivSchemas(strict))
+	assert.Error(t, err)
+
+	_, _, err = New(Config{
+		Method: "testMethod",
+		Backend: []*processor.Config{{
+			Name:    "backend1",
+			URL:     "http://localhost/",
+			Method:  "GET",
+			Request: map[string]any{"key1": "value1"},
+		}},
+	}, WithDerivSchemas(strict))
+	assert.NoError(t, err)
+}
+
+func TestCreateHandler_Cache(t *testing.T) {
+	deriv := func(c *cache.Config) Config {
+		return Config{
+			Method:  "testMethod",
+			Backend: []*processor.Config{{Name: "backend1", Request: map[string]any{"website_status": 1}, Cache: c}},
+		}
+	}
+
+	_, h, err := New(deriv(&cache.Config{TTL: "1m"}))
+	assert.NoError(t, err)
+	assert.NotNil(t, h)
+
+	_, h, err = New(deriv(&cache.Config{}))
+	assert.ErrorContains(t, err, "invalid cache in method testMethod, backend backend1: cache ttl must be provided")
+	assert.Nil(t, h)
+
+	_, _, err = New(deriv(&cache.Config{TTL: "1m", HonorCacheControl: true}))
+	assert.ErrorContains(t, err, "honor_cache_control is supported only for HTTP backends")
+
+	_, _, err = New(Config{
+		Method: "testMethod",
+		Backend: []*processor.Config{{
+			Name:   "backend1",
+			URL:    "http://localhost/",
+			Method: "GET",
+			Cache:  &cache.Config{HonorCacheControl: true},
+		}},
+	})
+	assert.NoError(t, err)
+}
+
+func TestCreateHandler_Paginate(t *testing.T) {
+	paginated := func(c *cache.Config, p *processor.PaginateConfig) Config {
+		return Config{
+			Method: "testMethod",
+			Backend: []*processor.Config{{
+				Name:     "backend1",
+				Request:  map[string]any{"statement": 1},
+				Allow:    []string{"list"},
+				Cache:    c,
+				Paginate: p,
+			}},
+		}
+	}
+
+	_, h, err := New(paginated(nil, &processor.PaginateConfig{Items: "transactions", PageSize: 100, MaxItems: 500}))
+	assert.NoError(t, err)
+	assert.NotNil(t, h)
+
+	_, _, err = New(paginated(nil, &processor.PaginateConfig{Items: "transactions", PageSize: 100}))
+	assert.ErrorContains(t, err, "invalid paginate config: max_items must be greater than 0")
+
+	_, _, err = New(paginated(&cache.Config{TTL: "1m"}, &processor.PaginateConfig{Items: "transactions", PageSize: 100, MaxItems: 500}))
+	assert.ErrorContains(t, err, "cache is not supported for paginated backends in method testMethod, backend backend1")
+}
+
+func TestCreateHandler_ParamTypes(t *testing.T) {
+	withURL := func(url string) Config {
+		return Config{
+			Method: "testMethod",
+			Params: &validator.Config{
+				"id":     map[string]any{"type": "integer"},
+				"ids":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"filter": map[string]any{"type": "object"},
+			},
+			Backend: []*processor.Config{{
+				Name:    "backend1",
+				Method:  "POST",
+				URL:     url,
+				Request: map[string]any{"ids": "${params.ids}", "filter": "${params.filter}"},
+			}},
+		}
+	}
+
+	_, _, err := New(withURL("http://localhost/users/${params.id}?first=${params.ids.0}&filter=${params.filter | to_string}"))
+	assert.NoError(t, err)
+
+	_, _, err = New(withURL("http://localhost/users?ids=${params.ids}"))
+	assert.ErrorContains(t, err, "invalid template in method testMethod, backend backend1: placeholder ${params.ids} in url: can't be rendered as a string: param is array")
+}
+
+func TestCreateHandler_ConnContext(t *testing.T) {
+	withHeaders := func(headers map[string]string) Config {
+		return Config{
+			Method: "testMethod",
+			Backend: []*processor.Config{{
+				Name:    "backend1",
+				Method:  "GET",
+				URL:     "http://localhost/",
+				Headers: headers,
+			}},
+		}
+	}
+
+	allowlist := WithConnContext([]string{"User-Agent"}, []string{"app_id"})
+
+	_, _, err := New(withHeaders(map[string]string{"X-Agent": "${conn.headers.User-Agent}", "X-App": "${conn.query.app_id}"}), allowlist)
+	assert.NoError(t, err)
+
+	_, _, err = New(withHeaders(map[string]string{"X-Token": "${conn.headers.Authorization}"}), allowlist)
+	assert.ErrorContains(t, err, "placeholder ${conn.headers.Authorization} in headers.X-Token: conn.headers.Authorization is not listed in server.conn_context.headers, so it's always empty")
+
+	_, _, err = New(withHeaders(map[string]string{"X-Lang": "${conn.query.l}"}), allowlist)
+	assert.ErrorContains(t, err, "placeholder ${conn.query.l} in headers.X-Lang: conn.query.l is not listed in server.conn_context.query, so it's always empty")
+
+	_, _, err = New(withHeaders(map[string]string{"X-Token": "${conn.headers.Authorization}"}), WithConnContext(nil, nil))
+	assert.ErrorContains(t, err, "conn.headers.Authorization is not listed in server.conn_context.headers")
+
+	_, _, err = New(withHeaders(map[string]string{"X-Token": "${conn.headers.Authorization}"}))
+	assert.NoError(t, err)
+}
+
+func TestCreateHandler_HTTPAuth(t *testing.T) {
+	withAuth := func(auth string) Config {
+		return Config{
+			Method: "testMethod",
+			Backend: []*processor.Config{{
+				Name:   "backend1",
+				Method: "GET",
+				URL:    "http://localhost/",
+				Auth:   auth,
+			}},
+		}
+	}
+
+	_, _, err := New(withAuth("Billing"), WithHTTPAuth([]string{"billing"}))
+	assert.NoError(t, err)
+
+	_, _, err = New(withAuth("biling"), WithHTTPAuth([]string{"billing"}))
+	assert.ErrorContains(t, err, "invalid backend backend1 in method testMethod: auth biling is not configured in http.auth")
+
+	_, _, err = New(withAuth("billing"), WithHTTPAuth(nil))
+	assert.ErrorContains(t, err, "auth billing is not configured in http.auth")
+
+	_, _, err = New(withAuth("billing"))
+	assert.NoError(t, err)
+}
+
+func TestCreateHandler_HTTPSigners(t *testing.T) {
+	withSigner := func(signer string) Config {
+		return Config{
+			Method: "testMethod",
+			Backend: []*processor.Config{{
+				Name:   "backend1",
+				Method: "GET",
+				URL:    "http://localhost/",
+				Signer: signer,
+			}},
+		}
+	}
+
+	_, _, err := New(withSigner("Partner"), WithHTTPSigners([]string{"partner"}))
+	assert.NoError(t, err)
+
+	_, _, err = New(withSigner("partnr"), WithHTTPSigners([]string{"partner"}))
+	assert.ErrorContains(t, err, "invalid backend backend1 in method testMethod: signer partnr is not configured in http.signers")
+
+	_, _, err = New(withSigner("partner"), WithHTTPAuth([]string{"partner"}))
+	assert.NoError(t, err, "signers are checked only if the option is provided")
+
+	_, _, err = New(withSigner("partner"), WithHTTPSigners(nil))
+	assert.ErrorContains(t, err, "signer partner is not configured in http.signers")
+}
+
+func TestCreateHandler_TemplateRefs(t *testing.T) {
+	withBackends := func(backends ...*processor.Config) Config {
+		return Config{
+			Method: "testMethod",
+			Params: &validator.Config{
+				"name": &validator.FieldSchema{Type: "string"},
+			},
+			Backend: backends,
+		}
+	}
+
+	user := &processor.Config{Name: "user", Method: "GET", URL: "http://localhost/users/${params.name}"}
+
+	tests := []struct {
+		name    string
+		wantErr string
+		backend *processor.Config
+	}{
+		{
+			name: "valid references",
+			backend: &processor.Config{
+				Name:      "orders",
+				Method:    "POST",
+				URL:       "http://localhost/orders?user=${resp.user.id}&id=${req_id}",
+				Headers:   map[string]string{"X-Api-Key": "${secret.API_KEY}", "X-Client-IP": "${conn.ip}"},
+				Request:   map[string]any{"name": "${params.name | default env.DEFAULT_NAME}", "lang": "${conn.query.l}"},
+				DependsOn: []string{"user"},
+			},
+		},
+		{
+			name:    "undeclared param",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders/${params.id}"},
+			wantErr: "placeholder ${params.id} in url: param id is not declared",
+		},
+		{
+			name:    "impossible param path",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders", Request: map[string]any{"a": "${params.name.first}"}},
+			wantErr: "placeholder ${params.name.first} in request: params.name is string and has no field first",
+		},
+		{
+			name:    "backend not in depends_on",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders?user=${resp.user.id}"},
+			wantErr: "placeholder ${resp.user.id} in url: backend user is not listed in depends_on",
+		},
+		{
+			name:    "unknown data",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders?user=${user.id}"},
+			wantErr: "placeholder ${user.id} in url: unknown template data user, expected params, resp, req_id, conn, secret or env",
+		},
+		{
+			name:    "unknown connection data",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders", Headers: map[string]string{"X-Agent": "${conn.agent}"}},
+			wantErr: "placeholder ${conn.agent} in headers.X-Agent: unknown connection data agent, expected id, ip, lang, headers or query",
+		},
+		{
+			name:    "nested secret",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders", Query: map[string]string{"key": "${secret.partner.key}"}},
+			wantErr: "placeholder ${secret.partner.key} in query.key: secret must be referenced by name, e.g. ${secret.NAME}",
+		},
+		{
+			name:    "unknown dependency",
+			backend: &processor.Config{Name: "orders", Method: "GET", URL: "http://localhost/orders", DependsOn: []string{"users"}},
+			wantErr: "invalid dependencies in method testMethod: backend orders depends on unknown backend users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := New(withBackends(user, tt.backend))
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
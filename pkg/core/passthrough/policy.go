@@ -0,0 +1,266 @@
+package passthrough
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+)
+
+const (
+	defaultRejectCode    = "PermissionDenied"
+	defaultRejectMessage = "Request is not allowed"
+)
+
+// neutralKeys are top-level keys of Deriv API requests that don't select a call.
+var neutralKeys = []string{"req_id", "passthrough"}
+
+type Config struct {
+	Rewrite map[string]RewriteConfig `mapstructure:"rewrite"`
+	Reject  RejectConfig             `mapstructure:"reject"`
+	Allow   []string                 `mapstructure:"allow"`
+	Deny    []string                 `mapstructure:"deny"`
+}
+
+type RejectConfig struct {
+	Code    string `mapstructure:"code"`
+	Message string `mapstructure:"message"`
+}
+
+type RewriteConfig struct {
+	Request  RuleConfig `mapstructure:"request"`
+	Response RuleConfig `mapstructure:"response"`
+}
+
+type RuleConfig struct {
+	Inject map[string]any `mapstructure:"inject"`
+	Strip  []string       `mapstructure:"strip"`
+}
+
+// CallParams provides names of top-level fields of requests to Deriv API calls.
+type CallParams interface {
+	Params(call string) ([]string, bool)
+}
+
+type Policy struct {
+	allow     map[string]struct{}
+	deny      map[string]struct{}
+	reqRules  map[string]*rule
+	respRules map[string]*rule
+	params    CallParams
+	rejectErr *core.APIError
+}
+
+type Option func(*Policy)
+
+// WithCallParams sets the source of parameters of allowed calls.
+// It takes params of type CallParams and returns an Option.
+// Without it, requests to allowed calls can contain only the call key, req_id and passthrough.
+func WithCallParams(params CallParams) Option {
+	return func(p *Policy) {
+		p.params = params
+	}
+}
+
+// New creates a new passthrough Policy based on the provided configuration.
+// It takes cfg of type *Config and opts of type ...Option.
+// It returns a pointer to Policy and an error.
+// It returns an error if a call is present in both allow and deny lists or if inject values cannot be marshaled to JSON.
+func New(cfg *Config, opts ...Option) (*Policy, error) {
+	p := &Policy{
+		allow:     toSet(cfg.Allow),
+		deny:      toSet(cfg.Deny),
+		reqRules:  make(map[string]*rule, len(cfg.Rewrite)),
+		respRules: make(map[string]*rule, len(cfg.Rewrite)),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for key := range p.deny {
+		if _, ok := p.allow[key]; ok {
+			return nil, fmt.Errorf("call %s is present in both allow and deny lists", key)
+		}
+	}
+
+	for key, rw := range cfg.Rewrite {
+		reqRule, err := newRule(rw.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request rewrite for %s: %w", key, err)
+		}
+
+		respRule, err := newRule(rw.Response)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response rewrite for %s: %w", key, err)
+		}
+
+		if reqRule != nil {
+			p.reqRules[key] = reqRule
+		}
+
+		if respRule != nil {
+			p.respRules[key] = respRule
+		}
+	}
+
+	code, msg := cfg.Reject.Code, cfg.Reject.Message
+	if code == "" {
+		code = defaultRejectCode
+	}
+
+	if msg == "" {
+		msg = defaultRejectMessage
+	}
+
+	p.rejectErr = core.NewAPIError(code, msg, nil)
+
+	return p, nil
+}
+
+// Request checks the raw passthrough request against the allow and deny lists and applies request rewrites.
+// It takes data of type []byte which is the raw request received from the client.
+// It returns the request data that should be forwarded upstream and an error.
+// It returns *core.APIError if the request is rejected by the policy.
+// The deny list takes precedence over the allow list. If the allow list is not empty, requests that
+// can't be parsed as a JSON object, don't contain any allowed call or contain keys other than allowed calls,
+// their parameters, req_id and passthrough are rejected.
+func (p *Policy) Request(data []byte) ([]byte, error) {
+	if len(p.allow) == 0 && len(p.deny) == 0 && len(p.reqRules) == 0 {
+		return data, nil
+	}
+
+	var msg map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		if len(p.allow) > 0 {
+			return nil, p.rejectErr
+		}
+
+		return data, nil
+	}
+
+	for key := range msg {
+		if _, ok := p.deny[key]; ok {
+			return nil, p.rejectErr
+		}
+	}
+
+	if len(p.allow) > 0 && !p.allowed(msg) {
+		return nil, p.rejectErr
+	}
+
+	rules := make([]*rule, 0, len(p.reqRules))
+
+	for key := range msg {
+		if r, ok := p.reqRules[key]; ok {
+			rules = append(rules, r)
+		}
+	}
+
+	if len(rules) == 0 {
+		return data, nil
+	}
+
+	for _, r := range rules {
+		r.apply(msg)
+	}
+
+	rewritten, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rewritten request: %w", err)
+	}
+
+	return rewritten, nil
+}
+
+// allowed checks that the request targets only allowed calls.
+// It takes msg of type map[string]json.RawMessage which is the parsed request.
+// It returns true if the request contains at least one allowed call, and all other keys are parameters of these calls or neutral keys.
+func (p *Policy) allowed(msg map[string]json.RawMessage) bool {
+	known := toSet(neutralKeys)
+	calls := 0
+
+	for key := range msg {
+		if _, ok := p.allow[key]; !ok {
+			continue
+		}
+
+		calls++
+
+		if p.params == nil {
+			continue
+		}
+
+		params, _ := p.params.Params(key)
+		for _, param := range params {
+			known[param] = struct{}{}
+		}
+	}
+
+	if calls == 0 {
+		return false
+	}
+
+	for key := range msg {
+		_, isCall := p.allow[key]
+		_, isKnown := known[key]
+
+		if !isCall && !isKnown {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Response applies response rewrites to a raw upstream message based on its msg_type.
+// It takes data of type []byte which is the raw message received from upstream.
+// It returns the message that should be delivered to the client.
+// If the message can't be parsed or there is no rewrite for its msg_type, the message is returned unchanged.
+func (p *Policy) Response(data []byte) []byte {
+	if len(p.respRules) == 0 {
+		return data
+	}
+
+	var msgType struct {
+		MsgType string `json:"msg_type"`
+	}
+
+	if err := json.Unmarshal(data, &msgType); err != nil {
+		return data
+	}
+
+	r, ok := p.respRules[msgType.MsgType]
+	if !ok {
+		return data
+	}
+
+	var msg map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return data
+	}
+
+	r.apply(msg)
+
+	rewritten, err := json.Marshal(msg)
+	if err != nil {
+		return data
+	}
+
+	return rewritten
+}
+
+// toSet converts a slice of strings into a set.
+// It takes keys of type []string.
+// It returns a map with the keys as map keys and empty struct values.
+func toSet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+
+	return set
+}
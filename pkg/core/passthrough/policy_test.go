@@ -0,0 +1,270 @@
+package passthrough
+
+import (
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		cfg     *Config
+		name    string
+		wantErr bool
+	}{
+		{
+			name: "Empty config",
+			cfg:  &Config{},
+		},
+		{
+			name: "Valid config",
+			cfg: &Config{
+				Allow: []string{"ticks"},
+				Deny:  []string{"buy"},
+				Rewrite: map[string]RewriteConfig{
+					"ticks": {
+						Request:  RuleConfig{Inject: map[string]any{"subscribe": 1}},
+						Response: RuleConfig{Strip: []string{"echo_req"}},
+					},
+				},
+			},
+		},
+		{
+			name: "Call in both allow and deny lists",
+			cfg: &Config{
+				Allow: []string{"ticks"},
+				Deny:  []string{"ticks"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid inject value",
+			cfg: &Config{
+				Rewrite: map[string]RewriteConfig{
+					"ticks": {
+						Request: RuleConfig{Inject: map[string]any{"key": make(chan int)}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, p)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, p)
+			}
+		})
+	}
+}
+
+func TestPolicy_Request(t *testing.T) {
+	tests := []struct {
+		cfg     *Config
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "No restrictions",
+			cfg:  &Config{},
+			data: `{"ticks":"R_50"}`,
+			want: `{"ticks":"R_50"}`,
+		},
+		{
+			name: "Allowed call",
+			cfg:  &Config{Allow: []string{"ticks"}},
+			data: `{"ticks":"R_50","req_id":1}`,
+			want: `{"ticks":"R_50","req_id":1}`,
+		},
+		{
+			name:    "Call is not in allow list",
+			cfg:     &Config{Allow: []string{"ticks"}},
+			data:    `{"buy":"1","price":10}`,
+			wantErr: true,
+		},
+		{
+			name:    "Allowed call with call outside allow list",
+			cfg:     &Config{Allow: []string{"ticks"}},
+			data:    `{"ticks":"R_50","transfer_between_accounts":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "Allowed call with unknown params",
+			cfg:     &Config{Allow: []string{"ticks"}},
+			data:    `{"ticks":"R_50","subscribe":1}`,
+			wantErr: true,
+		},
+		{
+			name: "Several allowed calls",
+			cfg:  &Config{Allow: []string{"ticks", "website_status"}},
+			data: `{"ticks":"R_50","website_status":1,"passthrough":{}}`,
+			want: `{"ticks":"R_50","website_status":1,"passthrough":{}}`,
+		},
+		{
+			name:    "Denied call",
+			cfg:     &Config{Deny: []string{"buy"}},
+			data:    `{"buy":"1","price":10}`,
+			wantErr: true,
+		},
+		{
+			name:    "Deny list takes precedence",
+			cfg:     &Config{Allow: []string{"ticks"}, Deny: []string{"buy"}},
+			data:    `{"ticks":"R_50","buy":"1"}`,
+			wantErr: true,
+		},
+		{
+			name: "Invalid JSON without allow list",
+			cfg:  &Config{Deny: []string{"buy"}},
+			data: `not json`,
+			want: `not json`,
+		},
+		{
+			name:    "Invalid JSON with allow list",
+			cfg:     &Config{Allow: []string{"ticks"}},
+			data:    `not json`,
+			wantErr: true,
+		},
+		{
+			name: "Request rewrite",
+			cfg: &Config{
+				Rewrite: map[string]RewriteConfig{
+					"ticks": {
+						Request: RuleConfig{
+							Inject: map[string]any{"subscribe": 1},
+							Strip:  []string{"passthrough"},
+						},
+					},
+				},
+			},
+			data: `{"ticks":"R_50","passthrough":{"key":"value"}}`,
+			want: `{"subscribe":1,"ticks":"R_50"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			require.NoError(t, err)
+
+			got, err := p.Request([]byte(tt.data))
+
+			if tt.wantErr {
+				var apiErr *core.APIError
+
+				assert.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, defaultRejectCode, apiErr.Code)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestPolicy_Request_CallParams(t *testing.T) {
+	params := callParams{"ticks": {"ticks", "subscribe", "req_id", "passthrough"}}
+
+	p, err := New(&Config{Allow: []string{"ticks", "time"}}, WithCallParams(params))
+	require.NoError(t, err)
+
+	got, err := p.Request([]byte(`{"ticks":"R_50","subscribe":1,"req_id":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ticks":"R_50","subscribe":1,"req_id":1}`, string(got))
+
+	_, err = p.Request([]byte(`{"ticks":"R_50","subscribe":1,"transfer_between_accounts":1}`))
+	assert.ErrorIs(t, err, p.rejectErr)
+
+	_, err = p.Request([]byte(`{"time":1,"subscribe":1}`))
+	assert.ErrorIs(t, err, p.rejectErr)
+
+	got, err = p.Request([]byte(`{"time":1,"req_id":2}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"time":1,"req_id":2}`, string(got))
+}
+
+type callParams map[string][]string
+
+func (c callParams) Params(call string) ([]string, bool) {
+	params, ok := c[call]
+	return params, ok
+}
+
+func TestPolicy_Request_CustomReject(t *testing.T) {
+	p, err := New(&Config{
+		Deny:   []string{"buy"},
+		Reject: RejectConfig{Code: "CustomCode", Message: "Custom message"},
+	})
+	require.NoError(t, err)
+
+	_, err = p.Request([]byte(`{"buy":"1"}`))
+
+	var apiErr *core.APIError
+
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "CustomCode", apiErr.Code)
+	assert.Equal(t, "Custom message", apiErr.Message)
+}
+
+func TestPolicy_Response(t *testing.T) {
+	p, err := New(&Config{
+		Rewrite: map[string]RewriteConfig{
+			"ticks": {
+				Response: RuleConfig{
+					Inject: map[string]any{"source": "bff"},
+					Strip:  []string{"echo_req"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "Rewrite response",
+			data: `{"echo_req":{"ticks":"R_50"},"msg_type":"ticks","ticks":{"quote":1}}`,
+			want: `{"msg_type":"ticks","source":"bff","ticks":{"quote":1}}`,
+		},
+		{
+			name: "No rule for msg_type",
+			data: `{"echo_req":{"ping":1},"msg_type":"ping","ping":"pong"}`,
+			want: `{"echo_req":{"ping":1},"msg_type":"ping","ping":"pong"}`,
+		},
+		{
+			name: "Invalid JSON",
+			data: `not json`,
+			want: `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Response([]byte(tt.data))
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestPolicy_Response_NoRules(t *testing.T) {
+	p, err := New(&Config{})
+	require.NoError(t, err)
+
+	data := []byte(`{"msg_type":"ticks"}`)
+
+	assert.Equal(t, data, p.Response(data))
+}
@@ -0,0 +1,51 @@
+package passthrough
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type rule struct {
+	inject map[string]json.RawMessage
+	strip  []string
+}
+
+// newRule creates a new rewrite rule from the provided configuration.
+// It takes cfg of type RuleConfig.
+// It returns a pointer to rule and an error.
+// It returns nil rule if the configuration doesn't define any rewrites.
+// It returns an error if any of the inject values cannot be marshaled to JSON.
+func newRule(cfg RuleConfig) (*rule, error) {
+	if len(cfg.Inject) == 0 && len(cfg.Strip) == 0 {
+		return nil, nil
+	}
+
+	inject := make(map[string]json.RawMessage, len(cfg.Inject))
+
+	for key, value := range cfg.Inject {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal inject value for %s: %w", key, err)
+		}
+
+		inject[key] = data
+	}
+
+	return &rule{
+		inject: inject,
+		strip:  cfg.Strip,
+	}, nil
+}
+
+// apply modifies the provided message in place by removing stripped fields and setting injected ones.
+// It takes msg of type map[string]json.RawMessage.
+// Injected fields take precedence over fields with the same name that are present in the message.
+func (r *rule) apply(msg map[string]json.RawMessage) {
+	for _, key := range r.strip {
+		delete(msg, key)
+	}
+
+	for key, value := range r.inject {
+		msg[key] = value
+	}
+}
@@ -0,0 +1,44 @@
+package passthrough
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRule(t *testing.T) {
+	r, err := newRule(RuleConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	r, err = newRule(RuleConfig{Inject: map[string]any{"key": "value"}, Strip: []string{"field"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]json.RawMessage{"key": json.RawMessage(`"value"`)}, r.inject)
+	assert.Equal(t, []string{"field"}, r.strip)
+
+	r, err = newRule(RuleConfig{Inject: map[string]any{"key": func() {}}})
+	assert.Error(t, err)
+	assert.Nil(t, r)
+}
+
+func TestRule_Apply(t *testing.T) {
+	r, err := newRule(RuleConfig{
+		Inject: map[string]any{"key": "injected"},
+		Strip:  []string{"field"},
+	})
+	assert.NoError(t, err)
+
+	msg := map[string]json.RawMessage{
+		"key":   json.RawMessage(`"original"`),
+		"field": json.RawMessage(`1`),
+		"other": json.RawMessage(`2`),
+	}
+
+	r.apply(msg)
+
+	assert.Equal(t, map[string]json.RawMessage{
+		"key":   json.RawMessage(`"injected"`),
+		"other": json.RawMessage(`2`),
+	}, msg)
+}
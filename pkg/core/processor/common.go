@@ -1,11 +1,25 @@
 package processor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
 )
 
+// connInfo returns data of the client connection of the request for templates.
+// It takes ctx of type context.Context.
+// It returns a pointer to core.ConnInfo or nil if the request isn't bound to a client connection.
+func connInfo(ctx context.Context) *core.ConnInfo {
+	if conn := core.ConnFromContext(ctx); conn != nil {
+		return conn.Info()
+	}
+
+	return nil
+}
+
 // prepareResp processes a byte slice representing a JSON response body and returns a map of JSON raw messages.
 // It takes data of type []byte.
 // It returns a map[string]json.RawMessage containing the parsed JSON data and an error if any occurs.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
@@ -12,45 +13,111 @@ import (
 )
 
 type DerivProc struct {
-	name     string
-	tmpl     *tmpl.Tmpl
+	tmpl     tmpl.JSONTemplate
 	fieldMap map[string]string
+	pages    *pagination
+	schema   *respSchema
+	secrets  *secretRefs
+	name     string
 	allow    []string
 }
 
 type templateData struct {
-	Resp   map[string]any  `json:"resp"`
-	ReqID  string          `json:"req_id"`
-	Params json.RawMessage `json:"params"`
+	Resp   map[string]any    `json:"resp"`
+	Conn   *core.ConnInfo    `json:"conn,omitempty"`
+	Secret map[string]string `json:"secret,omitempty"`
+	Env    map[string]string `json:"env,omitempty"`
+	ReqID  string            `json:"req_id"`
+	Params json.RawMessage   `json:"params"`
 }
 
-type passthrough struct {
+type correlation struct {
 	ReqID string `json:"req_id"`
 }
 
 // NewDeriv creates and returns a new Processor instance configured with the provided Config.
 // It takes a single parameter cfg of type *Config which contains the necessary configuration.
 // It returns a pointer to a Processor struct initialized with the values from the Config.
-func NewDeriv(cfg *Config) (*DerivProc, error) {
-	t := cfg.Request
-	t["passthrough"] = passthrough{ReqID: "${req_id}"}
+// Values of `secret` and `env` references of the template are resolved with the provider set by WithSecrets.
+func NewDeriv(cfg *Config, opts ...Option) (*DerivProc, error) {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	engine, err := tmpl.NewEngine(cfg.TemplateEngine)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template engine: %w", err)
+	}
+
+	t, err := withCorrelation(cfg.Request, engine.Placeholder("req_id"))
+	if err != nil {
+		return nil, err
+	}
 
 	rawTmpl, err := json.Marshal(t)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request template: %w", err)
 	}
 
-	reqTmpl, err := tmpl.New(string(rawTmpl))
+	reqTmpl, err := engine.JSON(string(rawTmpl))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse request template: %w", err)
 	}
 
-	return &DerivProc{
+	pages, err := newPagination(cfg.Paginate, PaginateOffset)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paginate config: %w", err)
+	}
+
+	schema, err := newRespSchema(cfg.ResponseSchema, cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+
+	p := &DerivProc{
 		name:     cfg.Name,
 		tmpl:     reqTmpl,
 		fieldMap: cfg.FieldMap,
 		allow:    cfg.Allow,
-	}, nil
+		pages:    pages,
+		schema:   schema,
+	}
+
+	p.secrets = newSecretRefs(p.Refs(), o.secrets)
+
+	return p, nil
+}
+
+// withCorrelation creates a copy of the request template with the internal correlation id in its passthrough object.
+// It takes req of type map[string]any which is the request template from the configuration,
+// and reqID of type string which is the placeholder of the request id in the template engine of the backend.
+// It returns the new request template and an error if the template's own passthrough is not an object.
+// The template's own passthrough data is preserved, and the provided template is not modified.
+func withCorrelation(req map[string]any, reqID string) (map[string]any, error) {
+	t := make(map[string]any, len(req)+1)
+	for k, v := range req {
+		t[k] = v
+	}
+
+	pt := make(map[string]any)
+
+	if v, ok := req["passthrough"]; ok && v != nil {
+		tmplPT, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("passthrough in request template must be an object")
+		}
+
+		for k, v := range tmplPT {
+			pt[k] = v
+		}
+	}
+
+	pt[core.CorrelationKey] = correlation{ReqID: reqID}
+	t["passthrough"] = pt
+
+	return t, nil
 }
 
 // Name returns the name of the Processor as a string.
@@ -60,11 +127,19 @@ func (p *DerivProc) Name() string {
 	return p.name
 }
 
+// Refs returns references of the request template of the processor to the template data, keyed by "request".
+func (p *DerivProc) Refs() map[string][]tmpl.Ref {
+	return map[string][]tmpl.Ref{
+		"request": p.tmpl.Refs(),
+	}
+}
+
 // Render generates and writes the rendered template to the provided writer.
 // It takes a writer w of type io.Writer, a request ID reqID of type int64,
 // and two maps params and deps of type map[string]any.
 // It returns an error if the template execution fails.
 // If deps or params are nil, they are initialized as empty maps before template execution.
+// For paginated backends, the page size is set as the limit of the first page.
 func (p *DerivProc) Render(ctx context.Context, reqID string, params []byte, deps map[string]any) (core.Request, error) {
 	if deps == nil {
 		deps = make(map[string]any)
@@ -74,10 +149,18 @@ func (p *DerivProc) Render(ctx context.Context, reqID string, params []byte, dep
 		params = []byte("{}")
 	}
 
+	secrets, env, err := p.secrets.resolve()
+	if err != nil {
+		return nil, err
+	}
+
 	data := templateData{
 		Params: params,
 		ReqID:  reqID,
 		Resp:   deps,
+		Conn:   connInfo(ctx),
+		Secret: secrets,
+		Env:    env,
 	}
 
 	req, err := p.tmpl.Execute(data)
@@ -85,6 +168,10 @@ func (p *DerivProc) Render(ctx context.Context, reqID string, params []byte, dep
 		return nil, fmt.Errorf("failed to execute template: %w", err)
 	}
 
+	if p.pages != nil {
+		return p.pageRequest(request.NewRequest(ctx, request.TextMessage, req), "")
+	}
+
 	return request.NewRequest(ctx, request.TextMessage, req), nil
 }
 
@@ -92,12 +179,17 @@ func (p *DerivProc) Render(ctx context.Context, reqID string, params []byte, dep
 // It takes data of type []byte.
 // It returns a pointer to response.Response and an error.
 // It returns an error if parsing or preparing the response fails.
+// If the response schema is configured, the response body is validated against it, and violations fail the call in strict mode.
 func (p *DerivProc) Parse(data []byte) (*response.Response, error) {
 	resp, err := p.parse(data)
 	if err != nil {
 		return nil, fmt.Errorf("fail to parse response %s: %w", p.name, err)
 	}
 
+	if err := p.schema.check(resp); err != nil {
+		return nil, fmt.Errorf("invalid response %s: %w", p.name, err)
+	}
+
 	prepared, err := prepareResp(resp)
 	if err != nil {
 		return nil, fmt.Errorf("fail to prepare response %s: %w", p.name, err)
@@ -108,6 +200,65 @@ func (p *DerivProc) Parse(data []byte) (*response.Response, error) {
 	return response.New(resp, filetered), nil
 }
 
+// Paginate fetches all pages of the paginated response and returns their items as a single list.
+// It takes data of type []byte which is the response of the first page and fetch of type core.PageFetcher which fetches the following pages.
+// It returns a pointer to response.Response with the list of items as its body and an error if any of the pages can't be fetched or parsed.
+// If the response schema is configured, each page is validated against it, and violations fail the call in strict mode.
+func (p *DerivProc) Paginate(data []byte, fetch core.PageFetcher) (*response.Response, error) {
+	list, err := p.pages.paginate(data, fetch, p)
+	if err != nil {
+		return nil, fmt.Errorf("fail to paginate response %s: %w", p.name, err)
+	}
+
+	prepared, err := prepareResp(list)
+	if err != nil {
+		return nil, fmt.Errorf("fail to prepare response %s: %w", p.name, err)
+	}
+
+	return response.New(list, filterResp(prepared, p.allow, p.fieldMap)), nil
+}
+
+// page parses the given JSON data as a page of the paginated response.
+// It takes data of type []byte.
+// It returns the page and an error if the response can't be parsed or doesn't match the schema in strict mode.
+func (p *DerivProc) page(data []byte) (page, error) {
+	body, err := p.parse(data)
+	if err != nil {
+		return page{}, err
+	}
+
+	if err := p.schema.check(body); err != nil {
+		return page{}, err
+	}
+
+	return page{body: body}, nil
+}
+
+// pageRequest points the request to the given page.
+// It takes req of type core.Request and next of type string which is the offset of the page, or empty for the first page.
+// It returns the request and an error if the request is not a JSON object.
+// The offset and the page size are set as fields of the request.
+func (p *DerivProc) pageRequest(req core.Request, next string) (core.Request, error) {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(req.Data(), &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+
+	if next != "" {
+		fields[p.pages.param] = json.RawMessage(next)
+	}
+
+	fields[p.pages.limitParam] = json.RawMessage(strconv.Itoa(p.pages.pageSize))
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return request.NewRequest(req.Context(), request.TextMessage, data), nil
+}
+
 // parse parses the given JSON data and extracts the relevant message body.
 // It takes data of type []byte which is the JSON data to be parsed.
 // It returns a json.RawMessage containing the extracted message body and an error if any occurs.
@@ -7,8 +7,38 @@ import (
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core/tmpl"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestDerivProc_Render_PassThrough(t *testing.T) {
+	cfg := &Config{
+		Request: map[string]any{
+			"ticks":       "${params.symbol}",
+			"passthrough": map[string]any{"req_id": "custom", "symbol": "${params.symbol}"},
+		},
+	}
+
+	prc, err := NewDeriv(cfg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]any{"req_id": "custom", "symbol": "${params.symbol}"}, cfg.Request["passthrough"])
+
+	req, err := prc.Render(context.Background(), "internalID", []byte(`{"symbol":"R_50"}`), nil)
+	assert.NoError(t, err)
+
+	var got map[string]any
+
+	assert.NoError(t, json.Unmarshal(req.Data(), &got))
+	assert.Equal(t, map[string]any{
+		"ticks": "R_50",
+		"passthrough": map[string]any{
+			"req_id": "custom",
+			"symbol": "R_50",
+			"_bff":   map[string]any{"req_id": "internalID"},
+		},
+	}, got)
+}
+
 func TestNewDeriv(t *testing.T) {
 	tests := []struct {
 		cfg     *Config
@@ -24,6 +54,20 @@ func TestNewDeriv(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Template with own passthrough",
+			cfg: &Config{
+				Request: map[string]any{"ticks": "R_50", "passthrough": map[string]any{"req_id": "custom"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Template with invalid passthrough",
+			cfg: &Config{
+				Request: map[string]any{"ticks": "R_50", "passthrough": "invalid"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Fail to marshal request template",
 			cfg: &Config{
@@ -33,6 +77,22 @@ func TestNewDeriv(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Go template engine",
+			cfg: &Config{
+				Request:        map[string]any{"ticks": "{{ .params.symbol }}"},
+				TemplateEngine: "go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unsupported template engine",
+			cfg: &Config{
+				Request:        map[string]any{"ticks": "R_50"},
+				TemplateEngine: "jinja",
+			},
+			wantErr: true,
+		},
 		{
 			name: "Fail to parse request template",
 			cfg: &Config{
@@ -63,13 +123,26 @@ func TestNewDeriv(t *testing.T) {
 	}
 }
 
+func TestDerivProc_Render_GoTemplate(t *testing.T) {
+	p, err := NewDeriv(&Config{
+		Request:        map[string]any{"ticks": "{{ .params.symbol | upper }}", "subscribe": "{{ .params.subscribe }}"},
+		TemplateEngine: "go",
+	})
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "42", []byte(`{"symbol":"r_50","subscribe":1}`), nil)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"ticks":"R_50","subscribe":1,"passthrough":{"_bff":{"req_id":"42"}}}`, string(req.Data()))
+}
+
 func TestProcessor_Render(t *testing.T) {
 	tmpl1 := tmpl.MustNewTmpl(`{"params":"${params}","req_id":"${req_id}","resp": "${resp}"}`)
 	tmpl2 := tmpl.MustNewTmpl(`{"params": "${params.key1.key2}}"}`)
 
 	tests := []struct {
 		deps     map[string]any
-		tmpl     *tmpl.Tmpl
+		tmpl     tmpl.JSONTemplate
 		name     string
 		expected string
 		reqID    string
@@ -5,36 +5,51 @@ import (
 	"fmt"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/cache"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/tmpl"
 )
 
 type Processor interface {
 	Name() string
 	Render(ctx context.Context, reqID string, params []byte, deps map[string]any) (core.Request, error)
 	Parse(data []byte) (*response.Response, error)
+	Refs() map[string][]tmpl.Ref
 }
 
 type Config struct {
-	Request   map[string]any    `json:"request,omitempty" yaml:"request,omitempty"`
-	FieldMap  map[string]string `json:"fields_map,omitempty" yaml:"fields_map,omitempty"`
-	Headers   map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Name      string            `json:"name,omitempty" yaml:"name,omitempty"`
-	Method    string            `json:"method,omitempty" yaml:"method,omitempty"`
-	URL       string            `json:"url,omitempty" yaml:"url,omitempty"`
-	DependsOn []string          `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
-	Allow     []string          `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Request    map[string]any    `json:"request,omitempty" yaml:"request,omitempty"`
+	FieldMap   map[string]string `json:"fields_map,omitempty" yaml:"fields_map,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Name       string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Method     string            `json:"method,omitempty" yaml:"method,omitempty"`
+	URL        string            `json:"url,omitempty" yaml:"url,omitempty"`
+	DependsOn  []string          `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	Allow      []string          `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Errors     []ErrorConfig     `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Cache      *cache.Config     `json:"cache,omitempty" yaml:"cache,omitempty"`
+	Format     string            `json:"response_format,omitempty" yaml:"response_format,omitempty"`
+	BodyFormat string            `json:"body_format,omitempty" yaml:"body_format,omitempty"`
+	Query      map[string]string `json:"query,omitempty" yaml:"query,omitempty"`
+	Auth       string            `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Signer     string            `json:"signer,omitempty" yaml:"signer,omitempty"`
+	Paginate   *PaginateConfig   `json:"paginate,omitempty" yaml:"paginate,omitempty"`
+	// ResponseSchema is the JSON schema that responses of the backend are validated against.
+	ResponseSchema *ResponseSchemaConfig `json:"response_schema,omitempty" yaml:"response_schema,omitempty"`
+	// TemplateEngine is the engine of request templates: "default" for ${...} placeholders or "go" for Go templates.
+	TemplateEngine string `json:"template_engine,omitempty" yaml:"template_engine,omitempty"`
 }
 
 // New creates a new Processor based on the provided configuration.
-// It takes cfg of type *Config.
+// It takes cfg of type *Config and a variadic number of Option functions.
 // It returns a Processor and an error.
 // It returns an error if the configuration is ambiguous or invalid.
-func New(cfg *Config) (Processor, error) {
+func New(cfg *Config, opts ...Option) (Processor, error) {
 	switch {
 	case isHTTPConfig(cfg):
-		return NewHTTP(cfg)
+		return NewHTTP(cfg, opts...)
 	case isDerivConfig(cfg):
-		return NewDeriv(cfg)
+		return NewDeriv(cfg, opts...)
 	default:
 		return nil, fmt.Errorf("invalid processor configuration")
 	}
@@ -0,0 +1,221 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	FormatAuto = "auto"
+	FormatJSON = "json"
+	FormatXML  = "xml"
+	FormatCSV  = "csv"
+	FormatForm = "form"
+	FormatText = "text"
+)
+
+// validateFormat checks that the response format is supported.
+// It takes format of type string.
+// It returns an error if the format is unknown.
+func validateFormat(format string) error {
+	switch format {
+	case "", FormatAuto, FormatJSON, FormatXML, FormatCSV, FormatForm, FormatText:
+		return nil
+	default:
+		return fmt.Errorf("invalid response format %q", format)
+	}
+}
+
+// detectFormat selects the response format based on the Content-Type header.
+// It takes headers of type map[string][]string and body of type []byte.
+// It returns the format, which is JSON if the content type is missing or unknown.
+// Plain text bodies that are valid JSON are parsed as JSON, as many backends label JSON responses as text/plain.
+func detectFormat(headers map[string][]string, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.Header(headers).Get("Content-Type"))
+	if err != nil {
+		return FormatJSON
+	}
+
+	switch {
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return FormatXML
+	case mediaType == "text/csv":
+		return FormatCSV
+	case mediaType == "application/x-www-form-urlencoded":
+		return FormatForm
+	case mediaType == "text/plain" && !json.Valid(body):
+		return FormatText
+	default:
+		return FormatJSON
+	}
+}
+
+// convertBody converts the response body of the given format into JSON.
+// It takes format of type string and body of type []byte.
+// It returns a json.RawMessage and an error if the body can't be parsed.
+// XML documents are converted into the object of the root element, CSV documents into a list of objects keyed by the header row,
+// form-encoded bodies into an object, and plain text into a JSON string.
+func convertBody(format string, body []byte) (json.RawMessage, error) {
+	var (
+		doc any
+		err error
+	)
+
+	switch format {
+	case FormatXML:
+		doc, err = decodeXML(body)
+	case FormatCSV:
+		doc, err = decodeCSV(body)
+	case FormatForm:
+		doc, err = decodeForm(body)
+	case FormatText:
+		doc = string(body)
+	default:
+		var jsonData json.RawMessage
+
+		if err := json.Unmarshal(body, &jsonData); err != nil {
+			return nil, err
+		}
+
+		return jsonData, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s body: %w", format, err)
+	}
+
+	return json.Marshal(doc)
+}
+
+// decodeXML converts the XML document into a generic value.
+// It takes data of type []byte.
+// It returns the value of the root element and an error if the document is invalid.
+func decodeXML(data []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("root element not found")
+		} else if err != nil {
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			return decodeXMLElement(dec, start)
+		}
+	}
+}
+
+// decodeXMLElement converts the XML element into a generic value.
+// It takes dec of type *xml.Decoder and start of type xml.StartElement.
+// It returns the value and an error if the element is invalid.
+// Elements without attributes and children are converted into strings, other elements into objects,
+// where attributes are prefixed with "@", text content is stored under "#text", and repeated children are collected into lists.
+func decodeXMLElement(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	obj := make(map[string]any, len(start.Attr))
+
+	for _, attr := range start.Attr {
+		obj["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+
+			switch existing := obj[t.Name.Local].(type) {
+			case nil:
+				obj[t.Name.Local] = child
+			case []any:
+				obj[t.Name.Local] = append(existing, child)
+			default:
+				obj[t.Name.Local] = []any{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+
+			if len(obj) == 0 {
+				return content, nil
+			}
+
+			if content != "" {
+				obj["#text"] = content
+			}
+
+			return obj, nil
+		}
+	}
+}
+
+// decodeCSV converts the CSV document into a list of records.
+// It takes data of type []byte where the first row contains column names.
+// It returns a slice of records keyed by column names and an error if the document is invalid.
+func decodeCSV(data []byte) (any, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("header row not found")
+	}
+
+	header := rows[0]
+	records := make([]map[string]string, 0, len(rows)-1)
+
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(header))
+
+		for i, name := range header {
+			record[name] = row[i]
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// decodeForm converts the form-encoded body into an object.
+// It takes data of type []byte.
+// It returns a map with string values, or lists for repeated keys, and an error if the body is invalid.
+func decodeForm(data []byte) (any, error) {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	obj := make(map[string]any, len(values))
+
+	for key, v := range values {
+		if len(v) == 1 {
+			obj[key] = v[0]
+		} else {
+			obj[key] = v
+		}
+	}
+
+	return obj, nil
+}
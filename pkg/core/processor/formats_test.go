@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{"", FormatAuto, FormatJSON, FormatXML, FormatCSV, FormatForm, FormatText} {
+		assert.NoError(t, validateFormat(format))
+	}
+
+	assert.EqualError(t, validateFormat("yaml"), `invalid response format "yaml"`)
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{contentType: "", want: FormatJSON},
+		{contentType: "application/json", want: FormatJSON},
+		{contentType: "application/xml", want: FormatXML},
+		{contentType: "text/xml; charset=utf-8", want: FormatXML},
+		{contentType: "application/atom+xml", want: FormatXML},
+		{contentType: "text/csv", want: FormatCSV},
+		{contentType: "application/x-www-form-urlencoded", want: FormatForm},
+		{contentType: "text/plain; charset=utf-8", body: "OK", want: FormatText},
+		{contentType: "text/plain", body: `{"status":"ok"}`, want: FormatJSON},
+		{contentType: "text/plain", body: `[1,2]`, want: FormatJSON},
+		{contentType: "text/plain", body: `{"status":`, want: FormatText},
+		{contentType: "text/html", want: FormatJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType+" "+tt.body, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectFormat(map[string][]string{"Content-Type": {tt.contentType}}, []byte(tt.body)))
+		})
+	}
+}
+
+func TestHTTPProc_Parse_PlainTextJSON(t *testing.T) {
+	data, err := response.NewHTTPResp(http.StatusOK, map[string][]string{"Content-Type": {"text/plain"}}, []byte(`{"status":"ok"}`)).Encode()
+	require.NoError(t, err)
+
+	auto, err := NewHTTP(&Config{Name: "status", Method: "GET", URL: "http://localhost/status", Allow: []string{"status"}})
+	require.NoError(t, err)
+
+	resp, err := auto.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, `"ok"`, string(resp.Filtered()["status"]))
+
+	text, err := NewHTTP(&Config{Name: "status", Method: "GET", URL: "http://localhost/status", Allow: []string{"value"}, Format: FormatText})
+	require.NoError(t, err)
+
+	resp, err = text.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, `"{\"status\":\"ok\"}"`, string(resp.Filtered()["value"]))
+}
+
+func TestConvertBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "JSON",
+			format: FormatJSON,
+			body:   `{"key":"value"}`,
+			want:   `{"key":"value"}`,
+		},
+		{
+			name:    "Invalid JSON",
+			format:  FormatJSON,
+			body:    `{"key":`,
+			wantErr: "unexpected end of JSON input",
+		},
+		{
+			name:   "XML",
+			format: FormatXML,
+			body: `<?xml version="1.0"?>
+<order id="7">
+  <status>open</status>
+  <item sku="a">Apple</item>
+  <item sku="b">Banana</item>
+  <note/>
+</order>`,
+			want: `{"@id":"7","status":"open","item":[{"@sku":"a","#text":"Apple"},{"@sku":"b","#text":"Banana"}],"note":""}`,
+		},
+		{
+			name:   "XML scalar root",
+			format: FormatXML,
+			body:   `<status>ok</status>`,
+			want:   `"ok"`,
+		},
+		{
+			name:    "XML without root element",
+			format:  FormatXML,
+			body:    `<?xml version="1.0"?>`,
+			wantErr: "failed to parse xml body: root element not found",
+		},
+		{
+			name:   "CSV",
+			format: FormatCSV,
+			body:   "id,name\n1,John\n2,\"Doe, Jane\"\n",
+			want:   `[{"id":"1","name":"John"},{"id":"2","name":"Doe, Jane"}]`,
+		},
+		{
+			name:   "CSV without records",
+			format: FormatCSV,
+			body:   "id,name\n",
+			want:   `[]`,
+		},
+		{
+			name:    "CSV with inconsistent rows",
+			format:  FormatCSV,
+			body:    "id,name\n1\n",
+			wantErr: "failed to parse csv body",
+		},
+		{
+			name:    "Empty CSV",
+			format:  FormatCSV,
+			body:    "",
+			wantErr: "failed to parse csv body: header row not found",
+		},
+		{
+			name:   "Form",
+			format: FormatForm,
+			body:   "name=John+Doe&tag=a&tag=b",
+			want:   `{"name":"John Doe","tag":["a","b"]}`,
+		},
+		{
+			name:    "Invalid form",
+			format:  FormatForm,
+			body:    "name=%zz",
+			wantErr: "failed to parse form body",
+		},
+		{
+			name:   "Text",
+			format: FormatText,
+			body:   "plain \"text\"",
+			want:   `"plain \"text\""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertBody(tt.format, []byte(tt.body))
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
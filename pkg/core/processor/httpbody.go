@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	BodyFormatJSON      = "json"
+	BodyFormatForm      = "form"
+	BodyFormatMultipart = "multipart"
+	BodyFormatNone      = "none"
+)
+
+// validateBodyFormat checks that the request body format is supported.
+// It takes format of type string.
+// It returns an error if the format is unknown.
+func validateBodyFormat(format string) error {
+	switch format {
+	case "", BodyFormatJSON, BodyFormatForm, BodyFormatMultipart, BodyFormatNone:
+		return nil
+	default:
+		return fmt.Errorf("invalid body format %q", format)
+	}
+}
+
+// encodeBody encodes the rendered request template in the given format.
+// It takes format of type string and body of type []byte which is the rendered JSON template.
+// It returns the encoded body, its content type and an error if the body can't be encoded.
+// Form and multipart bodies require the template to be a JSON object with scalar fields or lists of scalars.
+func encodeBody(format string, body []byte) (data []byte, contentType string, err error) {
+	switch format {
+	case BodyFormatForm:
+		fields, err := formFields(body)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return []byte(fields.Encode()), "application/x-www-form-urlencoded", nil
+	case BodyFormatMultipart:
+		fields, err := formFields(body)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return encodeMultipart(fields)
+	default:
+		return body, "application/json", nil
+	}
+}
+
+// encodeMultipart encodes the fields as a multipart form.
+// It takes fields of type url.Values.
+// It returns the encoded body, its content type with the boundary and an error if the form can't be written.
+// Fields are written in the order of their names.
+func encodeMultipart(fields url.Values) (data []byte, contentType string, err error) {
+	var buf bytes.Buffer
+
+	w := multipart.NewWriter(&buf)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, value := range fields[name] {
+			if err := w.WriteField(name, value); err != nil {
+				return nil, "", fmt.Errorf("failed to write multipart field %s: %w", name, err)
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to close multipart form: %w", err)
+	}
+
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// formFields converts the rendered JSON object into form fields.
+// It takes body of type []byte.
+// It returns url.Values and an error if the body is not a JSON object or contains nested objects.
+// Null fields are omitted, and lists are converted into repeated fields.
+func formFields(body []byte) (url.Values, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var obj map[string]any
+
+	if err := dec.Decode(&obj); err != nil || obj == nil {
+		return nil, fmt.Errorf("request body must be a JSON object")
+	}
+
+	fields := make(url.Values, len(obj))
+
+	for name, value := range obj {
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+
+			str, ok := formValue(v)
+			if !ok {
+				return nil, fmt.Errorf("field %s must be a scalar or a list of scalars", name)
+			}
+
+			fields.Add(name, str)
+		}
+	}
+
+	return fields, nil
+}
+
+// formValue converts the scalar JSON value into a form value.
+// It takes v of type any.
+// It returns the value as a string and false if the value is not a scalar.
+func formValue(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case json.Number:
+		return val.String(), true
+	case bool:
+		return strconv.FormatBool(val), true
+	default:
+		return "", false
+	}
+}
+
+// appendQuery appends the query parameters to the URL.
+// It takes rawURL of type string and query of type url.Values.
+// It returns the URL with the encoded parameters, which are added to the existing query of the URL.
+func appendQuery(rawURL string, query url.Values) string {
+	if len(query) == 0 {
+		return rawURL
+	}
+
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+
+	return rawURL + sep + query.Encode()
+}
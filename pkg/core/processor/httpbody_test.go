@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBodyFormat(t *testing.T) {
+	for _, format := range []string{"", BodyFormatJSON, BodyFormatForm, BodyFormatMultipart, BodyFormatNone} {
+		assert.NoError(t, validateBodyFormat(format))
+	}
+
+	assert.EqualError(t, validateBodyFormat("xml"), `invalid body format "xml"`)
+}
+
+func TestEncodeBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		body            string
+		wantBody        string
+		wantContentType string
+		wantErr         string
+	}{
+		{
+			name:            "Default JSON",
+			body:            `{"key":"value"}`,
+			wantBody:        `{"key":"value"}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "Form",
+			format:          BodyFormatForm,
+			body:            `{"name":"John Doe","age":30,"active":true,"tags":["a","b"],"skip":null}`,
+			wantBody:        "active=true&age=30&name=John+Doe&tags=a&tags=b",
+			wantContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:    "Form with nested object",
+			format:  BodyFormatForm,
+			body:    `{"user":{"name":"John"}}`,
+			wantErr: "field user must be a scalar or a list of scalars",
+		},
+		{
+			name:    "Form with list of objects",
+			format:  BodyFormatForm,
+			body:    `{"users":[{"name":"John"}]}`,
+			wantErr: "field users must be a scalar or a list of scalars",
+		},
+		{
+			name:    "Form from non-object",
+			format:  BodyFormatForm,
+			body:    `["a"]`,
+			wantErr: "request body must be a JSON object",
+		},
+		{
+			name:    "Multipart from non-object",
+			format:  BodyFormatMultipart,
+			body:    `null`,
+			wantErr: "request body must be a JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType, err := encodeBody(tt.format, []byte(tt.body))
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, tt.wantContentType, contentType)
+		})
+	}
+}
+
+func TestEncodeBody_Multipart(t *testing.T) {
+	body, contentType, err := encodeBody(BodyFormatMultipart, []byte(`{"name":"John","tags":["a","b"],"age":30}`))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+
+	r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+
+	var got [][2]string
+
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		value, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		got = append(got, [2]string{part.FormName(), string(value)})
+	}
+
+	assert.Equal(t, [][2]string{{"age", "30"}, {"name", "John"}, {"tags", "a"}, {"tags", "b"}}, got)
+}
+
+func TestAppendQuery(t *testing.T) {
+	query := url.Values{"q": {"a b"}, "page": {"1"}}
+
+	assert.Equal(t, "http://example.com/search?page=1&q=a+b", appendQuery("http://example.com/search", query))
+	assert.Equal(t, "http://example.com/search?v=2&page=1&q=a+b", appendQuery("http://example.com/search?v=2", query))
+	assert.Equal(t, "http://example.com/search", appendQuery("http://example.com/search", nil))
+}
@@ -0,0 +1,217 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+)
+
+const defaultErrorMessage = "Request failed"
+
+type ErrorConfig struct {
+	Status      string `json:"status,omitempty" yaml:"status,omitempty"`
+	Code        string `json:"code,omitempty" yaml:"code,omitempty"`
+	Message     string `json:"message,omitempty" yaml:"message,omitempty"`
+	CodePath    string `json:"code_path,omitempty" yaml:"code_path,omitempty"`
+	MessagePath string `json:"message_path,omitempty" yaml:"message_path,omitempty"`
+}
+
+type errorMapping struct {
+	code        string
+	message     string
+	codePath    []string
+	messagePath []string
+	minStatus   int
+	maxStatus   int
+}
+
+var defaultErrorCodes = map[int]string{
+	http.StatusBadRequest:          "BadRequest",
+	http.StatusUnauthorized:        "AuthorizationRequired",
+	http.StatusForbidden:           "PermissionDenied",
+	http.StatusNotFound:            "NotFound",
+	http.StatusConflict:            "Conflict",
+	http.StatusUnprocessableEntity: "InputValidationFailed",
+	http.StatusTooManyRequests:     "RateLimit",
+	http.StatusServiceUnavailable:  "ServiceUnavailable",
+	http.StatusGatewayTimeout:      "Timeout",
+}
+
+// newErrorMappings creates error mappings from the provided configuration.
+// It takes cfg of type []ErrorConfig.
+// It returns a slice of errorMapping and an error if any of the mappings is invalid.
+func newErrorMappings(cfg []ErrorConfig) ([]errorMapping, error) {
+	mappings := make([]errorMapping, 0, len(cfg))
+
+	for i, c := range cfg {
+		if c.Code == "" && c.CodePath == "" {
+			return nil, fmt.Errorf("error mapping %d must define code or code_path", i)
+		}
+
+		minStatus, maxStatus, err := parseStatus(c.Status)
+		if err != nil {
+			return nil, fmt.Errorf("error mapping %d: %w", i, err)
+		}
+
+		mappings = append(mappings, errorMapping{
+			code:        c.Code,
+			message:     c.Message,
+			codePath:    splitPath(c.CodePath),
+			messagePath: splitPath(c.MessagePath),
+			minStatus:   minStatus,
+			maxStatus:   maxStatus,
+		})
+	}
+
+	return mappings, nil
+}
+
+// parseStatus parses the status code pattern of an error mapping.
+// It takes status of type string which is an exact status code like "404", a class like "4xx" or empty string for any error status.
+// It returns the inclusive range of matching status codes and an error if the pattern is invalid.
+// Mappings without status apply only to client and server errors, so successful responses are never mapped to errors by accident.
+func parseStatus(status string) (minStatus, maxStatus int, err error) {
+	if status == "" {
+		return http.StatusBadRequest, 599, nil
+	}
+
+	if len(status) == 3 && strings.HasSuffix(status, "xx") && status[0] >= '1' && status[0] <= '5' {
+		base := int(status[0]-'0') * 100
+		return base, base + 99, nil
+	}
+
+	code, err := strconv.Atoi(status)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("invalid status %q", status)
+	}
+
+	return code, code, nil
+}
+
+// match checks if the mapping applies to the response and creates an API error for it.
+// It takes status of type int and body of type any which is the decoded JSON body or nil if the body is not JSON.
+// It returns a pointer to core.APIError and true if the mapping applies to the response.
+// The mapping doesn't apply if the status doesn't match or the error code can't be resolved.
+func (m *errorMapping) match(status int, body any) (*core.APIError, bool) {
+	if status < m.minStatus || status > m.maxStatus {
+		return nil, false
+	}
+
+	code := m.code
+
+	if m.codePath != nil {
+		if v, ok := lookupString(body, m.codePath); ok {
+			code = v
+		}
+	}
+
+	if code == "" {
+		return nil, false
+	}
+
+	message := m.message
+
+	if m.messagePath != nil {
+		if v, ok := lookupString(body, m.messagePath); ok {
+			message = v
+		}
+	}
+
+	if message == "" {
+		message = statusMessage(status)
+	}
+
+	return core.NewAPIError(code, message, nil), true
+}
+
+// defaultError creates an API error for the responses with error status codes that aren't mapped explicitly.
+// It takes status of type int.
+// It returns a pointer to core.APIError.
+func defaultError(status int) *core.APIError {
+	code, ok := defaultErrorCodes[status]
+
+	switch {
+	case ok:
+	case status >= http.StatusInternalServerError:
+		code = "BackendError"
+	default:
+		code = "RequestFailed"
+	}
+
+	return core.NewAPIError(code, statusMessage(status), nil)
+}
+
+// statusMessage returns the default error message for the status code.
+// It takes status of type int.
+// It returns a string with the status text or a generic message if the status is unknown.
+func statusMessage(status int) string {
+	if text := http.StatusText(status); text != "" && status >= http.StatusBadRequest {
+		return text
+	}
+
+	return defaultErrorMessage
+}
+
+// splitPath splits a dot-separated JSON path into its segments.
+// It takes path of type string.
+// It returns a slice of strings or nil if the path is empty.
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, ".")
+}
+
+// lookupString looks up a scalar value in the decoded JSON document.
+// It takes doc of type any and path of type []string, where numeric segments address array elements.
+// It returns the value as a string and true if it's found and is a string or a number.
+func lookupString(doc any, path []string) (string, bool) {
+	doc, ok := lookupValue(doc, path)
+	if !ok {
+		return "", false
+	}
+
+	switch v := doc.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// lookupValue looks up a value in the decoded JSON document.
+// It takes doc of type any and path of type []string, where numeric segments address array elements.
+// It returns the value and true if it's found.
+func lookupValue(doc any, path []string) (any, bool) {
+	for _, key := range path {
+		switch v := doc.(type) {
+		case map[string]any:
+			val, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+
+			doc = val
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
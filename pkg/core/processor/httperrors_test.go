@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewErrorMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+		cfg     []ErrorConfig
+	}{
+		{
+			name: "Valid mappings",
+			cfg: []ErrorConfig{
+				{Status: "404", Code: "NotFound"},
+				{Status: "5xx", CodePath: "error.code"},
+				{CodePath: "error.code"},
+			},
+		},
+		{
+			name:    "Missing code",
+			cfg:     []ErrorConfig{{Status: "404", Message: "Not found"}},
+			wantErr: "error mapping 0 must define code or code_path",
+		},
+		{
+			name:    "Invalid status",
+			cfg:     []ErrorConfig{{Status: "abc", Code: "NotFound"}},
+			wantErr: "error mapping 0: invalid status \"abc\"",
+		},
+		{
+			name:    "Status out of range",
+			cfg:     []ErrorConfig{{Status: "600", Code: "NotFound"}},
+			wantErr: "error mapping 0: invalid status \"600\"",
+		},
+		{
+			name:    "Invalid status class",
+			cfg:     []ErrorConfig{{Status: "6xx", Code: "NotFound"}},
+			wantErr: "error mapping 0: invalid status \"6xx\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappings, err := newErrorMappings(tt.cfg)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, mappings)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, mappings, len(tt.cfg))
+			}
+		})
+	}
+}
+
+func TestErrorMapping_Match(t *testing.T) {
+	mappings, err := newErrorMappings([]ErrorConfig{
+		{Status: "4xx", Code: "ClientError", CodePath: "error.type"},
+		{CodePath: "code"},
+	})
+	require.NoError(t, err)
+
+	body := map[string]any{"error": map[string]any{"type": "Invalid"}}
+
+	apiErr, ok := mappings[0].match(http.StatusBadRequest, body)
+	assert.True(t, ok)
+	assert.Equal(t, core.NewAPIError("Invalid", "Bad Request", nil), apiErr)
+
+	apiErr, ok = mappings[0].match(http.StatusForbidden, nil)
+	assert.True(t, ok)
+	assert.Equal(t, core.NewAPIError("ClientError", "Forbidden", nil), apiErr)
+
+	_, ok = mappings[0].match(http.StatusInternalServerError, body)
+	assert.False(t, ok)
+
+	_, ok = mappings[1].match(http.StatusBadGateway, body)
+	assert.False(t, ok)
+
+	apiErr, ok = mappings[1].match(http.StatusBadGateway, map[string]any{"code": "Failed"})
+	assert.True(t, ok)
+	assert.Equal(t, core.NewAPIError("Failed", "Bad Gateway", nil), apiErr)
+
+	_, ok = mappings[1].match(http.StatusOK, map[string]any{"code": "Failed"})
+	assert.False(t, ok, "mapping without status must not apply to successful responses")
+}
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantMin int
+		wantMax int
+	}{
+		{status: "", wantMin: 400, wantMax: 599},
+		{status: "2xx", wantMin: 200, wantMax: 299},
+		{status: "404", wantMin: 404, wantMax: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			minStatus, maxStatus, err := parseStatus(tt.status)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMin, minStatus)
+			assert.Equal(t, tt.wantMax, maxStatus)
+		})
+	}
+}
+
+func TestDefaultError(t *testing.T) {
+	tests := []struct {
+		want   *core.APIError
+		status int
+	}{
+		{status: http.StatusUnauthorized, want: core.NewAPIError("AuthorizationRequired", "Unauthorized", nil)},
+		{status: http.StatusTooManyRequests, want: core.NewAPIError("RateLimit", "Too Many Requests", nil)},
+		{status: http.StatusTeapot, want: core.NewAPIError("RequestFailed", "I'm a teapot", nil)},
+		{status: http.StatusGatewayTimeout, want: core.NewAPIError("Timeout", "Gateway Timeout", nil)},
+		{status: 599, want: core.NewAPIError("BackendError", "Request failed", nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.want, defaultError(tt.status))
+		})
+	}
+}
+
+func TestLookupString(t *testing.T) {
+	doc := map[string]any{
+		"a": []any{map[string]any{"b": "c"}, float64(1.5)},
+		"d": "",
+	}
+
+	tests := []struct {
+		name   string
+		want   string
+		path   []string
+		wantOk bool
+	}{
+		{name: "Nested string", path: []string{"a", "0", "b"}, want: "c", wantOk: true},
+		{name: "Number", path: []string{"a", "1"}, want: "1.5", wantOk: true},
+		{name: "Empty string", path: []string{"d"}},
+		{name: "Object", path: []string{"a", "0"}},
+		{name: "Index out of range", path: []string{"a", "2"}},
+		{name: "Invalid index", path: []string{"a", "x"}},
+		{name: "Missing key", path: []string{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupString(doc, tt.path)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,7 +3,13 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
@@ -11,43 +17,77 @@ import (
 	"github.com/ksysoev/deriv-api-bff/pkg/core/tmpl"
 )
 
+const (
+	StatusField  = "_status"
+	HeadersField = "_headers"
+)
+
 type errData struct {
 	Err json.RawMessage `json:"error,omitempty"`
 }
 
 type HTTPProc struct {
-	urlTemplate *tmpl.URLTmpl
-	tmpl        *tmpl.Tmpl
+	urlTemplate tmpl.StringTemplate
+	tmpl        tmpl.JSONTemplate
 	fieldMap    map[string]string
-	headers     map[string]*tmpl.StrTmpl
+	headers     map[string]tmpl.StringTemplate
+	query       map[string]tmpl.StringTemplate
+	pages       *pagination
+	schema      *respSchema
+	secrets     *secretRefs
 	name        string
 	method      string
+	format      string
+	bodyFormat  string
+	auth        string
+	signer      string
 	allow       []string
+	errors      []errorMapping
 }
 
 // NewHTTP creates a new instance of HTTPProc based on the provided configuration.
 // It takes a single parameter cfg of type *Config which contains the necessary configuration details.
 // It returns a pointer to an HTTPProc initialized with the values from the configuration.
-func NewHTTP(cfg *Config) (*HTTPProc, error) {
-	rawTmpl, err := json.Marshal(cfg.Request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request template: %w", err)
+// Requests are sent without a body if the request template is empty or the body format is "none".
+// Values of `secret` and `env` references of templates are resolved with the provider set by WithSecrets.
+func NewHTTP(cfg *Config, opts ...Option) (*HTTPProc, error) {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := validateBodyFormat(cfg.BodyFormat); err != nil {
+		return nil, err
 	}
 
-	reqTmpl, err := tmpl.New(string(rawTmpl))
+	engine, err := tmpl.NewEngine(cfg.TemplateEngine)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse request template: %w", err)
+		return nil, fmt.Errorf("invalid template engine: %w", err)
 	}
 
-	urlTmpl, err := tmpl.NewURLTmpl(cfg.URL)
+	var reqTmpl tmpl.JSONTemplate
+
+	if len(cfg.Request) > 0 && cfg.BodyFormat != BodyFormatNone {
+		rawTmpl, err := json.Marshal(cfg.Request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request template: %w", err)
+		}
+
+		if reqTmpl, err = engine.JSON(string(rawTmpl)); err != nil {
+			return nil, fmt.Errorf("failed to parse request template: %w", err)
+		}
+	}
+
+	urlTmpl, err := engine.URL(cfg.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL template: %w", err)
 	}
 
-	headers := make(map[string]*tmpl.StrTmpl, len(cfg.Headers))
+	headers := make(map[string]tmpl.StringTemplate, len(cfg.Headers))
 
 	for key, value := range cfg.Headers {
-		t, err := tmpl.NewStrTmpl(value)
+		t, err := engine.String(value)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse header template %s: %w", key, err)
 		}
@@ -55,15 +95,57 @@ func NewHTTP(cfg *Config) (*HTTPProc, error) {
 		headers[key] = t
 	}
 
-	return &HTTPProc{
+	query := make(map[string]tmpl.StringTemplate, len(cfg.Query))
+
+	for key, value := range cfg.Query {
+		t, err := engine.String(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query template %s: %w", key, err)
+		}
+
+		query[key] = t
+	}
+
+	if err := validateFormat(cfg.Format); err != nil {
+		return nil, err
+	}
+
+	errMappings, err := newErrorMappings(cfg.Errors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse error mappings: %w", err)
+	}
+
+	pages, err := newPagination(cfg.Paginate, PaginateCursor, PaginateOffset, PaginateLink)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paginate config: %w", err)
+	}
+
+	schema, err := newRespSchema(cfg.ResponseSchema, cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+
+	p := &HTTPProc{
 		name:        cfg.Name,
 		method:      cfg.Method,
+		format:      cfg.Format,
+		bodyFormat:  cfg.BodyFormat,
+		query:       query,
+		auth:        cfg.Auth,
+		signer:      cfg.Signer,
 		urlTemplate: urlTmpl,
 		tmpl:        reqTmpl,
 		fieldMap:    cfg.FieldMap,
 		allow:       cfg.Allow,
 		headers:     headers,
-	}, nil
+		errors:      errMappings,
+		pages:       pages,
+		schema:      schema,
+	}
+
+	p.secrets = newSecretRefs(p.Refs(), o.secrets)
+
+	return p, nil
 }
 
 // Name returns the name of the HTTPProc instance.
@@ -73,14 +155,48 @@ func (p *HTTPProc) Name() string {
 	return p.name
 }
 
+// Refs returns references of templates of the processor to the template data, keyed by location of the template.
+// Locations are "request" for the request body, "url" for the URL, and "headers.<name>" and "query.<name>"
+// for headers and query parameters.
+func (p *HTTPProc) Refs() map[string][]tmpl.Ref {
+	refs := map[string][]tmpl.Ref{
+		"url": p.urlTemplate.Refs(),
+	}
+
+	if p.tmpl != nil {
+		refs["request"] = p.tmpl.Refs()
+	}
+
+	for name, t := range p.headers {
+		refs["headers."+name] = t.Refs()
+	}
+
+	for name, t := range p.query {
+		refs["query."+name] = t.Refs()
+	}
+
+	return refs
+}
+
 // Render processes the HTTP request and writes the response.
 // It takes an io.Writer, an int64, and two maps of string to any type as parameters.
 // It returns an error indicating that the HTTP processor is not implemented.
+// Rendered query parameters are URL-encoded and appended to the URL, and the body is encoded in the configured format.
+// The Content-Type header is set according to the body format, unless it's configured explicitly.
+// For paginated backends, the page size is added to the query of the first page.
 func (p *HTTPProc) Render(ctx context.Context, reqID string, param []byte, deps map[string]any) (core.Request, error) {
+	secrets, env, err := p.secrets.resolve()
+	if err != nil {
+		return nil, err
+	}
+
 	data := templateData{
 		Params: param,
 		Resp:   deps,
 		ReqID:  reqID,
+		Conn:   connInfo(ctx),
+		Secret: secrets,
+		Env:    env,
 	}
 
 	url, err := p.urlTemplate.Execute(data)
@@ -88,26 +204,61 @@ func (p *HTTPProc) Render(ctx context.Context, reqID string, param []byte, deps
 		return nil, fmt.Errorf("fail to execute URL template %s: %w", p.name, err)
 	}
 
-	var body []byte
+	if len(p.query) > 0 {
+		query := make(neturl.Values, len(p.query))
+
+		for key, value := range p.query {
+			queryValue, err := value.Execute(data)
+			if err != nil {
+				return nil, fmt.Errorf("fail to execute query template %s: %w", key, err)
+			}
+
+			query.Set(key, queryValue)
+		}
+
+		url = appendQuery(url, query)
+	}
+
+	var (
+		body        []byte
+		contentType string
+	)
 
 	if p.tmpl != nil {
 		body, err = p.tmpl.Execute(data)
 		if err != nil {
 			return nil, fmt.Errorf("fail to execute request template %s: %w", p.name, err)
 		}
+
+		body, contentType, err = encodeBody(p.bodyFormat, body)
+		if err != nil {
+			return nil, fmt.Errorf("fail to encode request body %s: %w", p.name, err)
+		}
 	}
 
 	req := request.NewHTTPReq(ctx, p.method, url, body, reqID)
+	req.SetAuth(p.auth)
+	req.SetSigner(p.signer)
 
-	if p.headers != nil {
-		for key, value := range p.headers {
-			headerValue, err := value.Execute(data)
-			if err != nil {
-				return nil, fmt.Errorf("fail to execute header template %s: %w", key, err)
-			}
+	hasContentType := false
 
-			req.AddHeader(key, headerValue)
+	for key, value := range p.headers {
+		headerValue, err := value.Execute(data)
+		if err != nil {
+			return nil, fmt.Errorf("fail to execute header template %s: %w", key, err)
 		}
+
+		req.AddHeader(key, headerValue)
+
+		hasContentType = hasContentType || strings.EqualFold(key, "Content-Type")
+	}
+
+	if contentType != "" && !hasContentType {
+		req.AddHeader("Content-Type", contentType)
+	}
+
+	if p.pages != nil {
+		return p.pageRequest(req, "")
 	}
 
 	return req, nil
@@ -117,38 +268,163 @@ func (p *HTTPProc) Render(ctx context.Context, reqID string, param []byte, deps
 // It takes data of type []byte.
 // It returns a pointer to response.Response and an error.
 // It returns an error if parsing or preparing the response fails.
+// The status code and headers of the response are added to the response as _status and _headers fields,
+// so they can be selected by allow and used by templates of dependent calls.
+// Bodies that aren't objects are wrapped into an object under the list or value key, like for allow, to hold these fields.
+// If the response schema is configured, the response body is validated against it, and violations fail the call in strict mode.
 func (p *HTTPProc) Parse(data []byte) (*response.Response, error) {
-	resp, err := p.parse(data)
+	httpResp, err := response.DecodeHTTPResp(data)
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse response %s: %w", p.name, err)
+	}
+
+	resp, err := p.parseResp(httpResp)
 	if err != nil {
 		return nil, fmt.Errorf("fail to parse response %s: %w", p.name, err)
 	}
 
+	if err := p.schema.check(resp); err != nil {
+		return nil, fmt.Errorf("invalid response %s: %w", p.name, err)
+	}
+
 	prepared, err := prepareResp(resp)
 	if err != nil {
 		return nil, fmt.Errorf("fail to prepare response %s: %w", p.name, err)
 	}
 
+	if err := addResponseMeta(prepared, httpResp); err != nil {
+		return nil, fmt.Errorf("fail to prepare response %s: %w", p.name, err)
+	}
+
+	if resp, err = json.Marshal(prepared); err != nil {
+		return nil, fmt.Errorf("fail to prepare response %s: %w", p.name, err)
+	}
+
 	filetered := filterResp(prepared, p.allow, p.fieldMap)
 
 	return response.New(resp, filetered), nil
 }
 
-// parse parses the given byte slice as JSON and returns a json.RawMessage.
-// It takes data of type []byte.
+// Paginate fetches all pages of the paginated response and returns their items as a single list.
+// It takes data of type []byte which is the response of the first page and fetch of type core.PageFetcher which fetches the following pages.
+// It returns a pointer to response.Response with the list of items as its body and an error if any of the pages can't be fetched or parsed.
+// If the response schema is configured, each page is validated against it, and violations fail the call in strict mode.
+func (p *HTTPProc) Paginate(data []byte, fetch core.PageFetcher) (*response.Response, error) {
+	list, err := p.pages.paginate(data, fetch, p)
+	if err != nil {
+		return nil, fmt.Errorf("fail to paginate response %s: %w", p.name, err)
+	}
+
+	prepared, err := prepareResp(list)
+	if err != nil {
+		return nil, fmt.Errorf("fail to prepare response %s: %w", p.name, err)
+	}
+
+	return response.New(list, filterResp(prepared, p.allow, p.fieldMap)), nil
+}
+
+// page parses the given HTTP response envelope as a page of the paginated response.
+// It takes data of type []byte which is an encoded response.HTTPResp.
+// It returns the page and an error if the envelope is invalid, the response can't be parsed or doesn't match the schema in strict mode.
+func (p *HTTPProc) page(data []byte) (page, error) {
+	resp, err := response.DecodeHTTPResp(data)
+	if err != nil {
+		return page{}, err
+	}
+
+	body, err := p.parseResp(resp)
+	if err != nil {
+		return page{}, err
+	}
+
+	if err := p.schema.check(body); err != nil {
+		return page{}, err
+	}
+
+	return page{body: body, headers: resp.Headers()}, nil
+}
+
+// pageRequest points the request to the given page.
+// It takes req of type core.Request and next of type string which is the cursor, offset or link of the page, or empty for the first page.
+// It returns the request and an error if its URL is invalid or the link points to another host.
+// Cursors and offsets are set as query parameters along with the page size, while links replace the URL of the request.
+func (p *HTTPProc) pageRequest(req core.Request, next string) (core.Request, error) {
+	httpReq, ok := req.(*request.HTTPReq)
+	if !ok {
+		return nil, fmt.Errorf("unexpected request type %T", req)
+	}
+
+	u, err := neturl.Parse(httpReq.URL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL: %w", err)
+	}
+
+	if p.pages.typ == PaginateLink && next != "" {
+		link, err := u.Parse(next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page link: %w", err)
+		}
+
+		if link.Scheme != u.Scheme || link.Host != u.Host {
+			return nil, fmt.Errorf("next page link %s points to another host", next)
+		}
+
+		httpReq.SetURL(link.String())
+
+		return httpReq, nil
+	}
+
+	query := u.Query()
+
+	if next != "" {
+		query.Set(p.pages.param, next)
+	}
+
+	if p.pages.pageSize > 0 {
+		query.Set(p.pages.limitParam, strconv.Itoa(p.pages.pageSize))
+	}
+
+	u.RawQuery = query.Encode()
+	httpReq.SetURL(u.String())
+
+	return httpReq, nil
+}
+
+// parse parses the given HTTP response envelope and returns its body as a json.RawMessage.
+// It takes data of type []byte which is an encoded response.HTTPResp.
 // It returns a json.RawMessage and an error.
-// It returns an error if the data is empty, if the data cannot be unmarshaled, or if the unmarshaled data contains an error.
-// If the data starts with '{', it attempts to unmarshal it into an errData struct and checks for an error field.
+// It returns an error if the envelope is invalid or the response can't be parsed.
 func (p *HTTPProc) parse(data []byte) (json.RawMessage, error) {
-	if len(data) == 0 {
-		return nil, fmt.Errorf("response body not found")
+	resp, err := response.DecodeHTTPResp(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseResp(resp)
+}
+
+// parseResp parses the given HTTP response and returns its body as a json.RawMessage.
+// It takes resp of type *response.HTTPResp.
+// It returns a json.RawMessage and an error.
+// It returns an error if the body is empty or cannot be unmarshaled, or if the response is an error.
+// Error mappings are checked first, then the error field of the body, and then the status code is mapped to a default error.
+// Bodies of other formats are converted into JSON, where the format is configured or detected by the Content-Type header.
+func (p *HTTPProc) parseResp(resp *response.HTTPResp) (json.RawMessage, error) {
+	status := resp.StatusCode()
+	body := resp.Body()
+
+	format := p.format
+	if format == "" || format == FormatAuto {
+		format = detectFormat(resp.Headers(), body)
 	}
 
-	var jsonData json.RawMessage
-	if err := json.Unmarshal(data, &jsonData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
+	jsonData, jsonErr := convertBody(format, body)
+
+	if err := p.mapError(status, jsonData); err != nil {
+		return nil, err
 	}
 
-	if jsonData[0] == '{' {
+	if jsonErr == nil && jsonData[0] == '{' {
 		var errRaw errData
 
 		if err := json.Unmarshal(jsonData, &errRaw); err != nil {
@@ -156,9 +432,91 @@ func (p *HTTPProc) parse(data []byte) (json.RawMessage, error) {
 		}
 
 		if errRaw.Err != nil {
-			return nil, NewAPIError(errRaw.Err)
+			apiErr := NewAPIError(errRaw.Err)
+
+			var coreErr *core.APIError
+			if status < http.StatusBadRequest || errors.As(apiErr, &coreErr) {
+				return nil, apiErr
+			}
 		}
 	}
 
+	if status >= http.StatusBadRequest {
+		return nil, defaultError(status)
+	}
+
+	if len(body) == 0 {
+		return nil, fmt.Errorf("response body not found")
+	}
+
+	if jsonErr != nil && format == FormatJSON {
+		return nil, fmt.Errorf("failed to unmarshal response body: %w", jsonErr)
+	} else if jsonErr != nil {
+		return nil, jsonErr
+	}
+
 	return jsonData, nil
 }
+
+// mapError applies the configured error mappings to the response.
+// It takes status of type int and body of type json.RawMessage which is nil if the body is not valid JSON.
+// It returns an error produced by the first matching mapping or nil if none of the mappings applies.
+func (p *HTTPProc) mapError(status int, body json.RawMessage) error {
+	if len(p.errors) == 0 {
+		return nil
+	}
+
+	var doc any
+
+	if body != nil {
+		_ = json.Unmarshal(body, &doc)
+	}
+
+	for i := range p.errors {
+		if apiErr, ok := p.errors[i].match(status, doc); ok {
+			return apiErr
+		}
+	}
+
+	return nil
+}
+
+// addResponseMeta adds the status code and headers of the HTTP response to the prepared response.
+// It takes prepared of type map[string]json.RawMessage and resp of type *response.HTTPResp.
+// It returns an error if the headers can't be marshaled.
+// Header names are lowercased, and multiple values of a header are joined with commas.
+// Fields of the response body with the same names are kept, and a warning is logged, because they are likely meaningful to clients.
+func addResponseMeta(prepared map[string]json.RawMessage, resp *response.HTTPResp) error {
+	headers := make(map[string]string, len(resp.Headers()))
+
+	for name, values := range resp.Headers() {
+		name = strings.ToLower(name)
+
+		if existing, ok := headers[name]; ok {
+			values = append([]string{existing}, values...)
+		}
+
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	rawHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response headers: %w", err)
+	}
+
+	meta := map[string]json.RawMessage{
+		StatusField:  json.RawMessage(strconv.Itoa(resp.StatusCode())),
+		HeadersField: rawHeaders,
+	}
+
+	for key, value := range meta {
+		if _, ok := prepared[key]; ok {
+			slog.Warn("Response body field shadows HTTP response metadata", slog.String("key", key))
+			continue
+		}
+
+		prepared[key] = value
+	}
+
+	return nil
+}
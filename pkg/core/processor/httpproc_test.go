@@ -3,14 +3,27 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/tmpl"
+	"github.com/ksysoev/wasabi/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+func encodeHTTPResp(t *testing.T, status int, body []byte) []byte {
+	t.Helper()
+
+	data, err := response.NewHTTPResp(status, nil, body).Encode()
+	require.NoError(t, err)
+
+	return data
+}
+
 func TestHTTPProc_Parse(t *testing.T) {
 	tests := []struct {
 		fieldMap map[string]string
@@ -26,7 +39,7 @@ func TestHTTPProc_Parse(t *testing.T) {
 			data:     []byte(`{"key1": "value1", "key2": 2}`),
 			allow:    []string{"key1", "key2"},
 			fieldMap: nil,
-			wantResp: json.RawMessage(`{"key1": "value1", "key2": 2}`),
+			wantResp: json.RawMessage(`{"key1": "value1", "key2": 2, "_status": 200, "_headers": {}}`),
 			wantFilt: map[string]json.RawMessage{"key1": []byte(`"value1"`), "key2": []byte("2")},
 			wantErr:  false,
 		},
@@ -35,7 +48,7 @@ func TestHTTPProc_Parse(t *testing.T) {
 			data:     []byte(`{"key1": "value1", "key2": 2}`),
 			allow:    []string{"key1", "key2"},
 			fieldMap: map[string]string{"key1": "mappedKey1"},
-			wantResp: json.RawMessage(`{"key1": "value1", "key2": 2}`),
+			wantResp: json.RawMessage(`{"key1": "value1", "key2": 2, "_status": 200, "_headers": {}}`),
 			wantFilt: map[string]json.RawMessage{"mappedKey1": []byte(`"value1"`), "key2": []byte("2")},
 			wantErr:  false,
 		},
@@ -44,7 +57,7 @@ func TestHTTPProc_Parse(t *testing.T) {
 			data:     []byte(`{"key1": "value1"}`),
 			allow:    []string{"key1", "key2"},
 			fieldMap: nil,
-			wantResp: json.RawMessage(`{"key1": "value1"}`),
+			wantResp: json.RawMessage(`{"key1": "value1", "_status": 200, "_headers": {}}`),
 			wantFilt: map[string]json.RawMessage{"key1": []byte(`"value1"`)},
 			wantErr:  false,
 		},
@@ -62,7 +75,7 @@ func TestHTTPProc_Parse(t *testing.T) {
 			data:     []byte(`123`),
 			allow:    []string{"value"},
 			fieldMap: nil,
-			wantResp: []byte(`123`),
+			wantResp: []byte(`{"value": 123, "_status": 200, "_headers": {}}`),
 			wantFilt: map[string]json.RawMessage{"value": []byte("123")},
 			wantErr:  false,
 		},
@@ -74,19 +87,191 @@ func TestHTTPProc_Parse(t *testing.T) {
 				allow:    tt.allow,
 				fieldMap: tt.fieldMap,
 			}
-			resp, err := p.Parse(tt.data)
+			resp, err := p.Parse(encodeHTTPResp(t, http.StatusOK, tt.data))
 
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, resp)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.wantResp, resp.Body())
+				assert.JSONEq(t, string(tt.wantResp), string(resp.Body()))
 				assert.Equal(t, tt.wantFilt, resp.Filtered())
 			}
 		})
 	}
 }
+func TestHTTPProc_Parse_ResponseMeta(t *testing.T) {
+	headers := map[string][]string{
+		"Etag":          {`"v1"`},
+		"X-Total-Count": {"42"},
+		"Link":          {`<http://localhost/?page=2>; rel="next"`, `<http://localhost/?page=5>; rel="last"`},
+	}
+
+	tests := []struct {
+		wantFilt map[string]json.RawMessage
+		name     string
+		body     string
+		wantResp string
+		allow    []string
+	}{
+		{
+			name:  "Object body",
+			body:  `{"items":[1,2]}`,
+			allow: []string{"items", "_status", "_headers"},
+			wantResp: `{"items":[1,2],"_status":200,"_headers":{"etag":"\"v1\"","x-total-count":"42",` +
+				`"link":"<http://localhost/?page=2>; rel=\"next\", <http://localhost/?page=5>; rel=\"last\""}}`,
+			wantFilt: map[string]json.RawMessage{
+				"items":   json.RawMessage(`[1,2]`),
+				"_status": json.RawMessage(`200`),
+				"_headers": json.RawMessage(`{"etag":"\"v1\"","link":"<http://localhost/?page=2>; rel=\"next\", ` +
+					`<http://localhost/?page=5>; rel=\"last\"","x-total-count":"42"}`),
+			},
+		},
+		{
+			name:  "Array body",
+			body:  `[1,2]`,
+			allow: []string{"list", "_status"},
+			wantResp: `{"list":[1,2],"_status":200,"_headers":{"etag":"\"v1\"","x-total-count":"42",` +
+				`"link":"<http://localhost/?page=2>; rel=\"next\", <http://localhost/?page=5>; rel=\"last\""}}`,
+			wantFilt: map[string]json.RawMessage{
+				"list":    json.RawMessage(`[1,2]`),
+				"_status": json.RawMessage(`200`),
+			},
+		},
+		{
+			name:     "Body fields with metadata names",
+			body:     `{"_status":"active","_headers":["a"]}`,
+			allow:    []string{"_status", "_headers"},
+			wantResp: `{"_status":"active","_headers":["a"]}`,
+			wantFilt: map[string]json.RawMessage{
+				"_status":  json.RawMessage(`"active"`),
+				"_headers": json.RawMessage(`["a"]`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := response.NewHTTPResp(http.StatusOK, headers, []byte(tt.body)).Encode()
+			require.NoError(t, err)
+
+			p := &HTTPProc{allow: tt.allow}
+
+			resp, err := p.Parse(data)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, tt.wantResp, string(resp.Body()))
+			assert.Len(t, resp.Filtered(), len(tt.wantFilt))
+
+			for key, want := range tt.wantFilt {
+				assert.JSONEq(t, string(want), string(resp.Filtered()[key]))
+			}
+		})
+	}
+}
+
+func TestHTTPProc_Render_ResponseMetaOfDependency(t *testing.T) {
+	dep := &HTTPProc{}
+
+	data, err := response.NewHTTPResp(http.StatusOK, map[string][]string{"X-Total-Count": {"42"}}, []byte(`{"items":[]}`)).Encode()
+	require.NoError(t, err)
+
+	depResp, err := dep.Parse(data)
+	require.NoError(t, err)
+
+	p, err := NewHTTP(&Config{
+		Method: "GET",
+		URL:    "http://localhost/summary",
+		Query:  map[string]string{"total": "${resp.list._headers.x-total-count}"},
+	})
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "1", []byte(`{}`), map[string]any{"list": depResp.Body()})
+	require.NoError(t, err)
+
+	assert.Equal(t, "GET http://localhost/summary?total=42", req.RoutingKey())
+}
+
+func TestHTTPProc_Render_ResponseMetaOfListDependency(t *testing.T) {
+	dep := &HTTPProc{}
+
+	data, err := response.NewHTTPResp(http.StatusOK, map[string][]string{"X-Total-Count": {"42"}}, []byte(`[{"id":7}]`)).Encode()
+	require.NoError(t, err)
+
+	depResp, err := dep.Parse(data)
+	require.NoError(t, err)
+
+	p, err := NewHTTP(&Config{
+		Method: "GET",
+		URL:    "http://localhost/summary",
+		Query:  map[string]string{"total": "${resp.users._headers.x-total-count}", "status": "${resp.users._status}"},
+	})
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "1", []byte(`{}`), map[string]any{"users": depResp.Body()})
+	require.NoError(t, err)
+
+	assert.Equal(t, "GET http://localhost/summary?status=200&total=42", req.RoutingKey())
+}
+
+func TestHTTPProc_Render_ConnInfo(t *testing.T) {
+	mockConn := mocks.NewMockConnection(t)
+	mockConn.EXPECT().ID().Return("conn-1")
+	mockConn.EXPECT().Context().Return(core.ContextWithConnInfo(context.Background(), &core.ConnInfo{
+		Headers: map[string]string{"User-Agent": "test-agent"},
+		Query:   map[string]string{"app_id": "1089"},
+		IP:      "1.2.3.4",
+	}))
+
+	ctx := core.ContextWithConn(context.Background(), core.NewConnection(mockConn, func(string) {}))
+
+	p, err := NewHTTP(&Config{
+		Method:  "POST",
+		URL:     "http://localhost/events?app_id=${conn.query.app_id}",
+		Headers: map[string]string{"X-Forwarded-For": "${conn.ip}"},
+		Request: map[string]any{"conn_id": "${conn.id}", "agent": "${conn.headers.User-Agent}"},
+	})
+	require.NoError(t, err)
+
+	req, err := p.Render(ctx, "1", []byte(`{}`), nil)
+	require.NoError(t, err)
+
+	httpReq, ok := req.(*request.HTTPReq)
+	require.True(t, ok)
+	assert.Equal(t, "POST http://localhost/events?app_id=1089", req.RoutingKey())
+	assert.Equal(t, []string{"1.2.3.4"}, httpReq.Headers()["X-Forwarded-For"])
+	assert.JSONEq(t, `{"conn_id": "conn-1", "agent": "test-agent"}`, string(req.Data()))
+
+	_, err = p.Render(context.Background(), "1", []byte(`{}`), nil)
+	assert.ErrorContains(t, err, "fail to execute URL template")
+}
+
+func TestHTTPProc_Render_GoTemplate(t *testing.T) {
+	p, err := NewHTTP(&Config{
+		Method:         "POST",
+		URL:            "http://localhost/users/{{ .params.id }}?q={{ .params.query }}",
+		Headers:        map[string]string{"X-Request-ID": "{{ .req_id }}"},
+		Request:        map[string]any{"ids": "{{ .params.ids }}", "name": "{{ .params.name | default \"guest\" }}"},
+		TemplateEngine: "go",
+	})
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "1", []byte(`{"id":5,"query":"a b","ids":[1,2]}`), nil)
+	require.NoError(t, err)
+
+	httpReq, ok := req.(*request.HTTPReq)
+	require.True(t, ok)
+	assert.Equal(t, "POST http://localhost/users/5?q=a+b", req.RoutingKey())
+	assert.Equal(t, []string{"1"}, httpReq.Headers()["X-Request-ID"])
+	assert.JSONEq(t, `{"ids":[1,2],"name":"guest"}`, string(req.Data()))
+
+	assert.Equal(t, map[string][]tmpl.Ref{
+		"url":                  {{Path: "params.id", Raw: true}, {Path: "params.query", Raw: true}},
+		"request":              {{Path: "params.ids", Raw: true}, {Path: "params.name"}},
+		"headers.X-Request-ID": {{Path: "req_id", Raw: true}},
+	}, p.Refs())
+}
+
 func TestHTTPProc_Name(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -113,6 +298,24 @@ func TestHTTPProc_Name(t *testing.T) {
 		})
 	}
 }
+func TestHTTPProc_Refs(t *testing.T) {
+	p, err := NewHTTP(&Config{
+		Method:  "POST",
+		URL:     "http://localhost/users/${params.id}",
+		Request: map[string]any{"name": "${params.name | upper}"},
+		Headers: map[string]string{"X-Token": "${resp_auth.token}"},
+		Query:   map[string]string{"limit": "${params.limit | default 10}"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]tmpl.Ref{
+		"url":             {{Path: "params.id", Raw: true}},
+		"request":         {{Path: "params.name"}},
+		"headers.X-Token": {{Path: "resp_auth.token", Raw: true}},
+		"query.limit":     {{Path: "params.limit"}},
+	}, p.Refs())
+}
+
 func TestNewHTTP(t *testing.T) {
 	tests := []struct {
 		cfg     *Config
@@ -193,6 +396,39 @@ func TestNewHTTP(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Invalid response format",
+			cfg: &Config{
+				Name:    "TestProcessor",
+				Method:  "GET",
+				URL:     "/test/url",
+				Request: map[string]any{"key": "value"},
+				Format:  "yaml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid body format",
+			cfg: &Config{
+				Name:       "TestProcessor",
+				Method:     "POST",
+				URL:        "/test/url",
+				Request:    map[string]any{"key": "value"},
+				BodyFormat: "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Query parse error",
+			cfg: &Config{
+				Name:    "TestProcessor",
+				Method:  "GET",
+				URL:     "/test/url",
+				Request: map[string]any{"key": "value"},
+				Query:   map[string]string{"q": "${params.q"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -234,7 +470,7 @@ func TestHTTPProc_Render(t *testing.T) {
 				method:      "POST",
 				urlTemplate: tmpl.MustNewURLTmpl("http://example.com/${req_id}"),
 				tmpl:        tmpl.MustNewTmpl(`{"param": "${params.param}"}`),
-				headers:     map[string]*tmpl.StrTmpl{"Authorization": tmpl.MustNewStrTmpl("application/json")},
+				headers:     map[string]tmpl.StringTemplate{"Authorization": tmpl.MustNewStrTmpl("application/json")},
 			},
 			reqID:       "123",
 			param:       []byte(`{"param":"value"}`),
@@ -251,7 +487,7 @@ func TestHTTPProc_Render(t *testing.T) {
 				method:      "GET",
 				urlTemplate: tmpl.MustNewURLTmpl("http://example.com/${req_id}"),
 				tmpl:        nil,
-				headers:     map[string]*tmpl.StrTmpl{"Authorization": tmpl.MustNewStrTmpl("application/json")},
+				headers:     map[string]tmpl.StringTemplate{"Authorization": tmpl.MustNewStrTmpl("application/json")},
 			},
 			reqID:       "123",
 			param:       []byte(`{"param": "value"}`),
@@ -300,7 +536,7 @@ func TestHTTPProc_Render(t *testing.T) {
 				method:      "POST",
 				urlTemplate: tmpl.MustNewURLTmpl("http://example.com/${req_id}"),
 				tmpl:        tmpl.MustNewTmpl(`{"param": "${params.param}"}`),
-				headers:     map[string]*tmpl.StrTmpl{"Authorization": tmpl.MustNewStrTmpl("Bearer ${params.token}")},
+				headers:     map[string]tmpl.StringTemplate{"Authorization": tmpl.MustNewStrTmpl("Bearer ${params.token}")},
 			},
 			reqID:       "123",
 			param:       []byte(`{"param": "value", "token": "abc123"}`),
@@ -340,6 +576,130 @@ func TestHTTPProc_Render(t *testing.T) {
 	}
 }
 
+func TestHTTPProc_Render_BodyAndQuery(t *testing.T) {
+	tests := []struct {
+		cfg             *Config
+		name            string
+		param           string
+		wantURL         string
+		wantBody        string
+		wantContentType string
+		wantErr         string
+	}{
+		{
+			name: "JSON body",
+			cfg: &Config{
+				Method:  "POST",
+				URL:     "http://example.com/users",
+				Request: map[string]any{"name": "${params.name}"},
+			},
+			param:           `{"name":"John"}`,
+			wantURL:         "POST http://example.com/users",
+			wantBody:        `{"name":"John"}`,
+			wantContentType: "application/json",
+		},
+		{
+			name: "Form body with query",
+			cfg: &Config{
+				Signer:     "partner",
+				Method:     "POST",
+				URL:        "http://example.com/users?v=1",
+				Request:    map[string]any{"name": "${params.name}"},
+				BodyFormat: BodyFormatForm,
+				Query:      map[string]string{"q": "${params.q}", "lang": "en"},
+			},
+			param:           `{"name":"John Doe","q":"a&b"}`,
+			wantURL:         "POST http://example.com/users?v=1&lang=en&q=a%26b",
+			wantBody:        "name=John+Doe",
+			wantContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name: "No body",
+			cfg: &Config{
+				Auth:       "search",
+				Method:     "GET",
+				URL:        "http://example.com/users",
+				Request:    map[string]any{"name": "${params.name}"},
+				BodyFormat: BodyFormatNone,
+				Query:      map[string]string{"name": "${params.name}"},
+			},
+			param:   `{"name":"John"}`,
+			wantURL: "GET http://example.com/users?name=John",
+		},
+		{
+			name: "Empty request template",
+			cfg: &Config{
+				Method: "GET",
+				URL:    "http://example.com/users",
+			},
+			param:   `{}`,
+			wantURL: "GET http://example.com/users",
+		},
+		{
+			name: "Configured content type",
+			cfg: &Config{
+				Method:  "POST",
+				URL:     "http://example.com/users",
+				Request: map[string]any{"name": "${params.name}"},
+				Headers: map[string]string{"content-type": "application/vnd.api+json"},
+			},
+			param:           `{"name":"John"}`,
+			wantURL:         "POST http://example.com/users",
+			wantBody:        `{"name":"John"}`,
+			wantContentType: "application/vnd.api+json",
+		},
+		{
+			name: "Invalid query value",
+			cfg: &Config{
+				Method: "GET",
+				URL:    "http://example.com/users",
+				Query:  map[string]string{"name": "${params.missing}"},
+			},
+			param:   `{}`,
+			wantErr: "fail to execute query template name",
+		},
+		{
+			name: "Invalid form body",
+			cfg: &Config{
+				Method:     "POST",
+				URL:        "http://example.com/users",
+				Request:    map[string]any{"user": map[string]any{"name": "${params.name}"}},
+				BodyFormat: BodyFormatForm,
+			},
+			param:   `{"name":"John"}`,
+			wantErr: "fail to encode request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewHTTP(tt.cfg)
+			require.NoError(t, err)
+
+			req, err := p.Render(context.Background(), "1", []byte(tt.param), nil)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			httpReq, ok := req.(*request.HTTPReq)
+			require.True(t, ok)
+
+			assert.Equal(t, tt.wantURL, httpReq.RoutingKey())
+			assert.Equal(t, tt.wantBody, string(httpReq.Data()))
+			assert.Equal(t, tt.cfg.Auth, httpReq.Auth())
+			assert.Equal(t, tt.cfg.Signer, httpReq.Signer())
+
+			r, err := httpReq.ToHTTPRequest()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContentType, r.Header.Get("Content-Type"))
+		})
+	}
+}
+
 func TestHTTPProc_parse(t *testing.T) {
 	tests := []struct {
 		want    json.RawMessage
@@ -407,7 +767,7 @@ func TestHTTPProc_parse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &HTTPProc{}
-			got, err := p.parse(tt.data)
+			got, err := p.parse(encodeHTTPResp(t, http.StatusOK, tt.data))
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -419,3 +779,176 @@ func TestHTTPProc_parse(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPProc_parse_Formats(t *testing.T) {
+	tests := []struct {
+		headers map[string][]string
+		name    string
+		format  string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "XML detected by content type",
+			headers: map[string][]string{"Content-Type": {"application/xml; charset=utf-8"}},
+			body:    `<user id="1"><name>John</name></user>`,
+			want:    `{"@id":"1","name":"John"}`,
+		},
+		{
+			name:   "Configured CSV",
+			format: FormatCSV,
+			body:   "id,name\n1,John\n",
+			want:   `[{"id":"1","name":"John"}]`,
+		},
+		{
+			name:    "Configured format takes precedence over content type",
+			headers: map[string][]string{"Content-Type": {"application/json"}},
+			format:  FormatText,
+			body:    `{"key":"value"}`,
+			want:    `"{\"key\":\"value\"}"`,
+		},
+		{
+			name:    "Unknown content type falls back to JSON",
+			headers: map[string][]string{"Content-Type": {"application/octet-stream"}},
+			body:    `{"key":"value"}`,
+			want:    `{"key":"value"}`,
+		},
+		{
+			name:    "Invalid XML",
+			format:  FormatXML,
+			body:    `<user>`,
+			wantErr: "failed to parse xml body",
+		},
+		{
+			name:    "Error field of converted body",
+			format:  FormatForm,
+			body:    "error=failed",
+			wantErr: "failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := response.NewHTTPResp(http.StatusOK, tt.headers, []byte(tt.body)).Encode()
+			require.NoError(t, err)
+
+			p := &HTTPProc{format: tt.format}
+			got, err := p.parse(data)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestHTTPProc_parse_Status(t *testing.T) {
+	p, err := NewHTTP(&Config{
+		Method: "GET",
+		URL:    "http://localhost/",
+		Errors: []ErrorConfig{
+			{Status: "404", Code: "UserNotFound", Message: "User not found"},
+			{Status: "4xx", CodePath: "problem.type", MessagePath: "problem.detail"},
+			{CodePath: "errors.0.code", MessagePath: "errors.0.message"},
+			{Status: "2xx", CodePath: "result.error"},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		wantErr error
+		name    string
+		body    string
+		want    json.RawMessage
+		status  int
+	}{
+		{
+			name:   "Success",
+			status: http.StatusOK,
+			body:   `{"key":"value"}`,
+			want:   json.RawMessage(`{"key":"value"}`),
+		},
+		{
+			name:    "Exact status mapping",
+			status:  http.StatusNotFound,
+			body:    `<html>Not Found</html>`,
+			wantErr: core.NewAPIError("UserNotFound", "User not found", nil),
+		},
+		{
+			name:    "Status class mapping with JSON paths",
+			status:  http.StatusConflict,
+			body:    `{"problem":{"type":"Duplicate","detail":"Already exists"}}`,
+			wantErr: core.NewAPIError("Duplicate", "Already exists", nil),
+		},
+		{
+			name:    "Mapping for any error status",
+			status:  http.StatusBadGateway,
+			body:    `{"errors":[{"code":42,"message":"Invalid token"}]}`,
+			wantErr: core.NewAPIError("42", "Invalid token", nil),
+		},
+		{
+			name:   "Mapping without status doesn't apply to success",
+			status: http.StatusOK,
+			body:   `{"errors":[{"code":42,"message":"Invalid token"}]}`,
+			want:   json.RawMessage(`{"errors":[{"code":42,"message":"Invalid token"}]}`),
+		},
+		{
+			name:    "Mapping for successful status",
+			status:  http.StatusOK,
+			body:    `{"result":{"error":"Declined"}}`,
+			wantErr: core.NewAPIError("Declined", "Request failed", nil),
+		},
+		{
+			name:    "Error field takes precedence over default mapping",
+			status:  http.StatusInternalServerError,
+			body:    `{"error":{"code":"Internal","message":"Something went wrong"}}`,
+			wantErr: core.NewAPIError("Internal", "Something went wrong", nil),
+		},
+		{
+			name:    "Default mapping for unmapped client error",
+			status:  http.StatusBadRequest,
+			body:    `Bad input`,
+			wantErr: core.NewAPIError("BadRequest", "Bad Request", nil),
+		},
+		{
+			name:    "Default mapping for server error",
+			status:  http.StatusBadGateway,
+			body:    `{"error":"unexpected format"}`,
+			wantErr: core.NewAPIError("BackendError", "Bad Gateway", nil),
+		},
+		{
+			name:    "Default mapping for empty body",
+			status:  http.StatusServiceUnavailable,
+			wantErr: core.NewAPIError("ServiceUnavailable", "Service Unavailable", nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.parse(encodeHTTPResp(t, tt.status, []byte(tt.body)))
+
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHTTPProc_parse_InvalidEnvelope(t *testing.T) {
+	p := &HTTPProc{}
+
+	_, err := p.parse([]byte(`{"key":"value"}`))
+	assert.ErrorContains(t, err, "http response status code not found")
+
+	_, err = p.parse([]byte(`not json`))
+	assert.ErrorContains(t, err, "failed to decode http response")
+}
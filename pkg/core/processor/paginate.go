@@ -0,0 +1,243 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+)
+
+const (
+	PaginateCursor = "cursor"
+	PaginateOffset = "offset"
+	PaginateLink   = "link"
+
+	defaultMaxPages   = 10
+	defaultLimitParam = "limit"
+)
+
+type PaginateConfig struct {
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"`
+	Items      string `json:"items,omitempty" yaml:"items,omitempty"`
+	Cursor     string `json:"cursor,omitempty" yaml:"cursor,omitempty"`
+	Param      string `json:"param,omitempty" yaml:"param,omitempty"`
+	LimitParam string `json:"limit_param,omitempty" yaml:"limit_param,omitempty"`
+	PageSize   int    `json:"page_size,omitempty" yaml:"page_size,omitempty"`
+	MaxItems   int    `json:"max_items,omitempty" yaml:"max_items,omitempty"`
+	MaxPages   int    `json:"max_pages,omitempty" yaml:"max_pages,omitempty"`
+}
+
+// pagination follows pages of a list response and concatenates their items.
+type pagination struct {
+	typ        string
+	param      string
+	limitParam string
+	items      []string
+	cursor     []string
+	pageSize   int
+	maxItems   int
+	maxPages   int
+}
+
+// page is a decoded page of a list response.
+type page struct {
+	headers map[string][]string
+	body    json.RawMessage
+}
+
+// pageProc is implemented by processors that support pagination.
+type pageProc interface {
+	page(data []byte) (page, error)
+	pageRequest(req core.Request, next string) (core.Request, error)
+}
+
+// newPagination creates a new pagination based on the provided configuration.
+// It takes cfg of type *PaginateConfig and types, which lists pagination types supported by the backend, where the first one is the default.
+// It returns a pointer to pagination, which is nil if cfg is nil, and an error if the configuration is invalid.
+func newPagination(cfg *PaginateConfig, types ...string) (*pagination, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	pg := &pagination{
+		typ:        cfg.Type,
+		param:      cfg.Param,
+		limitParam: cfg.LimitParam,
+		items:      splitPath(cfg.Items),
+		cursor:     splitPath(cfg.Cursor),
+		pageSize:   cfg.PageSize,
+		maxItems:   cfg.MaxItems,
+		maxPages:   cfg.MaxPages,
+	}
+
+	if pg.typ == "" {
+		pg.typ = types[0]
+	}
+
+	if !slices.Contains(types, pg.typ) {
+		return nil, fmt.Errorf("pagination type %q is not supported", pg.typ)
+	}
+
+	if pg.maxItems <= 0 {
+		return nil, fmt.Errorf("max_items must be greater than 0")
+	}
+
+	if pg.pageSize < 0 || pg.maxPages < 0 {
+		return nil, fmt.Errorf("page_size and max_pages must not be negative")
+	}
+
+	switch pg.typ {
+	case PaginateCursor:
+		if len(pg.cursor) == 0 {
+			return nil, fmt.Errorf("cursor must be set for cursor pagination")
+		}
+
+		if pg.param == "" {
+			pg.param = PaginateCursor
+		}
+	case PaginateOffset:
+		if pg.pageSize == 0 {
+			return nil, fmt.Errorf("page_size must be set for offset pagination")
+		}
+
+		if pg.param == "" {
+			pg.param = PaginateOffset
+		}
+	}
+
+	if pg.limitParam == "" {
+		pg.limitParam = defaultLimitParam
+	}
+
+	if pg.maxPages == 0 {
+		pg.maxPages = defaultMaxPages
+	}
+
+	return pg, nil
+}
+
+// paginate fetches the pages following the first one and concatenates their items.
+// It takes data of type []byte which is the raw response of the first page, fetch of type core.PageFetcher
+// and proc of type pageProc which decodes pages and prepares their requests.
+// It returns the items of all pages as a JSON list and an error if any of the pages can't be fetched or doesn't contain a list.
+// Pages are fetched until the last page is reached, max_items items are collected or max_pages pages are fetched,
+// and the list is truncated to max_items items.
+func (pg *pagination) paginate(data []byte, fetch core.PageFetcher, proc pageProc) (json.RawMessage, error) {
+	cur, err := proc.page(data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]any, 0)
+
+	for pages := 1; ; pages++ {
+		doc, pageItems, err := pg.decode(cur.body)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, pageItems...)
+
+		if len(items) >= pg.maxItems {
+			items = items[:pg.maxItems]
+			break
+		}
+
+		if pages >= pg.maxPages || len(pageItems) == 0 {
+			break
+		}
+
+		next, ok := pg.next(cur, doc, len(pageItems), len(items))
+		if !ok {
+			break
+		}
+
+		data, err := fetch(func(req core.Request) (core.Request, error) {
+			return proc.pageRequest(req, next)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch page %d: %w", pages+1, err)
+		}
+
+		if cur, err = proc.page(data); err != nil {
+			return nil, fmt.Errorf("failed to parse page %d: %w", pages+1, err)
+		}
+	}
+
+	return json.Marshal(items)
+}
+
+// decode decodes the page body and extracts its items.
+// It takes body of type json.RawMessage.
+// It returns the decoded body, the items of the page and an error if the body doesn't contain a list at the items path.
+// Numbers are decoded as json.Number, so they are preserved as is.
+func (pg *pagination) decode(body json.RawMessage) (doc any, items []any, err error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal page: %w", err)
+	}
+
+	list, ok := lookupValue(doc, pg.items)
+	if ok {
+		items, ok = list.([]any)
+	}
+
+	if !ok {
+		return nil, nil, fmt.Errorf("list not found at %q", strings.Join(pg.items, "."))
+	}
+
+	return doc, items, nil
+}
+
+// next returns the reference to the page following the given one.
+// It takes cur of type page, doc of type any which is its decoded body, count of type int which is the number of its items,
+// and total of type int which is the number of items collected so far.
+// It returns the cursor, offset or URL of the next page and false if the given page is the last one.
+func (pg *pagination) next(cur page, doc any, count, total int) (string, bool) {
+	switch pg.typ {
+	case PaginateCursor:
+		return lookupString(doc, pg.cursor)
+	case PaginateOffset:
+		return strconv.Itoa(total), count >= pg.pageSize
+	case PaginateLink:
+		return nextLink(cur.headers)
+	default:
+		return "", false
+	}
+}
+
+// nextLink extracts the URL of the next page from the Link headers.
+// It takes headers of type map[string][]string.
+// It returns the URL of the link with the "next" relation type and false if there is no such link.
+func nextLink(headers map[string][]string) (string, bool) {
+	for _, value := range http.Header(headers).Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+
+			if len(target) < 2 || target[0] != '<' || target[len(target)-1] != '>' {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				name, rel, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+
+				if slices.Contains(strings.Fields(strings.ToLower(strings.Trim(rel, `"`))), "next") {
+					return target[1 : len(target)-1], true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,386 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pageResp struct {
+	headers map[string][]string
+	body    string
+}
+
+// newPageFetcher creates a fetcher that renders requests with render and answers them with the given HTTP responses.
+// It returns the fetcher and a pointer to the slice of sent requests.
+func newPageFetcher(t *testing.T, render func() core.Request, resps []pageResp) (core.PageFetcher, *[]core.Request) {
+	t.Helper()
+
+	sent := make([]core.Request, 0, len(resps))
+
+	return func(next func(core.Request) (core.Request, error)) ([]byte, error) {
+		req, err := next(render())
+		if err != nil {
+			return nil, err
+		}
+
+		require.Less(t, len(sent), len(resps), "unexpected page request")
+
+		resp := resps[len(sent)]
+		sent = append(sent, req)
+
+		if resp.headers == nil {
+			return []byte(resp.body), nil
+		}
+
+		return response.NewHTTPResp(http.StatusOK, resp.headers, []byte(resp.body)).Encode()
+	}, &sent
+}
+
+func TestNewPagination(t *testing.T) {
+	tests := []struct {
+		cfg     *PaginateConfig
+		want    *pagination
+		name    string
+		wantErr string
+	}{
+		{
+			name: "Not configured",
+		},
+		{
+			name: "Cursor defaults",
+			cfg:  &PaginateConfig{Type: PaginateCursor, Cursor: "meta.next", Items: "data", MaxItems: 100},
+			want: &pagination{
+				typ:        PaginateCursor,
+				param:      "cursor",
+				limitParam: "limit",
+				items:      []string{"data"},
+				cursor:     []string{"meta", "next"},
+				maxItems:   100,
+				maxPages:   10,
+			},
+		},
+		{
+			name: "Default type",
+			cfg:  &PaginateConfig{PageSize: 50, MaxItems: 100, MaxPages: 3, Param: "skip", LimitParam: "take"},
+			want: &pagination{
+				typ:        PaginateOffset,
+				param:      "skip",
+				limitParam: "take",
+				pageSize:   50,
+				maxItems:   100,
+				maxPages:   3,
+			},
+		},
+		{
+			name:    "Unsupported type",
+			cfg:     &PaginateConfig{Type: PaginateLink, MaxItems: 100},
+			wantErr: `pagination type "link" is not supported`,
+		},
+		{
+			name:    "Missing max items",
+			cfg:     &PaginateConfig{PageSize: 50},
+			wantErr: "max_items must be greater than 0",
+		},
+		{
+			name:    "Negative page size",
+			cfg:     &PaginateConfig{PageSize: -1, MaxItems: 100},
+			wantErr: "page_size and max_pages must not be negative",
+		},
+		{
+			name:    "Missing cursor",
+			cfg:     &PaginateConfig{Type: PaginateCursor, MaxItems: 100},
+			wantErr: "cursor must be set for cursor pagination",
+		},
+		{
+			name:    "Missing page size",
+			cfg:     &PaginateConfig{MaxItems: 100},
+			wantErr: "page_size must be set for offset pagination",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, err := newPagination(tt.cfg, PaginateOffset, PaginateCursor)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pg)
+		})
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		headers map[string][]string
+		name    string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "No link header",
+			headers: map[string][]string{},
+		},
+		{
+			name:    "Next link",
+			headers: map[string][]string{"Link": {`<http://localhost/?page=2>; rel="next", <http://localhost/?page=5>; rel="last"`}},
+			want:    "http://localhost/?page=2",
+			wantOK:  true,
+		},
+		{
+			name:    "Multiple relation types",
+			headers: map[string][]string{"Link": {`<http://localhost/?page=5>; rel="last"`, `</items?page=3>; title="x"; rel="prefetch next"`}},
+			want:    "/items?page=3",
+			wantOK:  true,
+		},
+		{
+			name:    "Last page",
+			headers: map[string][]string{"Link": {`<http://localhost/?page=1>; rel="first", <http://localhost/?page=4>; rel=prev`}},
+		},
+		{
+			name:    "Invalid link",
+			headers: map[string][]string{"Link": {`http://localhost/?page=2; rel="next"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nextLink(tt.headers)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHTTPProc_Paginate(t *testing.T) {
+	tests := []struct {
+		cfg      *PaginateConfig
+		name     string
+		first    pageResp
+		wantList string
+		wantErr  string
+		resps    []pageResp
+		wantURLs []string
+	}{
+		{
+			name:  "Cursor",
+			cfg:   &PaginateConfig{Type: PaginateCursor, Items: "data", Cursor: "meta.next", PageSize: 2, MaxItems: 10},
+			first: pageResp{body: `{"data":[1,2],"meta":{"next":"c2"}}`},
+			resps: []pageResp{
+				{body: `{"data":[3,4],"meta":{"next":"c3"}}`},
+				{body: `{"data":[5],"meta":{"next":null}}`},
+			},
+			wantList: `[1,2,3,4,5]`,
+			wantURLs: []string{"http://localhost/items?cursor=c2&limit=2&sort=asc", "http://localhost/items?cursor=c3&limit=2&sort=asc"},
+		},
+		{
+			name:     "Offset",
+			cfg:      &PaginateConfig{Type: PaginateOffset, PageSize: 2, MaxItems: 10},
+			first:    pageResp{body: `[{"id":1},{"id":2}]`},
+			resps:    []pageResp{{body: `[{"id":3}]`}},
+			wantList: `[{"id":1},{"id":2},{"id":3}]`,
+			wantURLs: []string{"http://localhost/items?limit=2&offset=2&sort=asc"},
+		},
+		{
+			name: "Link",
+			cfg:  &PaginateConfig{Type: PaginateLink, MaxItems: 10},
+			first: pageResp{
+				body:    `[1,2]`,
+				headers: map[string][]string{"Link": {`</items?page=2>; rel="next"`}},
+			},
+			resps: []pageResp{
+				{body: `[3,4]`, headers: map[string][]string{"Link": {`<http://localhost/items?page=3>; rel="next"`}}},
+				{body: `[]`, headers: map[string][]string{}},
+			},
+			wantList: `[1,2,3,4]`,
+			wantURLs: []string{"http://localhost/items?page=2", "http://localhost/items?page=3"},
+		},
+		{
+			name:     "Max items",
+			cfg:      &PaginateConfig{Type: PaginateOffset, PageSize: 2, MaxItems: 3},
+			first:    pageResp{body: `[1,2]`},
+			resps:    []pageResp{{body: `[3,4]`}},
+			wantList: `[1,2,3]`,
+			wantURLs: []string{"http://localhost/items?limit=2&offset=2&sort=asc"},
+		},
+		{
+			name:     "Max pages",
+			cfg:      &PaginateConfig{Type: PaginateOffset, PageSize: 1, MaxItems: 10, MaxPages: 2},
+			first:    pageResp{body: `[1]`},
+			resps:    []pageResp{{body: `[2]`}},
+			wantList: `[1,2]`,
+			wantURLs: []string{"http://localhost/items?limit=1&offset=1&sort=asc"},
+		},
+		{
+			name: "Link to another host",
+			cfg:  &PaginateConfig{Type: PaginateLink, MaxItems: 10},
+			first: pageResp{
+				body:    `[1]`,
+				headers: map[string][]string{"Link": {`<http://example.com/items?page=2>; rel="next"`}},
+			},
+			wantErr: "fail to paginate response test: failed to fetch page 2: next page link http://example.com/items?page=2 points to another host",
+		},
+		{
+			name:    "List not found",
+			cfg:     &PaginateConfig{Type: PaginateCursor, Items: "data", Cursor: "next", MaxItems: 10},
+			first:   pageResp{body: `{"items":[1]}`},
+			wantErr: `fail to paginate response test: list not found at "data"`,
+		},
+		{
+			name:    "Page error",
+			cfg:     &PaginateConfig{Type: PaginateCursor, Items: "data", Cursor: "next", MaxItems: 10},
+			first:   pageResp{body: `{"data":[1],"next":"c2"}`},
+			resps:   []pageResp{{body: `{"error":{"code":"RateLimit","message":"Too many requests"}}`}},
+			wantErr: "fail to paginate response test: failed to parse page 2: Too many requests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewHTTP(&Config{
+				Name:     "test",
+				Method:   http.MethodGet,
+				URL:      "http://localhost/items",
+				Query:    map[string]string{"sort": "asc"},
+				Allow:    []string{"list"},
+				FieldMap: map[string]string{"list": "items"},
+				Paginate: tt.cfg,
+			})
+			require.NoError(t, err)
+
+			render := func() core.Request {
+				req, err := p.Render(context.Background(), "2", nil, nil)
+				require.NoError(t, err)
+
+				return req
+			}
+
+			for i := range tt.resps {
+				if tt.resps[i].headers == nil {
+					tt.resps[i].headers = map[string][]string{}
+				}
+			}
+
+			fetch, sent := newPageFetcher(t, render, tt.resps)
+
+			first := tt.first.headers
+			if first == nil {
+				first = map[string][]string{}
+			}
+
+			data, err := response.NewHTTPResp(http.StatusOK, first, []byte(tt.first.body)).Encode()
+			require.NoError(t, err)
+
+			resp, err := p.Paginate(data, fetch)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.wantList, string(resp.Body()))
+			assert.JSONEq(t, tt.wantList, string(resp.Filtered()["items"]))
+
+			urls := make([]string, 0, len(*sent))
+			for _, req := range *sent {
+				urls = append(urls, req.(*request.HTTPReq).URL())
+			}
+
+			assert.Equal(t, tt.wantURLs, urls)
+		})
+	}
+}
+
+func TestHTTPProc_Render_Paginate(t *testing.T) {
+	p, err := NewHTTP(&Config{
+		Name:     "test",
+		Method:   http.MethodGet,
+		URL:      "http://localhost/items?sort=asc",
+		Paginate: &PaginateConfig{Type: PaginateCursor, Cursor: "next", LimitParam: "per_page", PageSize: 50, MaxItems: 100},
+	})
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "1", nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost/items?per_page=50&sort=asc", req.(*request.HTTPReq).URL())
+}
+
+func TestDerivProc_Paginate(t *testing.T) {
+	p, err := NewDeriv(&Config{
+		Name:     "statement",
+		Request:  map[string]any{"statement": 1, "description": 1},
+		Allow:    []string{"list"},
+		Paginate: &PaginateConfig{Items: "transactions", PageSize: 2, MaxItems: 10},
+	})
+	require.NoError(t, err)
+
+	reqID := 1
+
+	render := func() core.Request {
+		reqID++
+
+		req, err := p.Render(context.Background(), strconv.Itoa(reqID), nil, nil)
+		require.NoError(t, err)
+
+		return req
+	}
+
+	req, err := p.Render(context.Background(), "1", nil, nil)
+	require.NoError(t, err)
+
+	var first map[string]any
+
+	require.NoError(t, json.Unmarshal(req.Data(), &first))
+	assert.Equal(t, float64(2), first["limit"])
+	assert.NotContains(t, first, "offset")
+
+	fetch, sent := newPageFetcher(t, render, []pageResp{
+		{body: `{"msg_type":"statement","statement":{"count":2,"transactions":[{"transaction_id":12345678901234567890}]}}`},
+	})
+
+	resp, err := p.Paginate(
+		[]byte(`{"msg_type":"statement","statement":{"count":2,"transactions":[{"transaction_id":1},{"transaction_id":2}]}}`),
+		fetch,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, `[{"transaction_id":1},{"transaction_id":2},{"transaction_id":12345678901234567890}]`, string(resp.Body()))
+	assert.Equal(t, resp.Body(), resp.Filtered()["list"])
+
+	require.Len(t, *sent, 1)
+
+	var page map[string]any
+
+	require.NoError(t, json.Unmarshal((*sent)[0].Data(), &page))
+	assert.Equal(t, map[string]any{
+		"statement":   float64(1),
+		"description": float64(1),
+		"limit":       float64(2),
+		"offset":      float64(2),
+		"passthrough": map[string]any{"_bff": map[string]any{"req_id": "2"}},
+	}, page)
+}
+
+func TestNewDeriv_InvalidPaginate(t *testing.T) {
+	_, err := NewDeriv(&Config{
+		Request:  map[string]any{"statement": 1},
+		Paginate: &PaginateConfig{Type: PaginateLink, MaxItems: 10},
+	})
+
+	assert.EqualError(t, err, `invalid paginate config: pagination type "link" is not supported`)
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const respSchemaURL = "bff:///response.json"
+
+type ResponseSchemaConfig struct {
+	Schema        map[string]any `json:"schema" yaml:"schema"`
+	SamplePercent *float64       `json:"sample_percent,omitempty" yaml:"sample_percent,omitempty"`
+	Strict        bool           `json:"strict,omitempty" yaml:"strict,omitempty"`
+}
+
+// respSchema validates responses of a backend against the JSON schema of the response.
+type respSchema struct {
+	schema     *jsonschema.Schema
+	violations metric.Int64Counter
+	sample     func() bool
+	backend    string
+	strict     bool
+}
+
+// newRespSchema creates a validator of responses of the backend.
+// It takes cfg of type *ResponseSchemaConfig and backend, which is the name of the backend used in logs and metrics.
+// It returns a pointer to respSchema, which is nil if cfg is nil, and an error if the schema or the sample percentage is invalid.
+// If the sample percentage is not set, all responses are validated, while 0 disables validation.
+func newRespSchema(cfg *ResponseSchemaConfig, backend string) (*respSchema, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	percent := 100.0
+	if cfg.SamplePercent != nil {
+		percent = *cfg.SamplePercent
+	}
+
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("sample percent must be between 0 and 100, got %v", percent)
+	}
+
+	if cfg.Schema == nil {
+		return nil, fmt.Errorf("schema must be provided")
+	}
+
+	data, err := json.Marshal(cfg.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("schema %s can't be loaded", url)
+	}
+
+	if err := compiler.AddResource(respSchemaURL, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	schema, err := compiler.Compile(respSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	violations, err := otel.GetMeterProvider().Meter("processor").Int64Counter(
+		"response_schema_violations",
+		metric.WithDescription("Number of backend responses that don't match their schema"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize metric: %w", err)
+	}
+
+	return &respSchema{
+		schema:     schema,
+		violations: violations,
+		sample:     func() bool { return rand.Float64()*100 < percent }, //nolint:gosec // sampling doesn't need a secure source
+		backend:    backend,
+		strict:     cfg.Strict,
+	}, nil
+}
+
+// check validates the response body if it's sampled for validation.
+// It takes data of type []byte, which is the response body of the backend.
+// It returns an error if the response doesn't match the schema in strict mode.
+// Violations are logged and counted by the response_schema_violations metric in all modes.
+func (s *respSchema) check(data []byte) error {
+	if s == nil || !s.sample() {
+		return nil
+	}
+
+	var v any
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	err := dec.Decode(&v)
+	if err == nil {
+		err = s.schema.Validate(v)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	slog.Warn("Backend response doesn't match schema",
+		slog.String("backend", s.backend),
+		slog.Bool("strict", s.strict),
+		slog.Any("error", err),
+	)
+
+	s.violations.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("backend", s.backend),
+		attribute.Bool("strict", s.strict),
+	))
+
+	if s.strict {
+		return fmt.Errorf("response doesn't match schema: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var balanceSchema = map[string]any{
+	"type":     "object",
+	"required": []string{"balance", "currency"},
+	"properties": map[string]any{
+		"balance":  map[string]any{"type": "number"},
+		"currency": map[string]any{"type": "string"},
+	},
+}
+
+func TestNewRespSchema(t *testing.T) {
+	tests := []struct {
+		cfg     *ResponseSchemaConfig
+		name    string
+		wantErr string
+		wantNil bool
+	}{
+		{name: "not configured", cfg: nil, wantNil: true},
+		{name: "valid", cfg: &ResponseSchemaConfig{Schema: balanceSchema, SamplePercent: ptr(10.0), Strict: true}},
+		{name: "no schema", cfg: &ResponseSchemaConfig{}, wantErr: "schema must be provided"},
+		{name: "negative sample", cfg: &ResponseSchemaConfig{Schema: balanceSchema, SamplePercent: ptr(-1.0)}, wantErr: "sample percent must be between 0 and 100"},
+		{name: "sample over 100", cfg: &ResponseSchemaConfig{Schema: balanceSchema, SamplePercent: ptr(150.0)}, wantErr: "sample percent must be between 0 and 100"},
+		{name: "invalid schema", cfg: &ResponseSchemaConfig{Schema: map[string]any{"type": "text"}}, wantErr: "failed to compile schema"},
+		{name: "external reference", cfg: &ResponseSchemaConfig{Schema: map[string]any{"$ref": "/etc/schema.json"}}, wantErr: "can't be loaded"},
+		{name: "fail to marshal", cfg: &ResponseSchemaConfig{Schema: map[string]any{"type": make(chan int)}}, wantErr: "failed to marshal schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newRespSchema(tt.cfg, "balance")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.wantNil {
+				assert.Nil(t, s)
+				return
+			}
+
+			assert.NotNil(t, s)
+			assert.Equal(t, tt.cfg.Strict, s.strict)
+		})
+	}
+}
+
+func TestRespSchema_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+		strict  bool
+		skip    bool
+	}{
+		{name: "valid", data: `{"balance":10.5,"currency":"USD"}`, strict: true},
+		{name: "violation", data: `{"balance":"10.5"}`},
+		{name: "violation in strict mode", data: `{"balance":"10.5"}`, strict: true, wantErr: "response doesn't match schema"},
+		{name: "invalid JSON in strict mode", data: `{`, strict: true, wantErr: "response doesn't match schema"},
+		{name: "not sampled", data: `{"balance":"10.5"}`, strict: true, skip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newRespSchema(&ResponseSchemaConfig{Schema: balanceSchema, Strict: tt.strict}, "balance")
+			require.NoError(t, err)
+
+			s.sample = func() bool { return !tt.skip }
+
+			err = s.check([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	disabled, err := newRespSchema(&ResponseSchemaConfig{Schema: balanceSchema, SamplePercent: ptr(0.0), Strict: true}, "balance")
+	require.NoError(t, err)
+
+	for range 100 {
+		assert.NoError(t, disabled.check([]byte(`{"balance":"10.5"}`)))
+	}
+
+	all, err := newRespSchema(&ResponseSchemaConfig{Schema: balanceSchema, Strict: true}, "balance")
+	require.NoError(t, err)
+
+	for range 100 {
+		assert.Error(t, all.check([]byte(`{"balance":"10.5"}`)))
+	}
+
+	var nilSchema *respSchema
+
+	assert.NoError(t, nilSchema.check([]byte(`{`)))
+}
+
+func TestProcessors_Parse_ResponseSchema(t *testing.T) {
+	cfg := &ResponseSchemaConfig{Schema: balanceSchema, Strict: true}
+
+	deriv, err := NewDeriv(&Config{Name: "balance", Request: map[string]any{"balance": 1}, ResponseSchema: cfg})
+	require.NoError(t, err)
+
+	_, err = deriv.Parse([]byte(`{"msg_type":"balance","balance":{"balance":10,"currency":"USD"}}`))
+	assert.NoError(t, err)
+
+	_, err = deriv.Parse([]byte(`{"msg_type":"balance","balance":{"balance":10}}`))
+	assert.ErrorContains(t, err, "invalid response balance: response doesn't match schema")
+
+	httpProc, err := NewHTTP(&Config{Name: "balance", Method: "GET", URL: "http://localhost/balance", ResponseSchema: cfg})
+	require.NoError(t, err)
+
+	data, err := response.NewHTTPResp(http.StatusOK, nil, []byte(`{"balance":10,"currency":"USD"}`)).Encode()
+	require.NoError(t, err)
+
+	_, err = httpProc.Parse(data)
+	assert.NoError(t, err)
+
+	data, err = response.NewHTTPResp(http.StatusOK, nil, []byte(`{"balance":"10"}`)).Encode()
+	require.NoError(t, err)
+
+	_, err = httpProc.Parse(data)
+	assert.ErrorContains(t, err, "invalid response balance: response doesn't match schema")
+
+	_, err = NewHTTP(&Config{Method: "GET", URL: "http://localhost/balance", ResponseSchema: &ResponseSchemaConfig{}})
+	assert.ErrorContains(t, err, "invalid response schema")
+}
+
+func TestProcessors_Paginate_ResponseSchema(t *testing.T) {
+	cfg := &ResponseSchemaConfig{Schema: map[string]any{
+		"type":     "object",
+		"required": []string{"items"},
+		"properties": map[string]any{
+			"items": map[string]any{"type": "array", "items": map[string]any{"type": "number"}},
+		},
+	}, Strict: true}
+	paginate := &PaginateConfig{Type: PaginateOffset, Items: "items", PageSize: 2, MaxItems: 10}
+
+	deriv, err := NewDeriv(&Config{Name: "items", Request: map[string]any{"statement": 1}, Paginate: paginate, ResponseSchema: cfg})
+	require.NoError(t, err)
+
+	fetch := func(_ func(core.Request) (core.Request, error)) ([]byte, error) {
+		return []byte(`{"msg_type":"items","items":{"items":["3"]}}`), nil
+	}
+
+	_, err = deriv.Paginate([]byte(`{"msg_type":"items","items":{"items":[1,2]}}`), fetch)
+	assert.ErrorContains(t, err, "fail to paginate response items: failed to parse page 2: response doesn't match schema")
+
+	_, err = deriv.Paginate([]byte(`{"msg_type":"items","items":{"items":["1"]}}`), fetch)
+	assert.ErrorContains(t, err, "fail to paginate response items: response doesn't match schema")
+
+	httpProc, err := NewHTTP(&Config{Name: "items", Method: "GET", URL: "http://localhost/items", Paginate: paginate, ResponseSchema: cfg})
+	require.NoError(t, err)
+
+	fetch = func(_ func(core.Request) (core.Request, error)) ([]byte, error) {
+		return response.NewHTTPResp(http.StatusOK, map[string][]string{}, []byte(`{"items":["3"]}`)).Encode()
+	}
+
+	data, err := response.NewHTTPResp(http.StatusOK, map[string][]string{}, []byte(`{"items":[1,2]}`)).Encode()
+	require.NoError(t, err)
+
+	_, err = httpProc.Paginate(data, fetch)
+	assert.ErrorContains(t, err, "fail to paginate response items: failed to parse page 2: response doesn't match schema")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/tmpl"
+)
+
+const (
+	secretPrefix = "secret."
+	envPrefix    = "env."
+)
+
+// Secrets resolves `secret` and `env` references of request templates.
+type Secrets interface {
+	Secret(name string) (string, error)
+	Env(name string) (string, error)
+}
+
+type options struct {
+	secrets Secrets
+}
+
+type Option func(*options)
+
+// WithSecrets sets the provider of values of `secret` and `env` references of request templates.
+// It takes s of type Secrets and returns an Option.
+// If the option is not provided, rendering of templates with such references fails.
+func WithSecrets(s Secrets) Option {
+	return func(o *options) {
+		o.secrets = s
+	}
+}
+
+// secretRefs resolves values of `secret` and `env` references of templates of a processor at render time,
+// so the values are never stored in the configuration.
+type secretRefs struct {
+	provider Secrets
+	secrets  []string
+	env      []string
+}
+
+// newSecretRefs collects names of secrets and environment variables referenced by templates.
+// It takes refs, which are references of templates keyed by location, and provider of type Secrets.
+// It returns a pointer to secretRefs or nil if templates don't reference secrets or environment variables.
+func newSecretRefs(refs map[string][]tmpl.Ref, provider Secrets) *secretRefs {
+	s := &secretRefs{provider: provider}
+
+	for _, locRefs := range refs {
+		for _, ref := range locRefs {
+			if name, ok := refName(ref.Path, secretPrefix); ok && !slices.Contains(s.secrets, name) {
+				s.secrets = append(s.secrets, name)
+			}
+
+			if name, ok := refName(ref.Path, envPrefix); ok && !slices.Contains(s.env, name) {
+				s.env = append(s.env, name)
+			}
+		}
+	}
+
+	if len(s.secrets) == 0 && len(s.env) == 0 {
+		return nil
+	}
+
+	return s
+}
+
+// refName returns the name of the referenced secret or environment variable, which is the segment after the prefix.
+func refName(path, prefix string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok || rest == "" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(rest, ".")
+
+	return name, true
+}
+
+// resolve resolves values of referenced secrets and environment variables.
+// It returns maps of values keyed by name and an error if the provider is not configured or any value can't be resolved.
+func (s *secretRefs) resolve() (secrets, env map[string]string, err error) {
+	if s == nil {
+		return nil, nil, nil
+	}
+
+	if s.provider == nil {
+		return nil, nil, fmt.Errorf("secret provider is not configured")
+	}
+
+	secrets = make(map[string]string, len(s.secrets))
+
+	for _, name := range s.secrets {
+		if secrets[name], err = s.provider.Secret(name); err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve secret %s: %w", name, err)
+		}
+	}
+
+	env = make(map[string]string, len(s.env))
+
+	for _, name := range s.env {
+		if env[name], err = s.provider.Env(name); err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve environment variable %s: %w", name, err)
+		}
+	}
+
+	return secrets, env, nil
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSecrets map[string]string
+
+func (s testSecrets) Secret(name string) (string, error) {
+	if value, ok := s["secret."+name]; ok {
+		return value, nil
+	}
+
+	return "", fmt.Errorf("secret %s is not found", name)
+}
+
+func (s testSecrets) Env(name string) (string, error) {
+	if value, ok := s["env."+name]; ok {
+		return value, nil
+	}
+
+	return "", fmt.Errorf("environment variable %s is not set", name)
+}
+
+func TestHTTPProc_Render_Secrets(t *testing.T) {
+	cfg := &Config{
+		Method:  "GET",
+		URL:     "http://localhost/${env.REGION}/items",
+		Headers: map[string]string{"Authorization": "Bearer ${secret.API_KEY}"},
+		Query:   map[string]string{"key": "${secret.PARTNER_KEY | default 'none'}"},
+	}
+
+	p, err := NewHTTP(cfg, WithSecrets(testSecrets{
+		"secret.API_KEY":     "api-key",
+		"secret.PARTNER_KEY": "partner-key",
+		"env.REGION":         "eu",
+	}))
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "1", []byte(`{}`), nil)
+	require.NoError(t, err)
+
+	httpReq, ok := req.(*request.HTTPReq)
+	require.True(t, ok)
+	assert.Equal(t, "GET http://localhost/eu/items?key=partner-key", req.RoutingKey())
+	assert.Equal(t, []string{"Bearer api-key"}, httpReq.Headers()["Authorization"])
+
+	p, err = NewHTTP(cfg, WithSecrets(testSecrets{"secret.API_KEY": "api-key", "env.REGION": "eu"}))
+	require.NoError(t, err)
+
+	_, err = p.Render(context.Background(), "1", []byte(`{}`), nil)
+	assert.EqualError(t, err, "failed to resolve secret PARTNER_KEY: secret PARTNER_KEY is not found")
+
+	p, err = NewHTTP(cfg)
+	require.NoError(t, err)
+
+	_, err = p.Render(context.Background(), "1", []byte(`{}`), nil)
+	assert.EqualError(t, err, "secret provider is not configured")
+}
+
+func TestDerivProc_Render_Secrets(t *testing.T) {
+	p, err := NewDeriv(&Config{
+		Request: map[string]any{"authorize": "${secret.TOKEN}"},
+	}, WithSecrets(testSecrets{"secret.TOKEN": "a1-token"}))
+	require.NoError(t, err)
+
+	req, err := p.Render(context.Background(), "1", nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(req.Data()), `"authorize":"a1-token"`)
+}
+
+func TestNewSecretRefs(t *testing.T) {
+	p, err := NewHTTP(&Config{Method: "GET", URL: "http://localhost/${params.id}"}, WithSecrets(testSecrets{}))
+	require.NoError(t, err)
+	assert.Nil(t, p.secrets)
+}
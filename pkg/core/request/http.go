@@ -14,6 +14,8 @@ type HTTPReq struct {
 	url     string
 	method  string
 	id      string
+	auth    string
+	signer  string
 	body    []byte
 }
 
@@ -43,6 +45,39 @@ func (r *HTTPReq) AddHeader(key, value string) {
 	r.headers[key] = append(r.headers[key], value)
 }
 
+// SetAuth sets the name of the authentication provider that authorizes the request.
+// It takes name of type string.
+func (r *HTTPReq) SetAuth(name string) {
+	r.auth = name
+}
+
+// Auth returns the name of the authentication provider of the request, or an empty string if it's not set.
+func (r *HTTPReq) Auth() string {
+	return r.auth
+}
+
+// SetSigner sets the name of the signer that signs the request.
+// It takes name of type string.
+func (r *HTTPReq) SetSigner(name string) {
+	r.signer = name
+}
+
+// Signer returns the name of the signer of the request, or an empty string if it's not set.
+func (r *HTTPReq) Signer() string {
+	return r.signer
+}
+
+// URL returns the URL of the HTTP request.
+func (r *HTTPReq) URL() string {
+	return r.url
+}
+
+// SetURL sets the URL of the HTTP request.
+// It takes url of type string.
+func (r *HTTPReq) SetURL(url string) {
+	r.url = url
+}
+
 // ToHTTPRequest converts an HTTPReq struct to an *http.Request.
 // It takes no parameters and uses the fields of the HTTPReq struct.
 // It returns a pointer to an http.Request and an error.
@@ -92,3 +127,9 @@ func (r *HTTPReq) WithContext(ctx context.Context) wasabi.Request {
 	r.ctx = ctx
 	return r
 }
+
+// Headers returns the headers of the HTTP request.
+// It returns a map of header names to their values.
+func (r *HTTPReq) Headers() map[string][]string {
+	return r.headers
+}
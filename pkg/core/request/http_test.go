@@ -29,6 +29,35 @@ func TestAddHeader(t *testing.T) {
 	req.AddHeader("Content-Type", "application/json")
 
 	assert.Equal(t, []string{"application/json"}, req.headers["Content-Type"])
+	assert.Equal(t, map[string][]string{"Content-Type": {"application/json"}}, req.Headers())
+}
+
+func TestSetAuth(t *testing.T) {
+	req := NewHTTPReq(context.Background(), "GET", "http://example.com", nil, "testID")
+	assert.Empty(t, req.Auth())
+
+	req.SetAuth("billing")
+
+	assert.Equal(t, "billing", req.Auth())
+}
+
+func TestSetSigner(t *testing.T) {
+	req := NewHTTPReq(context.Background(), "GET", "http://example.com", nil, "testID")
+	assert.Empty(t, req.Signer())
+
+	req.SetSigner("partner")
+
+	assert.Equal(t, "partner", req.Signer())
+}
+
+func TestSetURL(t *testing.T) {
+	req := NewHTTPReq(context.Background(), "GET", "http://example.com", nil, "testID")
+	assert.Equal(t, "http://example.com", req.URL())
+
+	req.SetURL("http://example.com/?page=2")
+
+	assert.Equal(t, "http://example.com/?page=2", req.URL())
+	assert.Equal(t, "GET http://example.com/?page=2", req.RoutingKey())
 }
 
 func TestToHTTPRequest(t *testing.T) {
@@ -0,0 +1,50 @@
+package request
+
+import "context"
+
+type ResolvedReq struct {
+	ctx        context.Context
+	id         string
+	routingKey string
+	data       []byte
+	resp       []byte
+}
+
+// NewResolvedReq creates a new ResolvedReq instance for a request that already has its response, e.g. served from cache.
+// It takes ctx of type context.Context, reqID of type string, routingKey of type string, data of type []byte which is the request payload,
+// and resp of type []byte which is the response that resolves the request.
+// It returns a pointer to a ResolvedReq struct.
+func NewResolvedReq(ctx context.Context, reqID, routingKey string, data, resp []byte) *ResolvedReq {
+	return &ResolvedReq{
+		ctx:        ctx,
+		id:         reqID,
+		routingKey: routingKey,
+		data:       data,
+		resp:       resp,
+	}
+}
+
+// ID returns the ID of the request.
+func (r *ResolvedReq) ID() string {
+	return r.id
+}
+
+// Context returns the context associated with the request.
+func (r *ResolvedReq) Context() context.Context {
+	return r.ctx
+}
+
+// RoutingKey returns the routing key of the original request.
+func (r *ResolvedReq) RoutingKey() string {
+	return r.routingKey
+}
+
+// Data returns the payload of the original request.
+func (r *ResolvedReq) Data() []byte {
+	return r.data
+}
+
+// Response returns the response that resolves the request.
+func (r *ResolvedReq) Response() []byte {
+	return r.resp
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResolvedReq(t *testing.T) {
+	ctx := context.Background()
+
+	req := NewResolvedReq(ctx, "1", "GET http://localhost/", []byte("data"), []byte("resp"))
+
+	assert.Equal(t, "1", req.ID())
+	assert.Equal(t, ctx, req.Context())
+	assert.Equal(t, "GET http://localhost/", req.RoutingKey())
+	assert.Equal(t, []byte("data"), req.Data())
+	assert.Equal(t, []byte("resp"), req.Response())
+}
@@ -49,3 +49,36 @@ func createResponse(req *request.Request, respData map[string]any, err error) ([
 
 	return data, nil
 }
+
+// createPassThroughErrorResponse constructs an error response for a rejected passthrough request.
+// It takes data of type []byte which is the raw request and apiErr of type *APIError.
+// It returns a byte slice containing the marshaled response and an error if marshaling fails.
+// If the request is a JSON object, its req_id and passthrough fields are copied to the response,
+// and the request itself is echoed in the "echo_req" field as the Deriv API does.
+func createPassThroughErrorResponse(data []byte, apiErr *APIError) ([]byte, error) {
+	resp := map[string]any{
+		"error":    apiErr.Encode(),
+		"msg_type": "error",
+	}
+
+	var reqData map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &reqData); err == nil {
+		resp["echo_req"] = json.RawMessage(data)
+
+		if reqID, ok := reqData["req_id"]; ok {
+			resp["req_id"] = reqID
+		}
+
+		if passthrough, ok := reqData["passthrough"]; ok {
+			resp["passthrough"] = passthrough
+		}
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	return respData, nil
+}
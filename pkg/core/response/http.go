@@ -0,0 +1,73 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// HTTPResp is an envelope used to pass responses of HTTP backends from the HTTP provider to processors,
+// so the status code and headers are available along with the raw body.
+type HTTPResp struct {
+	headers    map[string][]string
+	body       []byte
+	statusCode int
+}
+
+type httpRespData struct {
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       []byte              `json:"body"`
+	StatusCode int                 `json:"status"`
+}
+
+// NewHTTPResp creates a new HTTPResp instance.
+// It takes statusCode of type int, headers of type map[string][]string and body of type []byte.
+// It returns a pointer to an HTTPResp struct.
+func NewHTTPResp(statusCode int, headers map[string][]string, body []byte) *HTTPResp {
+	return &HTTPResp{
+		statusCode: statusCode,
+		headers:    headers,
+		body:       body,
+	}
+}
+
+// DecodeHTTPResp decodes an HTTPResp from the data produced by Encode.
+// It takes data of type []byte.
+// It returns a pointer to an HTTPResp and an error if the data is not a valid HTTP response envelope.
+func DecodeHTTPResp(data []byte) (*HTTPResp, error) {
+	var d httpRespData
+
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to decode http response: %w", err)
+	}
+
+	if d.StatusCode == 0 {
+		return nil, fmt.Errorf("http response status code not found")
+	}
+
+	return NewHTTPResp(d.StatusCode, d.Headers, d.Body), nil
+}
+
+// Encode serializes the HTTPResp into a byte slice.
+// It returns the encoded data and an error if the serialization fails.
+func (r *HTTPResp) Encode() ([]byte, error) {
+	return json.Marshal(httpRespData{
+		StatusCode: r.statusCode,
+		Headers:    r.headers,
+		Body:       r.body,
+	})
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (r *HTTPResp) StatusCode() int {
+	return r.statusCode
+}
+
+// Headers returns the HTTP headers of the response.
+func (r *HTTPResp) Headers() map[string][]string {
+	return r.headers
+}
+
+// Body returns the raw body of the response.
+func (r *HTTPResp) Body() []byte {
+	return r.body
+}
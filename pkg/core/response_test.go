@@ -60,3 +60,15 @@ func TestCreateResponseWithPassthrough(t *testing.T) {
 	expected := []byte(`{"echo":{"req_id":1,"method":"testMethod","params":{"key":"value"},"passthrough":"test"},"msg_type":"testMethod","passthrough":"test","req_id":1,"testMethod":{"key":"value"}}`)
 	assert.Equal(t, expected, data)
 }
+
+func TestCreatePassThroughErrorResponse(t *testing.T) {
+	apiErr := NewAPIError("PermissionDenied", "Request is not allowed", nil)
+
+	data, err := createPassThroughErrorResponse([]byte(`{"buy":"1","req_id":1}`), apiErr)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"echo_req":{"buy":"1","req_id":1},"error":{"code":"PermissionDenied","message":"Request is not allowed"},"msg_type":"error","req_id":1}`, string(data))
+
+	data, err = createPassThroughErrorResponse([]byte(`not json`), apiErr)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"error":{"code":"PermissionDenied","message":"Request is not allowed"},"msg_type":"error"}`, string(data))
+}
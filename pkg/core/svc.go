@@ -3,9 +3,12 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
 	"github.com/ksysoev/wasabi"
+	"github.com/ksysoev/wasabi/channel"
 )
 
 type Request interface {
@@ -14,9 +17,22 @@ type Request interface {
 	Data() []byte
 }
 
+// ResolvedRequest is a request that already has its response, e.g. served from cache.
+// Such requests resolve their waiters instead of being sent to backends.
+type ResolvedRequest interface {
+	Request
+	ID() string
+	Response() []byte
+}
+
 type Sender func(Request) error
 type Waiter func() (reqID string, respChan <-chan []byte)
 
+// PageFetcher fetches the next page of a paginated response.
+// It takes next, which converts the request rendered for the page into the request of the page.
+// It returns the raw response of the page.
+type PageFetcher func(next func(Request) (Request, error)) ([]byte, error)
+
 type CallsRepo interface {
 	GetCall(method string) Handler
 	UpdateCalls(map[string]Handler)
@@ -26,47 +42,106 @@ type Handler interface {
 	Handle(ctx context.Context, params json.RawMessage, watcher Waiter, send Sender) (map[string]any, error)
 }
 
+// ConnWrapper wraps the client connection when it's registered, e.g. to rewrite messages delivered to the client.
+type ConnWrapper func(wasabi.Connection) wasabi.Connection
+
 type ConnRegistry interface {
-	GetConnection(wasabi.Connection) *Conn
+	GetConnection(clientConn wasabi.Connection, wrap ConnWrapper) *Conn
 }
 
 type APIProvider interface {
 	Handle(*Conn, Request) error
 }
 
+type PassThroughPolicy interface {
+	Request(data []byte) ([]byte, error)
+	Response(data []byte) []byte
+}
+
 type Service struct {
 	be       APIProvider
 	ch       CallsRepo
 	registry ConnRegistry
+	policy   PassThroughPolicy
+	wrap     ConnWrapper
+}
+
+type Option func(*Service)
+
+// WithPassThroughPolicy sets the policy that is applied to passthrough requests and their responses.
+// It takes a parameter policy of type PassThroughPolicy and returns an Option.
+func WithPassThroughPolicy(policy PassThroughPolicy) Option {
+	return func(s *Service) {
+		s.policy = policy
+	}
 }
 
 // NewService creates a new instance of Service.
 // It takes cfg of type *Config, wsBackend of type DerivAPI, and connRegistry of type ConnRegistry.
+// It also takes a variadic number of Option functions to configure the Service.
 // It returns a pointer to Service and an error.
 // It returns an error if the call handler creation fails.
-func NewService(callRepo CallsRepo, wsBackend APIProvider, connRegistry ConnRegistry) *Service {
-	return &Service{
+func NewService(callRepo CallsRepo, wsBackend APIProvider, connRegistry ConnRegistry, opts ...Option) *Service {
+	s := &Service{
 		be:       wsBackend,
 		ch:       callRepo,
 		registry: connRegistry,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.policy != nil {
+		s.wrap = s.wrapConnection
+	}
+
+	return s
 }
 
 // PassThrough forwards a request to the backend service using the provided client connection.
 // It takes clientConn of type wasabi.Connection and req of type *Request.
 // It returns an error if the backend service fails to handle the request.
+// If a passthrough policy is configured, the request is checked and rewritten before forwarding,
+// and rejected requests are answered with an error response without reaching the backend.
+// Text requests that the backend rejects with an APIError, for example when its upstream is unavailable, are answered with an error response as well.
 func (s *Service) PassThrough(clientConn wasabi.Connection, req *request.Request) error {
-	conn := s.registry.GetConnection(clientConn)
+	conn := s.getConnection(clientConn)
+	fwd := req
+
+	if s.policy != nil && req.RoutingKey() != request.BinaryMessage {
+		data, err := s.policy.Request(req.Data())
+
+		var apiErr *APIError
+
+		switch {
+		case errors.As(err, &apiErr):
+			return sendPassThroughError(clientConn, req.Data(), apiErr)
+		case err != nil:
+			return fmt.Errorf("failed to apply passthrough policy: %w", err)
+		}
 
-	return s.be.Handle(conn, req)
+		fwd = request.NewRequest(req.Context(), request.TextMessage, data)
+	}
+
+	err := s.be.Handle(conn, fwd)
+
+	var apiErr *APIError
+
+	if req.RoutingKey() == request.TextMessage && errors.As(err, &apiErr) {
+		return sendPassThroughError(clientConn, req.Data(), apiErr)
+	}
+
+	return err
 }
 
 // ProcessRequest handles an incoming request by delegating it to the appropriate handler based on the request method.
 // It takes a client connection of type wasabi.Connection and a request of type *Request.
 // It returns an error if the request method is unsupported, if the handler fails to process the request, or if the response cannot be marshaled to JSON.
 // If the handler returns an APIError, it encodes the error in the response.
+// Resolved requests produced by the handler are delivered to their waiters without reaching the backend.
 func (s *Service) ProcessRequest(clientConn wasabi.Connection, req *request.Request) error {
-	conn := s.registry.GetConnection(clientConn)
+	conn := s.getConnection(clientConn)
 
 	handler := s.ch.GetCall(req.Method)
 
@@ -82,10 +157,15 @@ func (s *Service) ProcessRequest(clientConn wasabi.Connection, req *request.Requ
 	}
 
 	resp, err := handler.Handle(
-		req.Context(),
+		ContextWithConn(req.Context(), conn),
 		req.Params,
 		conn.WaitResponse,
 		func(req Request) error {
+			if r, ok := req.(ResolvedRequest); ok {
+				conn.DoneRequest(r.ID(), r.Response())
+				return nil
+			}
+
 			return s.be.Handle(conn, req)
 		},
 	)
@@ -104,3 +184,39 @@ func (s *Service) ProcessRequest(clientConn wasabi.Connection, req *request.Requ
 func (s *Service) UpdateHandlers(handlers map[string]Handler) {
 	s.ch.UpdateCalls(handlers)
 }
+
+// getConnection retrieves the connection for the provided client connection from the registry.
+// It takes clientConn of type wasabi.Connection.
+// It returns a pointer to Conn.
+// If a passthrough policy is configured, messages delivered to the client through the connection are rewritten by the policy.
+func (s *Service) getConnection(clientConn wasabi.Connection) *Conn {
+	return s.registry.GetConnection(clientConn, s.wrap)
+}
+
+// wrapConnection wraps the client connection, so text messages delivered to the client are rewritten by the passthrough policy.
+// It takes clientConn of type wasabi.Connection.
+// It returns the wrapped wasabi.Connection.
+func (s *Service) wrapConnection(clientConn wasabi.Connection) wasabi.Connection {
+	return channel.NewConnectionWrapper(
+		clientConn,
+		channel.WithSendWrapper(func(conn wasabi.Connection, msgType wasabi.MessageType, msg []byte) error {
+			if msgType == wasabi.MsgTypeText {
+				msg = s.policy.Response(msg)
+			}
+
+			return conn.Send(msgType, msg)
+		}),
+	)
+}
+
+// sendPassThroughError sends the error response for the passthrough request to the client.
+// It takes clientConn of type wasabi.Connection, data of type []byte which is the original request and apiErr of type *APIError.
+// It returns an error if the response can't be created or sent.
+func sendPassThroughError(clientConn wasabi.Connection, data []byte, apiErr *APIError) error {
+	resp, err := createPassThroughErrorResponse(data, apiErr)
+	if err != nil {
+		return err
+	}
+
+	return clientConn.Send(wasabi.MsgTypeText, resp)
+}
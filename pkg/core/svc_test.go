@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
@@ -9,6 +11,7 @@ import (
 	"github.com/ksysoev/wasabi/mocks"
 	"github.com/stretchr/testify/assert"
 	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewService(t *testing.T) {
@@ -22,6 +25,7 @@ func TestNewService(t *testing.T) {
 	assert.Equal(t, mockCallsRepo, svc.ch)
 	assert.Equal(t, mockDerivAPI, svc.be)
 	assert.Equal(t, mockConnRegistry, svc.registry)
+	assert.Nil(t, svc.wrap)
 }
 
 func TestService_PassThrough(t *testing.T) {
@@ -36,7 +40,7 @@ func TestService_PassThrough(t *testing.T) {
 
 	conn := NewConnection(mockConn, func(_ string) {})
 
-	mockConnRegistry.EXPECT().GetConnection(mockConn).Return(conn)
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
 	mockDerivAPI.EXPECT().Handle(conn, mockRequest).Return(nil)
 
 	err := svc.PassThrough(mockConn, mockRequest)
@@ -44,6 +48,148 @@ func TestService_PassThrough(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestService_PassThrough_BackendAPIError(t *testing.T) {
+	mockCallsRepo := NewMockCallsRepo(t)
+	mockDerivAPI := NewMockAPIProvider(t)
+	mockConnRegistry := NewMockConnRegistry(t)
+
+	svc := NewService(mockCallsRepo, mockDerivAPI, mockConnRegistry)
+
+	mockConn := mocks.NewMockConnection(t)
+	req := request.NewRequest(context.Background(), request.TextMessage, []byte(`{"ping":1,"req_id":2}`))
+
+	conn := NewConnection(mockConn, func(_ string) {})
+
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
+	mockDerivAPI.EXPECT().Handle(conn, req).Return(fmt.Errorf("failed to connect to upstream: %w", NewAPIError("ServiceUnavailable", "unavailable", nil)))
+	mockConn.EXPECT().Send(wasabi.MsgTypeText, []byte(`{"echo_req":{"ping":1,"req_id":2},"error":{"code":"ServiceUnavailable","message":"unavailable"},"msg_type":"error","req_id":2}`)).Return(nil)
+
+	err := svc.PassThrough(mockConn, req)
+
+	assert.NoError(t, err)
+}
+
+func TestService_PassThrough_WithPolicy(t *testing.T) {
+	tests := []struct {
+		policyErr error
+		name      string
+		data      string
+		rewritten string
+		wantResp  string
+		wantErr   bool
+	}{
+		{
+			name:      "Allowed request",
+			data:      `{"ticks":"R_50"}`,
+			rewritten: `{"subscribe":1,"ticks":"R_50"}`,
+		},
+		{
+			name:      "Rejected request",
+			data:      `{"buy":"1","req_id":2,"passthrough":{"key":"value"}}`,
+			policyErr: NewAPIError("PermissionDenied", "Request is not allowed", nil),
+			wantResp:  `{"echo_req":{"buy":"1","req_id":2,"passthrough":{"key":"value"}},"error":{"code":"PermissionDenied","message":"Request is not allowed"},"msg_type":"error","passthrough":{"key":"value"},"req_id":2}`,
+		},
+		{
+			name:      "Policy failure",
+			data:      `{"ticks":"R_50"}`,
+			policyErr: assert.AnError,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCallsRepo := NewMockCallsRepo(t)
+			mockDerivAPI := NewMockAPIProvider(t)
+			mockConnRegistry := NewMockConnRegistry(t)
+			mockPolicy := NewMockPassThroughPolicy(t)
+
+			svc := NewService(mockCallsRepo, mockDerivAPI, mockConnRegistry, WithPassThroughPolicy(mockPolicy))
+
+			mockConn := mocks.NewMockConnection(t)
+			conn := NewConnection(mockConn, func(_ string) {})
+			req := request.NewRequest(context.Background(), request.TextMessage, []byte(tt.data))
+
+			mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
+
+			var rewritten []byte
+			if tt.rewritten != "" {
+				rewritten = []byte(tt.rewritten)
+			}
+
+			mockPolicy.EXPECT().Request([]byte(tt.data)).Return(rewritten, tt.policyErr)
+
+			if tt.rewritten != "" {
+				mockDerivAPI.EXPECT().Handle(conn, mock.Anything).
+					Run(func(_ *Conn, r Request) {
+						assert.Equal(t, tt.rewritten, string(r.Data()))
+					}).
+					Return(nil)
+			}
+
+			if tt.wantResp != "" {
+				mockConn.EXPECT().Send(wasabi.MsgTypeText, []byte(tt.wantResp)).Return(nil)
+			}
+
+			err := svc.PassThrough(mockConn, req)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, assert.AnError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_PassThrough_BinaryWithPolicy(t *testing.T) {
+	mockCallsRepo := NewMockCallsRepo(t)
+	mockDerivAPI := NewMockAPIProvider(t)
+	mockConnRegistry := NewMockConnRegistry(t)
+	mockPolicy := NewMockPassThroughPolicy(t)
+
+	svc := NewService(mockCallsRepo, mockDerivAPI, mockConnRegistry, WithPassThroughPolicy(mockPolicy))
+
+	mockConn := mocks.NewMockConnection(t)
+	conn := NewConnection(mockConn, func(_ string) {})
+	req := request.NewRequest(context.Background(), request.BinaryMessage, []byte("binary"))
+
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
+	mockDerivAPI.EXPECT().Handle(conn, req).Return(nil)
+
+	err := svc.PassThrough(mockConn, req)
+
+	assert.NoError(t, err)
+}
+
+func TestService_getConnection_WithPolicy(t *testing.T) {
+	mockConnRegistry := NewMockConnRegistry(t)
+	mockPolicy := NewMockPassThroughPolicy(t)
+
+	svc := NewService(nil, nil, mockConnRegistry, WithPassThroughPolicy(mockPolicy))
+
+	mockConn := mocks.NewMockConnection(t)
+
+	var wrap ConnWrapper
+
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).
+		Run(func(_ wasabi.Connection, w ConnWrapper) { wrap = w }).
+		Return(nil)
+
+	svc.getConnection(mockConn)
+
+	require.NotNil(t, wrap)
+
+	wrapped := wrap(mockConn)
+
+	mockPolicy.EXPECT().Response([]byte(`{"msg_type":"ticks"}`)).Return([]byte(`{"msg_type":"ticks","rewritten":1}`))
+	mockConn.EXPECT().Send(wasabi.MsgTypeText, []byte(`{"msg_type":"ticks","rewritten":1}`)).Return(nil)
+	mockConn.EXPECT().Send(wasabi.MsgTypeBinary, []byte("binary")).Return(nil)
+
+	assert.NoError(t, wrapped.Send(wasabi.MsgTypeText, []byte(`{"msg_type":"ticks"}`)))
+	assert.NoError(t, wrapped.Send(wasabi.MsgTypeBinary, []byte("binary")))
+}
+
 func TestService_ProcessRequest(t *testing.T) {
 	mockCallsRepo := NewMockCallsRepo(t)
 	mockDerivAPI := NewMockAPIProvider(t)
@@ -59,7 +205,7 @@ func TestService_ProcessRequest(t *testing.T) {
 
 	conn := NewConnection(mockConn, func(_ string) {})
 
-	mockConnRegistry.EXPECT().GetConnection(mockConn).Return(conn)
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
 
 	mockHandler := NewMockHandler(t)
 	mockCallsRepo.EXPECT().GetCall("testMethod").Return(mockHandler)
@@ -78,6 +224,45 @@ func TestService_ProcessRequest(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestService_ProcessRequest_ResolvedRequest(t *testing.T) {
+	mockCallsRepo := NewMockCallsRepo(t)
+	mockDerivAPI := NewMockAPIProvider(t)
+	mockConnRegistry := NewMockConnRegistry(t)
+
+	svc := NewService(mockCallsRepo, mockDerivAPI, mockConnRegistry)
+
+	ctx := context.Background()
+	mockConn := mocks.NewMockConnection(t)
+	mockRequest := request.NewRequest(ctx, request.TextMessage, []byte(`{"req_id":1,"method":"testMethod"}`))
+
+	conn := NewConnection(mockConn, func(_ string) {})
+
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
+
+	mockHandler := NewMockHandler(t)
+	mockCallsRepo.EXPECT().GetCall("testMethod").Return(mockHandler)
+	mockHandler.EXPECT().Handle(mock.Anything, mockRequest.Params, mock.Anything, mock.Anything).RunAndReturn(
+		func(ctx context.Context, _ json.RawMessage, waiter Waiter, send Sender) (map[string]any, error) {
+			assert.Same(t, conn, ConnFromContext(ctx))
+
+			reqID, respChan := waiter()
+
+			if err := send(request.NewResolvedReq(ctx, reqID, "", nil, []byte(`{"result":"cached"}`))); err != nil {
+				return nil, err
+			}
+
+			return map[string]any{"result": string(<-respChan)}, nil
+		},
+	)
+
+	mockConn.EXPECT().
+		Send(wasabi.MsgTypeText, []byte(`{"echo":{"req_id":1,"method":"testMethod"},"msg_type":"testMethod","req_id":1,"testMethod":{"result":"{\"result\":\"cached\"}"}}`)).
+		Return(nil)
+
+	err := svc.ProcessRequest(mockConn, mockRequest)
+	assert.NoError(t, err)
+}
+
 func TestService_ProcessRequest_UnsupportedMethod(t *testing.T) {
 	mockCallsRepo := NewMockCallsRepo(t)
 	mockDerivAPI := NewMockAPIProvider(t)
@@ -92,7 +277,7 @@ func TestService_ProcessRequest_UnsupportedMethod(t *testing.T) {
 
 	conn := NewConnection(mockConn, func(_ string) {})
 
-	mockConnRegistry.EXPECT().GetConnection(mockConn).Return(conn)
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
 	mockCallsRepo.EXPECT().GetCall("unsupportedMethod").Return(nil)
 	mockConn.EXPECT().Send(wasabi.MsgTypeText, []byte(`{"echo":null,"error":{"code":"UnrecognisedRequest","message":"Unrecognised request method"},"msg_type":"error"}`)).Return(nil)
 
@@ -108,14 +293,11 @@ func TestService_ProcessRequest_HandlerError(t *testing.T) {
 	svc := NewService(mockCallsRepo, mockDerivAPI, mockConnRegistry)
 
 	mockConn := mocks.NewMockConnection(t)
-	mockRequest := &request.Request{
-		Method: "testMethod",
-		Params: []byte(`{"key": "value"}`),
-	}
+	mockRequest := request.NewRequest(context.Background(), request.TextMessage, []byte(`{"method":"testMethod","params":{"key":"value"}}`))
 
 	conn := NewConnection(mockConn, func(_ string) {})
 
-	mockConnRegistry.EXPECT().GetConnection(mockConn).Return(conn)
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
 
 	mockHandler := NewMockHandler(t)
 	mockCallsRepo.EXPECT().GetCall("testMethod").Return(mockHandler)
@@ -145,7 +327,7 @@ func TestService_ProcessRequest_APIError(t *testing.T) {
 
 	conn := NewConnection(mockConn, func(_ string) {})
 
-	mockConnRegistry.EXPECT().GetConnection(mockConn).Return(conn)
+	mockConnRegistry.EXPECT().GetConnection(mockConn, mock.Anything).Return(conn)
 
 	mockHandler := NewMockHandler(t)
 	mockCallsRepo.EXPECT().GetCall("testMethod").Return(mockHandler)
@@ -0,0 +1,86 @@
+package tmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/wolfeidau/jsontemplate"
+)
+
+// document is the template data of a single execution of a template.
+// Values are read by jsontemplate, and missing paths are detected by looking them up in the decoded data,
+// so functions that accept missing values don't depend on errors of the library.
+type document struct {
+	*jsontemplate.Document
+	tree      any
+	decodeErr error
+	data      []byte
+	decoded   bool
+}
+
+// newDocument creates a document from the JSON encoded template data.
+// It takes data of type []byte.
+// It returns a pointer to document.
+func newDocument(data []byte) *document {
+	return &document{
+		Document: jsontemplate.NewDocument(data),
+		data:     data,
+	}
+}
+
+// missing reports whether the path doesn't exist in the template data.
+// It takes path of type string, which is the dot-separated path, optionally followed by tags, e.g. "params.name;escape".
+// It returns true if any segment of the path is not found, including segments below values that are not objects or lists,
+// and false if the data is not valid JSON.
+// As in jsontemplate, numeric segments are list indexes, and strings are decoded as JSON if the path continues below them.
+func (d *document) missing(path string) bool {
+	if !d.decoded {
+		d.decoded = true
+
+		dec := json.NewDecoder(bytes.NewReader(d.data))
+		dec.UseNumber()
+
+		d.decodeErr = dec.Decode(&d.tree)
+	}
+
+	if d.decodeErr != nil {
+		return false
+	}
+
+	path, _, _ = strings.Cut(path, ";")
+	v := d.tree
+
+	for _, segment := range strings.Split(path, ".") {
+		if s, ok := v.(string); ok {
+			var nested any
+			if err := json.Unmarshal([]byte(s), &nested); err != nil {
+				return true
+			}
+
+			v = nested
+		}
+
+		switch node := v.(type) {
+		case map[string]any:
+			child, ok := node[segment]
+			if !ok {
+				return true
+			}
+
+			v = child
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return true
+			}
+
+			v = node[i]
+		default:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_Missing(t *testing.T) {
+	doc := newDocument([]byte(`{"params":{"name":"John","age":0,"tags":["a","b"],"nested":"{\"id\":1}","empty":null}}`))
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "params", want: false},
+		{path: "params.name", want: false},
+		{path: "params.name;escape", want: false},
+		{path: "params.age", want: false},
+		{path: "params.empty", want: false},
+		{path: "params.tags.1", want: false},
+		{path: "params.nested.id", want: false},
+		{path: "params.missing", want: true},
+		{path: "params.missing.deeper", want: true},
+		{path: "params.tags.2", want: true},
+		{path: "params.tags.first", want: true},
+		{path: "params.age.value", want: true},
+		{path: "params.name.first", want: true},
+		{path: "resp", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, doc.missing(tt.path))
+		})
+	}
+}
+
+func TestDocument_Missing_InvalidData(t *testing.T) {
+	assert.False(t, newDocument([]byte(`{`)).missing("params.name"))
+}
+
+func TestTemplates_DefaultForMissingValues(t *testing.T) {
+	params := map[string]any{
+		"params": map[string]any{"tags": []any{"a"}, "count": 5},
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: `${params.missing | default "n/a"}`, want: "n/a"},
+		{src: `${params.missing.deeper | default "n/a"}`, want: "n/a"},
+		{src: `${params.tags.3 | default "n/a"}`, want: "n/a"},
+		{src: `${params.count.value | default "n/a"}`, want: "n/a"},
+		{src: `${params.tags.0 | default "n/a"}`, want: "a"},
+		{src: `${params.count | default 1}`, want: "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := MustNewStrTmpl(tt.src).Execute(params)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
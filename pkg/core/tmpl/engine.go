@@ -0,0 +1,88 @@
+package tmpl
+
+import (
+	"fmt"
+)
+
+// Names of supported template engines.
+const (
+	EngineDefault = "default"
+	EngineGo      = "go"
+)
+
+// Engine compiles templates of backend requests.
+type Engine interface {
+	// JSON compiles the template of a JSON request body.
+	JSON(src string) (JSONTemplate, error)
+	// String compiles the template of a header or a query parameter.
+	String(src string) (StringTemplate, error)
+	// URL compiles the template of a URL, where values of placeholders are URL-encoded.
+	URL(src string) (StringTemplate, error)
+	// Placeholder returns the placeholder of the dot-separated path of the template data, e.g. "req_id".
+	Placeholder(path string) string
+}
+
+// JSONTemplate renders JSON documents from the template data.
+type JSONTemplate interface {
+	Execute(params any) ([]byte, error)
+	Refs() []Ref
+}
+
+// StringTemplate renders strings from the template data.
+type StringTemplate interface {
+	Execute(params any) (string, error)
+	Refs() []Ref
+}
+
+// NewEngine returns the template engine with the given name.
+// It takes name of type string, which is "default" for `${...}` placeholders or "go" for Go templates.
+// It returns the Engine and an error if the engine is not supported.
+// If the name is empty, the default engine is returned.
+func NewEngine(name string) (Engine, error) {
+	switch name {
+	case "", EngineDefault:
+		return defaultEngine{}, nil
+	case EngineGo:
+		return goEngine{}, nil
+	default:
+		return nil, fmt.Errorf("template engine %q is not supported", name)
+	}
+}
+
+// defaultEngine compiles templates with `${...}` placeholders.
+type defaultEngine struct{}
+
+// JSON compiles the template with New.
+func (defaultEngine) JSON(src string) (JSONTemplate, error) {
+	t, err := New(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// String compiles the template with NewStrTmpl.
+func (defaultEngine) String(src string) (StringTemplate, error) {
+	t, err := NewStrTmpl(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// URL compiles the template with NewURLTmpl.
+func (defaultEngine) URL(src string) (StringTemplate, error) {
+	t, err := NewURLTmpl(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Placeholder returns the placeholder of the path, e.g. ${req_id}.
+func (defaultEngine) Placeholder(path string) string {
+	return "${" + path + "}"
+}
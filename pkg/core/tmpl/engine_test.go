@@ -0,0 +1,221 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEngine(t *testing.T) {
+	tests := []struct {
+		want    Engine
+		name    string
+		engine  string
+		wantErr bool
+	}{
+		{name: "empty", engine: "", want: defaultEngine{}},
+		{name: "default", engine: EngineDefault, want: defaultEngine{}},
+		{name: "go", engine: EngineGo, want: goEngine{}},
+		{name: "unknown", engine: "jinja", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := NewEngine(tt.engine)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, `template engine "jinja" is not supported`)
+				assert.Nil(t, engine)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, engine)
+		})
+	}
+}
+
+func TestDefaultEngine(t *testing.T) {
+	engine, err := NewEngine(EngineDefault)
+	require.NoError(t, err)
+
+	data := map[string]any{"params": map[string]any{"name": "a b", "ids": []any{1, 2}}}
+
+	jsonTmpl, err := engine.JSON(`{"ids":"${params.ids}"}`)
+	require.NoError(t, err)
+
+	body, err := jsonTmpl.Execute(data)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ids":[1,2]}`, string(body))
+
+	strTmpl, err := engine.String("${params.name}")
+	require.NoError(t, err)
+
+	str, err := strTmpl.Execute(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "a b", str)
+
+	urlTmpl, err := engine.URL("http://localhost/?q=${params.name}")
+	require.NoError(t, err)
+
+	str, err = urlTmpl.Execute(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/?q=a+b", str)
+
+	_, err = engine.JSON(`{"key": "${value"`)
+	assert.Error(t, err)
+}
+
+func TestGoEngine_JSON(t *testing.T) {
+	data := map[string]any{
+		"params": map[string]any{
+			"name":   "John",
+			"age":    42,
+			"price":  1.5,
+			"active": true,
+			"ids":    []any{1, 2},
+			"filter": map[string]any{"type": "a"},
+		},
+		"req_id": 7,
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "string", src: `{"name":"{{ .params.name }}"}`, want: `{"name":"John"}`},
+		{name: "number", src: `{"age":"{{ .params.age }}","price":"{{ .params.price }}"}`, want: `{"age":42,"price":1.5}`},
+		{name: "boolean", src: `{"active":"{{ .params.active }}"}`, want: `{"active":true}`},
+		{name: "array", src: `{"ids":"{{ .params.ids }}"}`, want: `{"ids":[1,2]}`},
+		{name: "object", src: `{"filter":"{{ .params.filter }}"}`, want: `{"filter":{"type":"a"}}`},
+		{name: "missing", src: `{"missing":"{{ .params.missing }}"}`, want: `{"missing":null}`},
+		{name: "mixed string", src: `{"greeting":"Hello, {{ .params.name }}!"}`, want: `{"greeting":"Hello, John!"}`},
+		{name: "nested", src: `{"req":{"id":"{{ .req_id }}","list":["{{ .params.name }}",1]}}`, want: `{"req":{"id":7,"list":["John",1]}}`},
+		{name: "functions", src: `{"name":"{{ .params.name | upper }}","age":"{{ .params.age | add 1 }}"}`, want: `{"name":"JOHN","age":43}`},
+		{name: "default", src: `{"name":"{{ .params.missing | default \"n/a\" }}"}`, want: `{"name":"n/a"}`},
+		{name: "conditional", src: `{"kind":"{{ if .params.active }}active{{ else }}inactive{{ end }}"}`, want: `{"kind":"active"}`},
+		{name: "range", src: `{"ids":"{{ range $i, $id := .params.ids }}{{ if $i }},{{ end }}{{ $id }}{{ end }}"}`, want: `{"ids":"1,2"}`},
+		{name: "builtins", src: `{"adult":"{{ and .params.active (ge (len .params.ids) 2) }}","count":"{{ len .params.ids }}","id":"{{ index .params.ids 1 }}"}`, want: `{"adult":true,"count":2,"id":2}`},
+		{name: "builtin eq", src: `{"kind":"{{ if eq .params.filter.type \"b\" \"a\" }}known{{ end }}"}`, want: `{"kind":"known"}`},
+		{name: "static", src: `{"static":1}`, want: `{"static":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := goEngine{}.JSON(tt.src)
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(data)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestGoEngine_String(t *testing.T) {
+	data := map[string]any{
+		"params": map[string]any{
+			"name":  "John Doe",
+			"age":   42,
+			"ids":   []any{1, 2},
+			"query": "a&b",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		url     bool
+		want    string
+		wantErr string
+	}{
+		{name: "string", src: "Hello, {{ .params.name }}", want: "Hello, John Doe"},
+		{name: "number", src: "{{ .params.age }}", want: "42"},
+		{name: "missing", src: "{{ .params.missing }}", want: ""},
+		{name: "functions", src: "{{ .params.name | lower | replace \" \" \"-\" }}", want: "john-doe"},
+		{name: "array", src: "{{ .params.ids }}", wantErr: "expected string, number, boolean or null, got array"},
+		{name: "url", src: "http://localhost/users/{{ .params.age }}?q={{ .params.query }}", url: true, want: "http://localhost/users/42?q=a%26b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tmpl StringTemplate
+				err  error
+			)
+
+			if tt.url {
+				tmpl, err = goEngine{}.URL(tt.src)
+			} else {
+				tmpl, err = goEngine{}.String(tt.src)
+			}
+
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(data)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewGoFuncs_Builtins(t *testing.T) {
+	for _, name := range goBuiltins {
+		assert.NotContains(t, goFuncs, name)
+	}
+
+	assert.Contains(t, goFuncs, "upper")
+}
+
+func TestGoEngine_Invalid(t *testing.T) {
+	_, err := goEngine{}.JSON(`{"key":"{{ .params.name }"}`)
+	assert.Error(t, err)
+
+	_, err = goEngine{}.JSON(`{"key":`)
+	assert.Error(t, err)
+
+	_, err = goEngine{}.String("{{ .params.name | unknown }}")
+	assert.Error(t, err)
+
+	_, err = goEngine{}.URL("{{ if .params.name }}")
+	assert.Error(t, err)
+
+	tmpl, err := goEngine{}.String("{{ .params.name }}")
+	require.NoError(t, err)
+
+	_, err = tmpl.Execute(map[string]any{"params": make(chan int)})
+	assert.Error(t, err)
+}
+
+func TestGoEngine_Refs(t *testing.T) {
+	jsonTmpl, err := goEngine{}.JSON(`{
+		"name": "{{ .params.name }}",
+		"greeting": "Hi, {{ .params.name | upper }}",
+		"ids": "{{ range .params.ids }}{{ .id }}{{ $.req_id }}{{ end }}",
+		"id": "{{ .resp.user.id }}"
+	}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Ref{
+		{Path: "params.ids", Raw: false},
+		{Path: "params.name", Raw: false},
+		{Path: "params.name", Raw: true},
+		{Path: "req_id", Raw: true},
+		{Path: "resp.user.id", Raw: true},
+	}, jsonTmpl.Refs())
+
+	strTmpl, err := goEngine{}.String("{{ .params.name }}-{{ .params.age | add 1 }}")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Ref{
+		{Path: "params.age", Raw: false},
+		{Path: "params.name", Raw: true},
+	}, strTmpl.Refs())
+}
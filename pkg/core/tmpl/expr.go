@@ -0,0 +1,423 @@
+package tmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasttemplate"
+	"github.com/wolfeidau/jsontemplate"
+)
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokPipe
+)
+
+// Ref is a reference of a template placeholder to a path of the template data.
+type Ref struct {
+	// Path is the dot-separated path, e.g. "params.name".
+	Path string
+	// Raw is true if the value of the path is rendered as is, without functions applied.
+	Raw bool
+}
+
+type token struct {
+	text string
+	kind tokenKind
+}
+
+// operand is a literal value or a path in the template data.
+type operand struct {
+	value any
+	path  string
+}
+
+// call is a call of a template function with its arguments, where the result of the previous stage is passed as input.
+type call struct {
+	fn   *function
+	name string
+	args []operand
+}
+
+// expression is a compiled placeholder: an operand followed by a pipeline of function calls, e.g. `params.name | default 'n/a' | upper`.
+type expression struct {
+	head  operand
+	calls []call
+}
+
+// parseExpr compiles the placeholder expression.
+// It takes src of type string, which is the content of the placeholder without delimiters.
+// It returns a pointer to expression and an error if the expression is invalid, refers to an unknown function,
+// or passes a wrong number or wrong types of literal arguments to a function.
+func parseExpr(src string) (*expression, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	head, err := parseOperand(tokens[0])
+	if err != nil {
+		return nil, err
+	}
+
+	e := &expression{head: head}
+	rest := tokens[1:]
+
+	for len(rest) > 0 {
+		if rest[0].kind != tokPipe {
+			return nil, fmt.Errorf("unexpected %q, expected |", rest[0].text)
+		}
+
+		rest = rest[1:]
+
+		if len(rest) == 0 || rest[0].kind != tokWord {
+			return nil, fmt.Errorf("function name expected after |")
+		}
+
+		name := rest[0].text
+
+		fn, ok := funcs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", name)
+		}
+
+		rest = rest[1:]
+		args := make([]operand, 0)
+
+		for len(rest) > 0 && rest[0].kind != tokPipe {
+			arg, err := parseOperand(rest[0])
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, arg)
+			rest = rest[1:]
+		}
+
+		if err := fn.check(name, args); err != nil {
+			return nil, err
+		}
+
+		e.calls = append(e.calls, call{fn: fn, name: name, args: args})
+	}
+
+	return e, nil
+}
+
+// tokenize splits the expression into words, quoted strings and pipes.
+// It takes src of type string.
+// It returns a slice of tokens and an error if a quoted string is not terminated.
+// Strings can be quoted with single or double quotes, and a backslash escapes the next character.
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+
+	for i := 0; i < len(src); {
+		switch c := src[i]; c {
+		case ' ', '\t', '\n', '\r':
+			i++
+		case '|':
+			tokens = append(tokens, token{kind: tokPipe, text: "|"})
+			i++
+		case '\'', '"':
+			var str strings.Builder
+
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+
+				str.WriteByte(src[j])
+			}
+
+			if j == len(src) {
+				return nil, fmt.Errorf("unterminated string %s", src[i:])
+			}
+
+			tokens = append(tokens, token{kind: tokString, text: str.String()})
+			i = j + 1
+		default:
+			j := i
+			for j < len(src) && !strings.ContainsRune(" \t\n\r|'\"", rune(src[j])) {
+				j++
+			}
+
+			tokens = append(tokens, token{kind: tokWord, text: src[i:j]})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+// parseOperand converts the token into an operand.
+// It takes tok of type token.
+// It returns the operand and an error if the token is a pipe, an invalid number or an invalid path.
+// Words are parsed as true, false, null, numbers or dot-separated paths.
+func parseOperand(tok token) (operand, error) {
+	switch tok.kind {
+	case tokString:
+		return operand{value: tok.text}, nil
+	case tokPipe:
+		return operand{}, fmt.Errorf("unexpected |")
+	}
+
+	switch tok.text {
+	case "true":
+		return operand{value: true}, nil
+	case "false":
+		return operand{value: false}, nil
+	case "null":
+		return operand{value: nil}, nil
+	}
+
+	if c := tok.text[0]; c == '-' || (c >= '0' && c <= '9') {
+		num, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid number %q", tok.text)
+		}
+
+		return operand{value: num}, nil
+	}
+
+	path, _, _ := strings.Cut(tok.text, ";")
+
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return operand{}, fmt.Errorf("invalid path %q", tok.text)
+		}
+	}
+
+	return operand{path: tok.text}, nil
+}
+
+// eval evaluates the operand.
+// It takes doc of type *document, which is the template data.
+// It returns the value and an error if the path can't be read.
+func (o *operand) eval(doc *document) (any, error) {
+	if o.path == "" {
+		return o.value, nil
+	}
+
+	return doc.Read(o.path)
+}
+
+// eval evaluates the expression.
+// It takes doc of type *document, which is the template data.
+// It returns the value and an error if any path can't be read or any function fails.
+// A missing value is passed to functions that accept it, e.g. default, as null.
+func (e *expression) eval(doc *document) (any, error) {
+	v, err := e.head.eval(doc)
+
+	for i := range e.calls {
+		c := &e.calls[i]
+
+		if err != nil {
+			if !c.fn.missing || e.head.path == "" || !doc.missing(e.head.path) {
+				return nil, err
+			}
+
+			v, err = nil, nil
+		}
+
+		args := make([]any, len(c.args))
+
+		for j := range c.args {
+			if args[j], err = c.args[j].eval(doc); err != nil {
+				return nil, err
+			}
+		}
+
+		if v, err = c.fn.call(v, args); err != nil {
+			return nil, fmt.Errorf("function %s failed: %w", c.name, err)
+		}
+	}
+
+	return v, err
+}
+
+// evalString evaluates the expression into a string.
+// It takes doc of type *document, which is the template data.
+// It returns the string and an error if the expression can't be evaluated or its value is a list or an object.
+// Numbers of plain paths keep their representation in the data, unless it uses an exponent.
+func (e *expression) evalString(doc *document) (string, error) {
+	v, err := e.eval(doc)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := v.(float64); ok && len(e.calls) == 0 && e.head.path != "" {
+		if raw, err := doc.Read(e.head.path + ";escape"); err == nil {
+			if str, ok := raw.(string); ok && !strings.ContainsAny(str, "eE") {
+				return str, nil
+			}
+		}
+	}
+
+	return formatString(v)
+}
+
+// formatString converts the value of the placeholder of a string template into a string.
+// It takes v of type any.
+// It returns the value converted with toString and an error if the value is a list or an object.
+func formatString(v any) (string, error) {
+	switch v.(type) {
+	case map[string]any:
+		return "", fmt.Errorf("expected string, number, boolean or null, got object")
+	case []any:
+		return "", fmt.Errorf("expected string, number, boolean or null, got array")
+	default:
+		return toString(v), nil
+	}
+}
+
+// refs returns references of the expression to the template data.
+func (e *expression) refs() []Ref {
+	refs := make([]Ref, 0, 1)
+
+	if e.head.path != "" {
+		refs = append(refs, newRef(e.head.path, len(e.calls) == 0))
+	}
+
+	for _, c := range e.calls {
+		for _, arg := range c.args {
+			if arg.path != "" {
+				refs = append(refs, newRef(arg.path, false))
+			}
+		}
+	}
+
+	return refs
+}
+
+// newRef creates a reference to the path, where tags of the path are removed.
+func newRef(path string, raw bool) Ref {
+	path, _, _ = strings.Cut(path, ";")
+
+	return Ref{Path: path, Raw: raw}
+}
+
+// collectRefs returns references of all expressions sorted by path, without duplicates.
+func collectRefs(exprs map[string]*expression) []Ref {
+	refs := make([]Ref, 0, len(exprs))
+
+	for _, e := range exprs {
+		for _, ref := range e.refs() {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	sortRefs(refs)
+
+	return refs
+}
+
+// sortRefs sorts references by path, placing references to raw values after the others.
+func sortRefs(refs []Ref) {
+	slices.SortFunc(refs, func(a, b Ref) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+
+		return strings.Compare(strconv.FormatBool(a.Raw), strconv.FormatBool(b.Raw))
+	})
+}
+
+// compileTags compiles expressions of all placeholders of the template.
+// It takes ft of type *fasttemplate.Template and decode, which converts the raw content of a placeholder into the expression source.
+// It returns a map of expressions keyed by the raw content of placeholders and an error if any of them is invalid.
+func compileTags(ft *fasttemplate.Template, decode func(string) (string, error)) (map[string]*expression, error) {
+	exprs := make(map[string]*expression)
+
+	_, err := ft.ExecuteFuncStringWithErr(func(_ io.Writer, tag string) (int, error) {
+		if _, ok := exprs[tag]; ok {
+			return 0, nil
+		}
+
+		src, err := decode(tag)
+		if err != nil {
+			return 0, fmt.Errorf("invalid placeholder ${%s}: %w", tag, err)
+		}
+
+		e, err := parseExpr(src)
+		if err != nil {
+			return 0, fmt.Errorf("invalid placeholder ${%s}: %w", src, err)
+		}
+
+		exprs[tag] = e
+
+		return 0, nil
+	})
+
+	return exprs, err
+}
+
+// rawTag returns the content of the placeholder as is.
+func rawTag(tag string) (string, error) {
+	return tag, nil
+}
+
+// jsonTag decodes the content of the placeholder from a JSON string, so quotes in the expression can be escaped in JSON templates.
+func jsonTag(tag string) (string, error) {
+	var src string
+
+	if err := json.Unmarshal([]byte(`"`+tag+`"`), &src); err != nil {
+		return "", err
+	}
+
+	return src, nil
+}
+
+// executeStr executes the string template with the provided parameters.
+// It takes ft of type *fasttemplate.Template, exprs with the compiled placeholders, params of type any, and escape,
+// which is applied to values of placeholders.
+// It returns the resulting string and an error if the parameters can't be marshaled or any placeholder can't be evaluated.
+func executeStr(ft *fasttemplate.Template, exprs map[string]*expression, params any, escape func(string) string) (string, error) {
+	jData, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request template: %w", err)
+	}
+
+	doc := newDocument(jData)
+
+	str, err := ft.ExecuteFuncStringWithErr(func(w io.Writer, tag string) (int, error) {
+		v, err := exprs[tag].evalString(doc)
+		if err != nil {
+			return 0, err
+		}
+
+		return w.Write([]byte(escape(v)))
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return str, nil
+}
+
+// encodeJSON encodes the value of the placeholder of a JSON template.
+// It takes v of type any.
+// It returns the encoded value and an error if it can't be encoded.
+func encodeJSON(v any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if err := jsontemplate.JSONEncoder(buf, v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,188 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		want    *expression
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name: "Path",
+			src:  "params.name",
+			want: &expression{head: operand{path: "params.name"}},
+		},
+		{
+			name: "Path with escape tag",
+			src:  " params.user;escape ",
+			want: &expression{head: operand{path: "params.user;escape"}},
+		},
+		{
+			name: "Pipeline",
+			src:  `params.name | default 'John \'Doe\'' | concat " " params.last 1 true null`,
+			want: &expression{
+				head: operand{path: "params.name"},
+				calls: []call{
+					{fn: funcs["default"], name: "default", args: []operand{{value: "John 'Doe'"}}},
+					{fn: funcs["concat"], name: "concat", args: []operand{{value: " "}, {path: "params.last"}, {value: 1.0}, {value: true}, {}}},
+				},
+			},
+		},
+		{
+			name: "Literal",
+			src:  "-1.5|add 2",
+			want: &expression{
+				head:  operand{value: -1.5},
+				calls: []call{{fn: funcs["add"], name: "add", args: []operand{{value: 2.0}}}},
+			},
+		},
+		{
+			name:    "Empty",
+			src:     " ",
+			wantErr: "empty expression",
+		},
+		{
+			name:    "Unterminated string",
+			src:     `params.name | default 'John`,
+			wantErr: "unterminated string 'John",
+		},
+		{
+			name:    "Missing pipe",
+			src:     "params.name upper",
+			wantErr: `unexpected "upper", expected |`,
+		},
+		{
+			name:    "Missing function",
+			src:     "params.name |",
+			wantErr: "function name expected after |",
+		},
+		{
+			name:    "Unknown function",
+			src:     "params.name | shout",
+			wantErr: `unknown function "shout"`,
+		},
+		{
+			name:    "Invalid number",
+			src:     "params.amount | add 1x",
+			wantErr: `invalid number "1x"`,
+		},
+		{
+			name:    "Invalid path",
+			src:     "params..name",
+			wantErr: `invalid path "params..name"`,
+		},
+		{
+			name:    "Too many arguments",
+			src:     "params.name | upper 'x'",
+			wantErr: "wrong number of arguments for function upper: 1",
+		},
+		{
+			name:    "Too few arguments",
+			src:     "params.name | replace 'a'",
+			wantErr: "wrong number of arguments for function replace: 1",
+		},
+		{
+			name:    "Wrong literal type",
+			src:     "params.amount | mul '100'",
+			wantErr: "argument 1 of function mul must be a number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := parseExpr(tt.src)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.head, e.head)
+			require.Len(t, e.calls, len(tt.want.calls))
+
+			for i, c := range tt.want.calls {
+				assert.Equal(t, c.name, e.calls[i].name)
+				assert.Same(t, c.fn, e.calls[i].fn)
+				assert.Equal(t, c.args, e.calls[i].args)
+			}
+		})
+	}
+}
+
+func TestTemplates_InvalidPlaceholder(t *testing.T) {
+	_, err := New(`{"name": "${params.name | shout}"}`)
+	assert.ErrorContains(t, err, `invalid placeholder ${params.name | shout}: unknown function "shout"`)
+
+	_, err = NewStrTmpl("Hello, ${params.name | upper 1}!")
+	assert.ErrorContains(t, err, "invalid placeholder ${params.name | upper 1}: wrong number of arguments for function upper: 1")
+
+	_, err = NewURLTmpl("http://localhost/${params.id | add}")
+	assert.ErrorContains(t, err, "invalid placeholder ${params.id | add}: wrong number of arguments for function add: 0")
+}
+
+func TestTemplates_Functions(t *testing.T) {
+	params := map[string]any{
+		"params": map[string]any{
+			"name":     " john ",
+			"currency": "usd",
+			"amount":   12.5,
+			"page":     2,
+			"vip":      true,
+		},
+	}
+
+	body, err := MustNewTmpl(`{
+		"name": "${params.name | trim | upper}",
+		"currency": "${params.currency | default \"USD\" | upper}",
+		"country": "${params.country | default 'GB'}",
+		"amount": "${params.amount | mul 100}",
+		"tier": "${params.vip | if 'gold' 'basic'}",
+		"params": "${params}"
+	}`).Execute(params)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "JOHN",
+		"currency": "USD",
+		"country": "GB",
+		"amount": 1250,
+		"tier": "gold",
+		"params": {"name": " john ", "currency": "usd", "amount": 12.5, "page": 2, "vip": true}
+	}`, string(body))
+
+	str, err := MustNewStrTmpl("${params.currency | upper}/${params.amount | format_number 2}").Execute(params)
+	require.NoError(t, err)
+	assert.Equal(t, "USD/12.50", str)
+
+	url, err := MustNewURLTmpl("http://localhost/items?q=${params.name | trim | concat ' & more'}&page=${params.page | add 1}").Execute(params)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/items?q=john+%26+more&page=3", url)
+
+	_, err = MustNewStrTmpl("${params.missing | upper}").Execute(params)
+	assert.ErrorContains(t, err, "token not found")
+
+	_, err = MustNewStrTmpl("${params.name | add 1}").Execute(params)
+	assert.ErrorContains(t, err, `function add failed: expected number, got " john "`)
+}
+
+func TestTemplates_Refs(t *testing.T) {
+	body := MustNewTmpl(`{"name": "${params.name | default resp_user.name}", "id": "${params.id;escape}", "copy": "${params.id}"}`)
+	assert.Equal(t, []Ref{
+		{Path: "params.id", Raw: true},
+		{Path: "params.name"},
+		{Path: "resp_user.name"},
+	}, body.Refs())
+
+	url := MustNewURLTmpl("http://localhost/${params.id}?q=${params.q | trim}")
+	assert.Equal(t, []Ref{{Path: "params.id", Raw: true}, {Path: "params.q"}}, url.Refs())
+
+	str := MustNewStrTmpl("static")
+	assert.Empty(t, str.Refs())
+}
@@ -0,0 +1,324 @@
+package tmpl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type argKind int
+
+const (
+	kindAny argKind = iota
+	kindString
+	kindNumber
+)
+
+// function is a template function, which takes the result of the previous pipeline stage as input.
+type function struct {
+	call     func(in any, args []any) (any, error)
+	args     []argKind
+	minArgs  int
+	variadic bool
+	missing  bool
+}
+
+// funcs is the library of functions available in placeholders.
+var funcs = map[string]*function{
+	"default":       {call: fnDefault, args: []argKind{kindAny}, minArgs: 1, missing: true},
+	"concat":        {call: fnConcat, args: []argKind{kindAny}, minArgs: 1, variadic: true},
+	"upper":         {call: stringFunc(strings.ToUpper)},
+	"lower":         {call: stringFunc(strings.ToLower)},
+	"trim":          {call: fnTrim, args: []argKind{kindString}},
+	"trim_prefix":   {call: fnTrimPrefix, args: []argKind{kindString}, minArgs: 1},
+	"trim_suffix":   {call: fnTrimSuffix, args: []argKind{kindString}, minArgs: 1},
+	"replace":       {call: fnReplace, args: []argKind{kindString, kindString}, minArgs: 2},
+	"to_string":     {call: fnToString},
+	"to_number":     {call: fnToNumber},
+	"format_number": {call: fnFormatNumber, args: []argKind{kindNumber}, minArgs: 1},
+	"add":           {call: arithFunc(func(a, b float64) (float64, error) { return a + b, nil }), args: []argKind{kindNumber}, minArgs: 1},
+	"sub":           {call: arithFunc(func(a, b float64) (float64, error) { return a - b, nil }), args: []argKind{kindNumber}, minArgs: 1},
+	"mul":           {call: arithFunc(func(a, b float64) (float64, error) { return a * b, nil }), args: []argKind{kindNumber}, minArgs: 1},
+	"div":           {call: arithFunc(divide), args: []argKind{kindNumber}, minArgs: 1},
+	"date":          {call: fnDate, args: []argKind{kindString}, minArgs: 1},
+	"base64_encode": {call: stringFunc(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) })},
+	"base64_decode": {call: fnBase64Decode},
+	"url_encode":    {call: stringFunc(url.QueryEscape)},
+	"url_decode":    {call: fnURLDecode},
+	"eq":            {call: fnEq, args: []argKind{kindAny}, minArgs: 1},
+	"ne":            {call: fnNe, args: []argKind{kindAny}, minArgs: 1},
+	"gt":            {call: compareFunc(func(a, b float64) bool { return a > b }), args: []argKind{kindNumber}, minArgs: 1},
+	"lt":            {call: compareFunc(func(a, b float64) bool { return a < b }), args: []argKind{kindNumber}, minArgs: 1},
+	"not":           {call: fnNot},
+	"if":            {call: fnIf, args: []argKind{kindAny, kindAny}, minArgs: 1},
+}
+
+// check validates arguments of the function call at parse time.
+// It takes name of type string, which is the name of the function, and args of type []operand.
+// It returns an error if the number of arguments is wrong or a literal argument has a wrong type.
+// Arguments that are paths are checked at runtime.
+func (f *function) check(name string, args []operand) error {
+	if len(args) < f.minArgs || (!f.variadic && len(args) > len(f.args)) {
+		return fmt.Errorf("wrong number of arguments for function %s: %d", name, len(args))
+	}
+
+	for i, arg := range args {
+		if arg.path != "" {
+			continue
+		}
+
+		kind := f.args[min(i, len(f.args)-1)]
+
+		if _, ok := arg.value.(string); kind == kindString && !ok {
+			return fmt.Errorf("argument %d of function %s must be a string", i+1, name)
+		}
+
+		if _, ok := arg.value.(float64); kind == kindNumber && !ok {
+			return fmt.Errorf("argument %d of function %s must be a number", i+1, name)
+		}
+	}
+
+	return nil
+}
+
+// toString converts the value into a string.
+// It takes v of type any.
+// It returns the value as is for strings, an empty string for null, the shortest representation without exponent for numbers,
+// and JSON for other values.
+func toString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+// toNumber converts the value into a number.
+// It takes v of type any.
+// It returns the number and an error if the value is neither a number nor a numeric string.
+func toNumber(v any) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case string:
+		num, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected number, got %q", val)
+		}
+
+		return num, nil
+	default:
+		return 0, fmt.Errorf("expected number, got %T", v)
+	}
+}
+
+// truthy reports whether the value is considered true by conditionals.
+// Null, false, zero, empty strings, lists and objects are false, and other values are true.
+func truthy(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	case []any:
+		return len(val) > 0
+	case map[string]any:
+		return len(val) > 0
+	default:
+		return true
+	}
+}
+
+func stringFunc(fn func(string) string) func(any, []any) (any, error) {
+	return func(in any, _ []any) (any, error) {
+		return fn(toString(in)), nil
+	}
+}
+
+func arithFunc(fn func(a, b float64) (float64, error)) func(any, []any) (any, error) {
+	return func(in any, args []any) (any, error) {
+		a, err := toNumber(in)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(a, b)
+	}
+}
+
+func compareFunc(fn func(a, b float64) bool) func(any, []any) (any, error) {
+	return func(in any, args []any) (any, error) {
+		a, err := toNumber(in)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(a, b), nil
+	}
+}
+
+func divide(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+
+	return a / b, nil
+}
+
+func fnDefault(in any, args []any) (any, error) {
+	if in == nil || in == "" {
+		return args[0], nil
+	}
+
+	return in, nil
+}
+
+func fnConcat(in any, args []any) (any, error) {
+	var sb strings.Builder
+
+	sb.WriteString(toString(in))
+
+	for _, arg := range args {
+		sb.WriteString(toString(arg))
+	}
+
+	return sb.String(), nil
+}
+
+func fnTrim(in any, args []any) (any, error) {
+	if len(args) == 0 {
+		return strings.TrimSpace(toString(in)), nil
+	}
+
+	return strings.Trim(toString(in), toString(args[0])), nil
+}
+
+func fnTrimPrefix(in any, args []any) (any, error) {
+	return strings.TrimPrefix(toString(in), toString(args[0])), nil
+}
+
+func fnTrimSuffix(in any, args []any) (any, error) {
+	return strings.TrimSuffix(toString(in), toString(args[0])), nil
+}
+
+func fnReplace(in any, args []any) (any, error) {
+	return strings.ReplaceAll(toString(in), toString(args[0]), toString(args[1])), nil
+}
+
+func fnToString(in any, _ []any) (any, error) {
+	return toString(in), nil
+}
+
+func fnToNumber(in any, _ []any) (any, error) {
+	return toNumber(in)
+}
+
+func fnFormatNumber(in any, args []any) (any, error) {
+	num, err := toNumber(in)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if decimals < 0 || decimals != math.Trunc(decimals) {
+		return nil, fmt.Errorf("invalid number of decimals %v", decimals)
+	}
+
+	return strconv.FormatFloat(num, 'f', int(decimals), 64), nil
+}
+
+// fnDate formats the time given as Unix seconds or an RFC 3339 string in UTC.
+// The layout is a Go time layout, "rfc3339", or "unix" for Unix seconds.
+func fnDate(in any, args []any) (any, error) {
+	var t time.Time
+
+	if num, err := toNumber(in); err == nil {
+		sec, frac := math.Modf(num)
+		t = time.Unix(int64(sec), int64(frac*float64(time.Second)))
+	} else if str, ok := in.(string); ok {
+		if t, err = time.Parse(time.RFC3339, str); err != nil {
+			return nil, fmt.Errorf("invalid time %q", str)
+		}
+	} else {
+		return nil, fmt.Errorf("expected time, got %T", in)
+	}
+
+	switch layout := toString(args[0]); layout {
+	case "unix":
+		return float64(t.Unix()), nil
+	case "rfc3339":
+		return t.UTC().Format(time.RFC3339), nil
+	default:
+		return t.UTC().Format(layout), nil
+	}
+}
+
+func fnBase64Decode(in any, _ []any) (any, error) {
+	data, err := base64.StdEncoding.DecodeString(toString(in))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func fnURLDecode(in any, _ []any) (any, error) {
+	return url.QueryUnescape(toString(in))
+}
+
+func fnEq(in any, args []any) (any, error) {
+	return reflect.DeepEqual(in, args[0]), nil
+}
+
+func fnNe(in any, args []any) (any, error) {
+	return !reflect.DeepEqual(in, args[0]), nil
+}
+
+func fnNot(in any, _ []any) (any, error) {
+	return !truthy(in), nil
+}
+
+// fnIf returns the first argument if the input is truthy, and the second argument or null otherwise.
+func fnIf(in any, args []any) (any, error) {
+	if truthy(in) {
+		return args[0], nil
+	}
+
+	if len(args) > 1 {
+		return args[1], nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncs(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    any
+		name    string
+		fn      string
+		wantErr string
+		args    []any
+	}{
+		{name: "default for null", fn: "default", in: nil, args: []any{"x"}, want: "x"},
+		{name: "default for empty string", fn: "default", in: "", args: []any{"x"}, want: "x"},
+		{name: "default for value", fn: "default", in: false, args: []any{"x"}, want: false},
+		{name: "concat", fn: "concat", in: "a", args: []any{"-", 1.5, true, nil}, want: "a-1.5true"},
+		{name: "upper", fn: "upper", in: "abc", want: "ABC"},
+		{name: "lower", fn: "lower", in: "ABC", want: "abc"},
+		{name: "trim", fn: "trim", in: " abc\n", want: "abc"},
+		{name: "trim cutset", fn: "trim", in: "--abc-", args: []any{"-"}, want: "abc"},
+		{name: "trim_prefix", fn: "trim_prefix", in: "CR123", args: []any{"CR"}, want: "123"},
+		{name: "trim_suffix", fn: "trim_suffix", in: "R_50", args: []any{"_50"}, want: "R"},
+		{name: "replace", fn: "replace", in: "a-b-c", args: []any{"-", "_"}, want: "a_b_c"},
+		{name: "to_string", fn: "to_string", in: 10.0, want: "10"},
+		{name: "to_string object", fn: "to_string", in: map[string]any{"a": 1.0}, want: `{"a":1}`},
+		{name: "to_number", fn: "to_number", in: " 10.5 ", want: 10.5},
+		{name: "to_number invalid", fn: "to_number", in: "ten", wantErr: `expected number, got "ten"`},
+		{name: "format_number", fn: "format_number", in: 1.005e3, args: []any{2.0}, want: "1005.00"},
+		{name: "format_number invalid decimals", fn: "format_number", in: 1.0, args: []any{1.5}, wantErr: "invalid number of decimals 1.5"},
+		{name: "add", fn: "add", in: "2", args: []any{3.0}, want: 5.0},
+		{name: "sub", fn: "sub", in: 2.0, args: []any{3.0}, want: -1.0},
+		{name: "mul", fn: "mul", in: 2.0, args: []any{3.0}, want: 6.0},
+		{name: "div", fn: "div", in: 3.0, args: []any{2.0}, want: 1.5},
+		{name: "div by zero", fn: "div", in: 3.0, args: []any{0.0}, wantErr: "division by zero"},
+		{name: "add invalid", fn: "add", in: true, args: []any{1.0}, wantErr: "expected number, got bool"},
+		{name: "date from unix", fn: "date", in: 1700000000.0, args: []any{"2006-01-02"}, want: "2023-11-14"},
+		{name: "date from string", fn: "date", in: "2023-11-14T22:13:20+02:00", args: []any{"rfc3339"}, want: "2023-11-14T20:13:20Z"},
+		{name: "date to unix", fn: "date", in: "2023-11-14T22:13:20Z", args: []any{"unix"}, want: 1700000000.0},
+		{name: "date invalid", fn: "date", in: "yesterday", args: []any{"unix"}, wantErr: `invalid time "yesterday"`},
+		{name: "base64_encode", fn: "base64_encode", in: "user:pass", want: "dXNlcjpwYXNz"},
+		{name: "base64_decode", fn: "base64_decode", in: "dXNlcjpwYXNz", want: "user:pass"},
+		{name: "base64_decode invalid", fn: "base64_decode", in: "!", wantErr: "illegal base64 data at input byte 0"},
+		{name: "url_encode", fn: "url_encode", in: "a b&c", want: "a+b%26c"},
+		{name: "url_decode", fn: "url_decode", in: "a+b%26c", want: "a b&c"},
+		{name: "eq", fn: "eq", in: "real", args: []any{"real"}, want: true},
+		{name: "eq different types", fn: "eq", in: "1", args: []any{1.0}, want: false},
+		{name: "ne", fn: "ne", in: 1.0, args: []any{2.0}, want: true},
+		{name: "gt", fn: "gt", in: 2.0, args: []any{1.0}, want: true},
+		{name: "lt", fn: "lt", in: "2", args: []any{1.0}, want: false},
+		{name: "not", fn: "not", in: []any{}, want: true},
+		{name: "if true", fn: "if", in: "x", args: []any{"a", "b"}, want: "a"},
+		{name: "if false", fn: "if", in: 0.0, args: []any{"a", "b"}, want: "b"},
+		{name: "if false without else", fn: "if", in: map[string]any{}, args: []any{"a"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, ok := funcs[tt.fn]
+			require.True(t, ok)
+
+			got, err := fn.call(tt.in, tt.args)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
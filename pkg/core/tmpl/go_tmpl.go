@@ -0,0 +1,520 @@
+package tmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+const (
+	fnValue = "_value"
+	fnStr   = "_str"
+	fnURL   = "_url"
+
+	// valueMarker prefixes the JSON value of a template that consists of a single action.
+	valueMarker = "\x00"
+)
+
+// goBuiltins are functions predefined by text/template, which are not shadowed by functions of the library,
+// so they keep their documented behavior, e.g. eq comparing its first argument with the rest.
+var goBuiltins = []string{
+	"and", "or", "not", "len", "index", "slice", "print", "printf", "println",
+	"html", "js", "urlquery", "call", "eq", "ne", "lt", "le", "gt", "ge",
+}
+
+// goFuncs is the library of functions for Go templates, where the piped value is the last argument,
+// e.g. {{ .params.name | default "n/a" | upper }}.
+var goFuncs = newGoFuncs()
+
+// goEngine compiles Go templates, e.g. {{ .params.name | upper }}.
+type goEngine struct{}
+
+// goText is a compiled Go template of a string.
+type goText struct {
+	t     *template.Template
+	refs  []Ref
+	typed bool
+}
+
+// GoJSONTmpl is a JSON template, where string values are Go templates.
+type GoJSONTmpl struct {
+	root any
+	refs []Ref
+}
+
+// GoStrTmpl is a Go template of a string.
+type GoStrTmpl struct {
+	text *goText
+}
+
+// JSON compiles the JSON template, where string values are Go templates.
+// It takes src of type string, which is the JSON template.
+// It returns a JSONTemplate and an error if the template is not valid JSON or any of its string values is an invalid template.
+// String values that consist of a single action are replaced with the JSON value of the action, e.g. "{{ .params.ids }}" produces a list.
+func (goEngine) JSON(src string) (JSONTemplate, error) {
+	var body any
+
+	if err := decodeJSON([]byte(src), &body); err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	t := &GoJSONTmpl{}
+
+	root, err := t.compile(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	t.root = root
+
+	return t, nil
+}
+
+// String compiles the Go template of a string.
+// It takes src of type string.
+// It returns a StringTemplate and an error if the template is invalid.
+func (goEngine) String(src string) (StringTemplate, error) {
+	text, err := parseGo(src, fnStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	return &GoStrTmpl{text: text}, nil
+}
+
+// URL compiles the Go template of a URL, where printed values are URL-encoded.
+// It takes src of type string.
+// It returns a StringTemplate and an error if the template is invalid.
+func (goEngine) URL(src string) (StringTemplate, error) {
+	text, err := parseGo(src, fnURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	return &GoStrTmpl{text: text}, nil
+}
+
+// Placeholder returns the action that prints the path, e.g. {{ .req_id }}.
+func (goEngine) Placeholder(path string) string {
+	return "{{ ." + path + " }}"
+}
+
+// compile replaces string values of the JSON document that contain actions with compiled templates.
+// It takes v of type any, which is a decoded JSON value.
+// It returns the compiled value and an error if any template is invalid.
+func (t *GoJSONTmpl) compile(v any) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		compiled := make(map[string]any, len(val))
+
+		for key, item := range val {
+			c, err := t.compile(item)
+			if err != nil {
+				return nil, err
+			}
+
+			compiled[key] = c
+		}
+
+		return compiled, nil
+	case []any:
+		compiled := make([]any, len(val))
+
+		for i, item := range val {
+			c, err := t.compile(item)
+			if err != nil {
+				return nil, err
+			}
+
+			compiled[i] = c
+		}
+
+		return compiled, nil
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+
+		text, err := parseGo(val, fnValue)
+		if err != nil {
+			return nil, err
+		}
+
+		t.refs = mergeRefs(t.refs, text.refs)
+
+		return text, nil
+	default:
+		return val, nil
+	}
+}
+
+// Execute renders the JSON document with the provided parameters.
+// It takes params of type any, which is the template data.
+// It returns the JSON document and an error if the parameters can't be marshaled or any template fails.
+func (t *GoJSONTmpl) Execute(params any) ([]byte, error) {
+	data, err := goData(params)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := renderGo(t.root, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return json.Marshal(v)
+}
+
+// Refs returns references of the template to the template data, sorted by path.
+func (t *GoJSONTmpl) Refs() []Ref {
+	return t.refs
+}
+
+// Execute renders the string with the provided parameters.
+// It takes params of type any, which is the template data.
+// It returns the string and an error if the parameters can't be marshaled or the template fails.
+func (t *GoStrTmpl) Execute(params any) (string, error) {
+	data, err := goData(params)
+	if err != nil {
+		return "", err
+	}
+
+	str, err := t.text.execute(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return str, nil
+}
+
+// Refs returns references of the template to the template data, sorted by path.
+func (t *GoStrTmpl) Refs() []Ref {
+	return t.text.refs
+}
+
+// renderGo renders templates of the compiled JSON value.
+// It takes v of type any, which is a compiled value, and data, which is the template data.
+// It returns the rendered value and an error if any template fails.
+func renderGo(v any, data any) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		rendered := make(map[string]any, len(val))
+
+		for key, item := range val {
+			r, err := renderGo(item, data)
+			if err != nil {
+				return nil, err
+			}
+
+			rendered[key] = r
+		}
+
+		return rendered, nil
+	case []any:
+		rendered := make([]any, len(val))
+
+		for i, item := range val {
+			r, err := renderGo(item, data)
+			if err != nil {
+				return nil, err
+			}
+
+			rendered[i] = r
+		}
+
+		return rendered, nil
+	case *goText:
+		str, err := val.execute(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if raw, ok := strings.CutPrefix(str, valueMarker); ok && val.typed {
+			return json.RawMessage(raw), nil
+		}
+
+		return str, nil
+	default:
+		return val, nil
+	}
+}
+
+// execute executes the template.
+// It takes data of type any.
+// It returns the output and an error if the template fails.
+func (g *goText) execute(data any) (string, error) {
+	var buf bytes.Buffer
+
+	if err := g.t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// goData converts the template data into maps and lists, so fields are addressed by their JSON names.
+// It takes params of type any.
+// It returns the converted data and an error if the data can't be marshaled.
+// Numbers are kept as json.Number, so they are printed as in the data.
+func goData(params any) (any, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request template: %w", err)
+	}
+
+	var data any
+
+	if err := decodeJSON(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to marshal request template: %w", err)
+	}
+
+	return data, nil
+}
+
+// decodeJSON decodes the JSON document into v, keeping numbers as json.Number.
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// parseGo parses the Go template and appends the print function to each action that prints a value.
+// It takes src of type string and print, which is the name of the function that formats printed values.
+// It returns a pointer to goText and an error if the template is invalid.
+// If print is _value and the template consists of a single action, the template produces the JSON value of the action.
+func parseGo(src, print string) (*goText, error) {
+	t, err := template.New("").Funcs(goFuncs).Option("missingkey=default").Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	text := &goText{t: t, refs: goRefs(t.Tree.Root, true)}
+
+	if action, ok := singleAction(t.Tree.Root.Nodes); ok && print == fnValue {
+		text.typed = true
+
+		appendCmd(t.Tree, action, fnValue)
+
+		return text, nil
+	}
+
+	if print == fnValue {
+		print = fnStr
+	}
+
+	for _, def := range t.Templates() {
+		if def.Tree != nil {
+			walkActions(def.Tree.Root, func(a *parse.ActionNode) {
+				appendCmd(def.Tree, a, print)
+			})
+		}
+	}
+
+	return text, nil
+}
+
+// singleAction returns the action if the nodes consist of a single action that prints a value.
+func singleAction(nodes []parse.Node) (*parse.ActionNode, bool) {
+	if len(nodes) != 1 {
+		return nil, false
+	}
+
+	action, ok := nodes[0].(*parse.ActionNode)
+
+	return action, ok && len(action.Pipe.Decl) == 0
+}
+
+// appendCmd appends the call of the function to the pipeline of the action.
+func appendCmd(tree *parse.Tree, a *parse.ActionNode, name string) {
+	a.Pipe.Cmds = append(a.Pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      a.Pos,
+		Args:     []parse.Node{parse.NewIdentifier(name).SetTree(tree).SetPos(a.Pos)},
+	})
+}
+
+// walkActions calls fn for each action of the node that prints a value.
+func walkActions(node parse.Node, fn func(*parse.ActionNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			walkActions(child, fn)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			fn(n)
+		}
+	case *parse.IfNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	case *parse.RangeNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	case *parse.WithNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	}
+}
+
+// goRefs returns references of fields of the template data in the node, sorted by path.
+// It takes node of type parse.Node and rooted, which is true if the dot is the template data.
+// Fields inside range and with blocks are relative to their values, so only references through $ are collected there.
+func goRefs(node parse.Node, rooted bool) []Ref {
+	refs := make([]Ref, 0)
+
+	var walk func(node parse.Node, rooted, raw bool)
+
+	walk = func(node parse.Node, rooted, raw bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+
+			for _, child := range n.Nodes {
+				walk(child, rooted, false)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, rooted, len(n.Pipe.Decl) == 0)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg, rooted, raw && len(n.Cmds) == 1 && len(cmd.Args) == 1)
+				}
+			}
+		case *parse.FieldNode:
+			if rooted {
+				refs = mergeRefs(refs, []Ref{{Path: strings.Join(n.Ident, "."), Raw: raw}})
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				refs = mergeRefs(refs, []Ref{{Path: strings.Join(n.Ident[1:], "."), Raw: raw}})
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, rooted, false)
+			walk(n.List, rooted, false)
+			walk(n.ElseList, rooted, false)
+		case *parse.RangeNode:
+			walk(n.Pipe, rooted, false)
+			walk(n.List, false, false)
+			walk(n.ElseList, rooted, false)
+		case *parse.WithNode:
+			walk(n.Pipe, rooted, false)
+			walk(n.List, false, false)
+			walk(n.ElseList, rooted, false)
+		}
+	}
+
+	walk(node, rooted, false)
+
+	return refs
+}
+
+// mergeRefs adds references that are not in the list yet, keeping the list sorted by path.
+func mergeRefs(refs, add []Ref) []Ref {
+	for _, ref := range add {
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	sortRefs(refs)
+
+	return refs
+}
+
+// newGoFuncs adapts the library of functions to Go templates, where the piped value is the last argument.
+// Functions predefined by Go templates, like eq or not, are not shadowed.
+func newGoFuncs() template.FuncMap {
+	fm := template.FuncMap{
+		fnValue: func(v any) (string, error) {
+			data, err := encodeJSON(normalize(v))
+			if err != nil {
+				return "", err
+			}
+
+			return valueMarker + string(data), nil
+		},
+		fnStr: func(v any) (string, error) {
+			return formatString(normalize(v))
+		},
+		fnURL: func(v any) (string, error) {
+			str, err := formatString(normalize(v))
+			return url.QueryEscape(str), err
+		},
+	}
+
+	for name, fn := range funcs {
+		if slices.Contains(goBuiltins, name) {
+			continue
+		}
+
+		fm[name] = func(args ...any) (any, error) {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("function %s expects a piped value", name)
+			}
+
+			rest := make([]any, len(args)-1)
+			for i := range rest {
+				rest[i] = normalize(args[i])
+			}
+
+			if len(rest) < fn.minArgs || (!fn.variadic && len(rest) > len(fn.args)) {
+				return nil, fmt.Errorf("wrong number of arguments for function %s: %d", name, len(rest))
+			}
+
+			return fn.call(normalize(args[len(args)-1]), rest)
+		}
+	}
+
+	return fm
+}
+
+// normalize converts values of Go templates into values of the template data,
+// e.g. json.Number and Go numbers into float64.
+func normalize(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if num, err := val.Float64(); err == nil {
+			return num
+		}
+
+		return val.String()
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case map[string]any:
+		normalized := make(map[string]any, len(val))
+
+		for key, item := range val {
+			normalized[key] = normalize(item)
+		}
+
+		return normalized
+	case []any:
+		normalized := make([]any, len(val))
+
+		for i, item := range val {
+			normalized[i] = normalize(item)
+		}
+
+		return normalized
+	default:
+		return v
+	}
+}
@@ -1,29 +1,32 @@
 package tmpl
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 
 	"github.com/valyala/fasttemplate"
-	"github.com/wolfeidau/jsontemplate"
 )
 
 type StrTmpl struct {
-	tmpl *fasttemplate.Template
+	tmpl  *fasttemplate.Template
+	exprs map[string]*expression
 }
 
 // NewStrTmpl creates a new StrTmpl instance by parsing the provided template string.
 // It takes tmpl of type string, which is the template to be parsed.
 // It returns a pointer to StrTmpl and an error.
-// It returns an error if the template parsing fails.
+// It returns an error if the template parsing fails or any of its placeholders is invalid.
 func NewStrTmpl(tmpl string) (*StrTmpl, error) {
 	t, err := fasttemplate.NewTemplate(tmpl, "${", "}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse request template: %w", err)
 	}
 
-	return &StrTmpl{tmpl: t}, nil
+	exprs, err := compileTags(t, rawTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	return &StrTmpl{tmpl: t, exprs: exprs}, nil
 }
 
 // MustNewStrTmpl creates a new StrTmpl from the provided raw template string.
@@ -45,28 +48,10 @@ func MustNewStrTmpl(tmplRaw string) *StrTmpl {
 // It returns an error if the parameters cannot be marshaled into JSON, if the template execution fails,
 // or if the template path does not resolve to a string.
 func (t *StrTmpl) Execute(params any) (string, error) {
-	jData, err := json.Marshal(params)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request template: %w", err)
-	}
-
-	doc := jsontemplate.NewDocument(jData)
-
-	str, err := t.tmpl.ExecuteFuncStringWithErr(func(w io.Writer, path string) (int, error) {
-		v, err := doc.Read(path + ";escape")
-		if err != nil {
-			return 0, err
-		}
-
-		if str, ok := v.(string); ok {
-			return w.Write([]byte(str))
-		}
-
-		return 0, fmt.Errorf("expected string, got %T", v)
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
-	}
+	return executeStr(t.tmpl, t.exprs, params, func(s string) string { return s })
+}
 
-	return str, nil
+// Refs returns references of placeholders of the template to the template data, sorted by path.
+func (t *StrTmpl) Refs() []Ref {
+	return collectRefs(t.exprs)
 }
@@ -4,26 +4,40 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 
+	"github.com/valyala/fasttemplate"
 	"github.com/wolfeidau/jsontemplate"
 )
 
 type Tmpl struct {
-	jt *jsontemplate.Template
+	ft    *fasttemplate.Template
+	exprs map[string]*expression
 }
 
 // New creates a new Tmpl instance from a raw JSON template string.
 // It takes tmplRaw of type string, which is the raw JSON template.
 // It returns a pointer to Tmpl and an error.
-// It returns an error if the template parsing fails.
+// It returns an error if the template is not valid JSON or any of its placeholders is invalid.
+// Placeholders are JSON strings of the form "${expression}" that are replaced with JSON values of the expressions.
 func New(tmplRaw string) (*Tmpl, error) {
-	tmpl, err := jsontemplate.NewTemplate(tmplRaw)
+	if ok, err := jsontemplate.Valid([]byte(tmplRaw)); !ok {
+		return nil, fmt.Errorf("failed to parse request template: validation failed: %w", err)
+	}
+
+	ft, err := fasttemplate.NewTemplate(tmplRaw, `"${`, `}"`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	exprs, err := compileTags(ft, jsonTag)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse request template: %w", err)
 	}
 
 	return &Tmpl{
-		jt: tmpl,
+		ft:    ft,
+		exprs: exprs,
 	}, nil
 }
 
@@ -52,9 +66,29 @@ func (t *Tmpl) Execute(params any) ([]byte, error) {
 		return nil, fmt.Errorf("failed to marshal request template: %w", err)
 	}
 
-	if _, err := t.jt.Execute(buf, jData); err != nil {
+	doc := newDocument(jData)
+
+	_, err = t.ft.ExecuteFunc(buf, func(w io.Writer, tag string) (int, error) {
+		v, err := t.exprs[tag].eval(doc)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read field: %w", err)
+		}
+
+		data, err := encodeJSON(v)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode result: %w", err)
+		}
+
+		return w.Write(data)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to execute template: %w", err)
 	}
 
 	return buf.Bytes(), nil
 }
+
+// Refs returns references of placeholders of the template to the template data, sorted by path.
+func (t *Tmpl) Refs() []Ref {
+	return collectRefs(t.exprs)
+}
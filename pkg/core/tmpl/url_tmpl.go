@@ -1,30 +1,33 @@
 package tmpl
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/url"
 
 	"github.com/valyala/fasttemplate"
-	"github.com/wolfeidau/jsontemplate"
 )
 
 type URLTmpl struct {
-	tmpl *fasttemplate.Template
+	tmpl  *fasttemplate.Template
+	exprs map[string]*expression
 }
 
 // NewURLTmpl creates a new URLTmpl instance by parsing the provided template string.
 // It takes tmpl of type string, which represents the template to be parsed.
 // It returns a pointer to a URLTmpl and an error.
-// It returns an error if the template parsing fails.
+// It returns an error if the template parsing fails or any of its placeholders is invalid.
 func NewURLTmpl(tmpl string) (*URLTmpl, error) {
 	t, err := fasttemplate.NewTemplate(tmpl, "${", "}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse request template: %w", err)
 	}
 
-	return &URLTmpl{tmpl: t}, nil
+	exprs, err := compileTags(t, rawTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	return &URLTmpl{tmpl: t, exprs: exprs}, nil
 }
 
 // MustNewURLTmpl creates a new URLTmpl from the given raw template string.
@@ -46,30 +49,10 @@ func MustNewURLTmpl(tmplRaw string) *URLTmpl {
 // It returns an error if the parameters cannot be marshaled into JSON, if the template execution fails,
 // or if the template path does not resolve to a string value.
 func (t *URLTmpl) Execute(params any) (string, error) {
-	jData, err := json.Marshal(params)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request template: %w", err)
-	}
-
-	doc := jsontemplate.NewDocument(jData)
-
-	str, err := t.tmpl.ExecuteFuncStringWithErr(func(w io.Writer, path string) (int, error) {
-		v, err := doc.Read(path + ";escape")
-		if err != nil {
-			return 0, err
-		}
-
-		if str, ok := v.(string); ok {
-			escapedStr := url.QueryEscape(str)
-
-			return w.Write([]byte(escapedStr))
-		}
-
-		return 0, fmt.Errorf("expected string, got %T", v)
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
-	}
+	return executeStr(t.tmpl, t.exprs, params, url.QueryEscape)
+}
 
-	return str, nil
+// Refs returns references of placeholders of the template to the template data, sorted by path.
+func (t *URLTmpl) Refs() []Ref {
+	return collectRefs(t.exprs)
 }
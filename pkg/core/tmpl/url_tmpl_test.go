@@ -1,6 +1,7 @@
 package tmpl
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,11 +52,23 @@ func TestURLTmpl_Execute(t *testing.T) {
 			want:   "http://example.com/value",
 		},
 		{
-			name:   "object parameter",
-			tmpl:   "http://example.com/${param}",
+			name:    "object parameter",
+			tmpl:    "http://example.com/${param}",
+			params:  map[string]any{"param": map[string]string{"key": "value"}},
+			wantErr: true,
+		},
+		{
+			name:   "object parameter converted to string",
+			tmpl:   "http://example.com/${param | to_string}",
 			params: map[string]any{"param": map[string]string{"key": "value"}},
 			want:   "http://example.com/%7B%22key%22%3A%22value%22%7D",
 		},
+		{
+			name:   "number and boolean parameters",
+			tmpl:   "http://example.com/?limit=${limit}&id=${id}&price=${price}&active=${active}",
+			params: map[string]any{"limit": 10, "id": json.RawMessage(`12345678901234567890`), "price": json.RawMessage(`1.5e3`), "active": true},
+			want:   "http://example.com/?limit=10&id=12345678901234567890&price=1500&active=true",
+		},
 		{
 			name:   "null parameter",
 			tmpl:   "http://example.com/${param}",
@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// applyDefaults fills in default values of missing properties of the value and its nested objects and arrays.
+// It takes s of type *jsonschema.Schema, which is the schema of the value, and v of type any, which is the decoded value.
+// It returns true if any default value was applied.
+// Defaults of oneOf and anyOf alternatives are applied only if the value matches exactly one of them.
+func applyDefaults(s *jsonschema.Schema, v any) bool {
+	if s == nil {
+		return false
+	}
+
+	applied := false
+
+	if s.Ref != nil {
+		applied = applyDefaults(s.Ref, v) || applied
+	}
+
+	for _, sub := range s.AllOf {
+		applied = applyDefaults(sub, v) || applied
+	}
+
+	if alt := matchingAlt(append(append([]*jsonschema.Schema{}, s.OneOf...), s.AnyOf...), v); alt != nil {
+		applied = applyDefaults(alt, v) || applied
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for name, prop := range s.Properties {
+			if field, ok := val[name]; ok {
+				applied = applyDefaults(prop, field) || applied
+				continue
+			}
+
+			if def, ok := defaultValue(prop); ok {
+				val[name] = clone(def)
+				applied = true
+			}
+		}
+	case []any:
+		items, _ := s.Items.(*jsonschema.Schema)
+		if s.Items2020 != nil {
+			items = s.Items2020
+		}
+
+		for _, item := range val {
+			applied = applyDefaults(items, item) || applied
+		}
+	}
+
+	return applied
+}
+
+// matchingAlt returns the only alternative that the value matches, or nil if there are none or several of them.
+func matchingAlt(alts []*jsonschema.Schema, v any) *jsonschema.Schema {
+	var match *jsonschema.Schema
+
+	for _, alt := range alts {
+		if alt.Validate(v) != nil {
+			continue
+		}
+
+		if match != nil {
+			return nil
+		}
+
+		match = alt
+	}
+
+	return match
+}
+
+// defaultValue returns the default value of the schema, following references.
+func defaultValue(s *jsonschema.Schema) (any, bool) {
+	for ; s != nil; s = s.Ref {
+		if s.Default != nil {
+			return s.Default, true
+		}
+	}
+
+	return nil, false
+}
+
+// clone returns a deep copy of the decoded JSON value, so defaults of the schema are not modified by later changes of params.
+func clone(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(val))
+
+		for key, item := range val {
+			c[key] = clone(item)
+		}
+
+		return c
+	case []any:
+		c := make([]any, len(val))
+
+		for i, item := range val {
+			c[i] = clone(item)
+		}
+
+		return c
+	default:
+		return v
+	}
+}
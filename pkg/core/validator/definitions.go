@@ -0,0 +1,165 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	// definitionsURL is the URL of the document with shared schemas.
+	definitionsURL = "bff:///definitions.json"
+	// definitionsRef is the prefix of references of params schemas to shared schemas.
+	definitionsRef = "#/definitions/"
+)
+
+// Definitions are shared schemas that schemas of params reference with $ref, e.g. {"$ref": "#/definitions/currency"}.
+// Shared schemas are compiled once, and schemas of params of all API calls are compiled by the same compiler.
+type Definitions struct {
+	compiler *jsonschema.Compiler
+	schemas  map[string]any
+	names    []string
+	seq      int
+	mu       sync.Mutex
+}
+
+// NewDefinitions compiles the shared schemas.
+// It takes defs of type map[string]any, which maps names of shared schemas to their JSON schemas.
+// It returns a pointer to Definitions and an error if any of the schemas is invalid.
+// Shared schemas can reference each other with $ref, e.g. {"$ref": "#/definitions/date"}.
+// Schemas can't be loaded from files or URLs, so only references to shared schemas and within the same schema are resolved.
+// Values are checked against bundled formats of the `format` keyword and formats registered with RegisterFormat.
+// Standard formats, like `date` and `email`, are checked only if enabled with AssertStandardFormats.
+func NewDefinitions(defs map[string]any) (*Definitions, error) {
+	doc := make(map[string]any, 1)
+	doc["definitions"] = defs
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal definitions: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.ExtractAnnotations = true
+	compiler.AssertFormat = true
+
+	maps.Copy(compiler.Formats, registeredFormats())
+
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("schema %s can't be loaded, only shared definitions can be referenced", url)
+	}
+
+	if err := compiler.AddResource(definitionsURL, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to parse definitions: %w", err)
+	}
+
+	d := &Definitions{
+		compiler: compiler,
+		schemas:  make(map[string]any, len(defs)),
+		names:    make([]string, 0, len(defs)),
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse definitions: %w", err)
+	}
+
+	if schemas, ok := doc["definitions"].(map[string]any); ok {
+		d.schemas = schemas
+	}
+
+	for name := range d.schemas {
+		d.names = append(d.names, name)
+	}
+
+	slices.Sort(d.names)
+
+	for _, name := range d.names {
+		if _, err := compiler.Compile(definitionsURL + definitionsRef + escapePointer(name)); err != nil {
+			return nil, fmt.Errorf("invalid definition %s: %w", name, err)
+		}
+	}
+
+	return d, nil
+}
+
+// compile compiles the schema of params, where references to shared schemas are resolved.
+// It takes schema of type map[string]any, which is the schema of params.
+// It returns the compiled schema and an error if the schema is invalid.
+// Shared schemas are added to the definitions of the schema, unless the schema has own definitions with the same names.
+func (d *Definitions) compile(schema map[string]any) (*jsonschema.Schema, error) {
+	own, _ := schema["definitions"].(map[string]any)
+	defs := make(map[string]any, len(d.names)+len(own))
+
+	for _, name := range d.names {
+		defs[name] = map[string]any{"$ref": definitionsURL + definitionsRef + escapePointer(name)}
+	}
+
+	for name, def := range own {
+		defs[name] = def
+	}
+
+	if len(defs) > 0 {
+		schema["definitions"] = defs
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.seq++
+	url := fmt.Sprintf("bff:///params/%d.json", d.seq)
+
+	if err := d.compiler.AddResource(url, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	s, err := d.compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	return s, nil
+}
+
+// hasDefault checks if the schema, or the shared schema it references, has a default value.
+// It takes s of type any, which is a decoded JSON schema.
+// It returns true if the schema has a default value, otherwise false.
+func (d *Definitions) hasDefault(s any) bool {
+	for range len(d.names) + 1 {
+		m, ok := s.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		if _, ok := m["default"]; ok {
+			return true
+		}
+
+		ref, _ := m["$ref"].(string)
+
+		name, ok := strings.CutPrefix(ref, definitionsRef)
+		if !ok {
+			return false
+		}
+
+		s = d.schemas[strings.NewReplacer("~1", "/", "~0", "~").Replace(name)]
+	}
+
+	return false
+}
+
+// escapePointer escapes the name to be used as a token of a JSON pointer.
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
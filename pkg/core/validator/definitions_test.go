@@ -0,0 +1,110 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDefinitions(t *testing.T) {
+	tests := []struct {
+		defs    map[string]any
+		name    string
+		wantErr string
+	}{
+		{name: "nil", defs: nil},
+		{
+			name: "valid",
+			defs: map[string]any{
+				"currency":   map[string]any{"type": "string", "enum": []string{"USD", "EUR"}},
+				"date":       map[string]any{"type": "string", "format": "date"},
+				"date_range": map[string]any{"type": "object", "properties": map[string]any{"from": map[string]any{"$ref": "#/definitions/date"}}},
+			},
+		},
+		{
+			name:    "invalid schema",
+			defs:    map[string]any{"currency": map[string]any{"type": "text"}},
+			wantErr: "invalid definition currency",
+		},
+		{
+			name:    "unknown reference",
+			defs:    map[string]any{"range": map[string]any{"$ref": "#/definitions/date"}},
+			wantErr: "invalid definition range",
+		},
+		{
+			name:    "external reference",
+			defs:    map[string]any{"range": map[string]any{"$ref": "/etc/schema.json"}},
+			wantErr: "only shared definitions can be referenced",
+		},
+		{
+			name:    "fail to marshal",
+			defs:    map[string]any{"currency": make(chan int)},
+			wantErr: "failed to marshal definitions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs, err := NewDefinitions(tt.defs)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, defs)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, defs)
+		})
+	}
+}
+
+func TestNew_WithDefinitions(t *testing.T) {
+	defs, err := NewDefinitions(map[string]any{
+		"currency": map[string]any{"type": "string", "enum": []string{"USD", "EUR"}, "default": "USD"},
+		"range": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"from": map[string]any{"type": "integer"}, "to": map[string]any{"type": "integer"}},
+		},
+	})
+	require.NoError(t, err)
+
+	balance, err := New(&Config{
+		"loginid":  map[string]any{"type": "string"},
+		"currency": map[string]any{"$ref": "#/definitions/currency"},
+	}, WithDefinitions(defs))
+	require.NoError(t, err)
+
+	statement, err := New(&Config{
+		"type":       "object",
+		"properties": map[string]any{"period": map[string]any{"$ref": "#/definitions/range"}},
+	}, WithDefinitions(defs))
+	require.NoError(t, err)
+
+	got, err := balance.Validate(context.Background(), []byte(`{"loginid":"CR1"}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"loginid":"CR1","currency":"USD"}`, string(got))
+
+	_, err = balance.Validate(context.Background(), []byte(`{"loginid":"CR1","currency":"GBP"}`))
+
+	var apiErr *core.APIError
+
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, string(apiErr.Details), "params/currency")
+
+	_, err = statement.Validate(context.Background(), []byte(`{"period":{"from":"yesterday"}}`))
+	assert.Error(t, err)
+
+	assert.NoError(t, statement.CheckPath([]string{"period", "from"}))
+	assert.EqualError(t, statement.CheckPath([]string{"period", "from", "day"}), "params.period.from is integer and has no field day")
+	assert.Equal(t, []string{"string"}, balance.Types([]string{"currency"}))
+
+	_, err = New(&Config{"currency": map[string]any{"$ref": "#/definitions/unknown"}}, WithDefinitions(defs))
+	assert.ErrorContains(t, err, "failed to compile schema")
+
+	_, err = New(&Config{"currency": map[string]any{"$ref": "#/definitions/currency"}})
+	assert.ErrorContains(t, err, "failed to compile schema")
+}
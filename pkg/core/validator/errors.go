@@ -12,8 +12,9 @@ type ValidationError struct {
 }
 
 type fieldError struct {
-	err   error
-	field string
+	err     error
+	field   string
+	keyword string
 }
 
 // NewValidationError creates and returns a new instance of ValidationError.
@@ -45,6 +46,12 @@ func (e *ValidationError) AddError(field string, err error) {
 	e.errors = append(e.errors, fieldError{field: field, err: err})
 }
 
+// addViolation adds a violation of the schema keyword to the ValidationError for a specific field.
+// It takes field of type string, keyword of type string which is used to localize the message, and err of type error.
+func (e *ValidationError) addViolation(field, keyword string, err error) {
+	e.errors = append(e.errors, fieldError{field: field, keyword: keyword, err: err})
+}
+
 // HasErrors checks if there are any validation errors present.
 // It returns true if there is at least one error, otherwise false.
 func (e *ValidationError) HasErrors() bool {
@@ -55,15 +62,34 @@ func (e *ValidationError) HasErrors() bool {
 // It takes no parameters.
 // It returns an error of type core.APIError with details about the validation errors.
 func (e *ValidationError) APIError() error {
+	return e.LocalizedAPIError("")
+}
+
+// LocalizedAPIError converts a ValidationError into a core.APIError with messages in the language.
+// It takes lang of type string, which is the language of the client, e.g. "es".
+// It returns an error of type core.APIError with details about the validation errors.
+// Messages are kept in English if there is no catalog for the language, and the code of the error is the same for all languages.
+func (e *ValidationError) LocalizedAPIError(lang string) error {
+	catalog, localized := catalogFor(lang)
+
+	message := "Input validation failed"
+	if localized && catalog.Message != "" {
+		message = catalog.Message
+	}
+
 	details := make(map[string]string, len(e.errors))
 
 	for _, fieldErr := range e.errors {
 		details[fieldErr.field] = fieldErr.err.Error()
+
+		if localized && fieldErr.keyword != "" {
+			details[fieldErr.field] = catalog.localize(fieldErr.keyword, details[fieldErr.field])
+		}
 	}
 
 	detailsData, err := json.Marshal(details)
 	if err == nil {
-		return core.NewAPIError("InputValidationFailed", "Input validation failed", detailsData)
+		return core.NewAPIError("InputValidationFailed", message, detailsData)
 	}
 
 	return fmt.Errorf("failed to marshal APIError details: %w", err)
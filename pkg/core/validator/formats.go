@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"maps"
+	"regexp"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var (
+	formatsMu       sync.RWMutex
+	standardFormats bool
+	formats         = map[string]func(v any) bool{
+		"loginid":  matchString(regexp.MustCompile(`^[A-Z]{2,4}[0-9]{1,10}$`)),
+		"currency": matchString(regexp.MustCompile(`^[a-z]?[A-Z]{3,10}$`)),
+		"symbol":   matchString(regexp.MustCompile(`^[A-Za-z0-9_]{1,30}$`)),
+	}
+)
+
+// RegisterFormat registers a checker of the `format` keyword of schemas of params and shared definitions.
+// It takes name of type string, which is the name of the format, and check, which reports whether the value has the format.
+// Values of other types than the format applies to should be reported as valid, e.g. numbers for string formats.
+// Formats are applied to schemas compiled after the registration, so they should be registered before the configuration is loaded.
+// Registering a format with the name of a bundled or a standard format replaces it.
+func RegisterFormat(name string, check func(v any) bool) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	formats[name] = check
+}
+
+// AssertStandardFormats enables checks of standard formats of the `format` keyword, like `date`, `email` and `uri`.
+// It takes enabled of type bool.
+// Standard formats are annotations by default, so existing schemas don't start rejecting values that don't match them.
+// Like RegisterFormat, it applies to schemas compiled after the call, so it should be called before the configuration is loaded.
+func AssertStandardFormats(enabled bool) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	standardFormats = enabled
+}
+
+// registeredFormats returns a copy of format checkers that are applied to schemas.
+// Unless standard formats are asserted, they are replaced with checkers that accept any value,
+// so only bundled and registered formats are checked.
+func registeredFormats() map[string]func(v any) bool {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	checkers := make(map[string]func(v any) bool, len(jsonschema.Formats)+len(formats))
+
+	if !standardFormats {
+		for name := range jsonschema.Formats {
+			checkers[name] = anyValue
+		}
+	}
+
+	maps.Copy(checkers, formats)
+
+	return checkers
+}
+
+// anyValue is a format checker that accepts any value.
+func anyValue(any) bool {
+	return true
+}
+
+// matchString returns a format checker that matches strings with the regular expression.
+func matchString(re *regexp.Regexp) func(v any) bool {
+	return func(v any) bool {
+		s, ok := v.(string)
+
+		return !ok || re.MatchString(s)
+	}
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldValidator_Validate_Formats(t *testing.T) {
+	RegisterFormat("test_even_length", func(v any) bool {
+		s, ok := v.(string)
+		return !ok || len(s)%2 == 0
+	})
+
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		wantErr string
+	}{
+		{name: "Valid loginid", format: "loginid", data: `{"value":"CR90000000"}`},
+		{name: "Invalid loginid", format: "loginid", data: `{"value":"cr9"}`, wantErr: `'cr9' is not valid 'loginid'`},
+		{name: "Valid currency", format: "currency", data: `{"value":"USD"}`},
+		{name: "Valid crypto currency", format: "currency", data: `{"value":"eUSDT"}`},
+		{name: "Invalid currency", format: "currency", data: `{"value":"US"}`, wantErr: `'US' is not valid 'currency'`},
+		{name: "Valid symbol", format: "symbol", data: `{"value":"R_100"}`},
+		{name: "Invalid symbol", format: "symbol", data: `{"value":"R 100"}`, wantErr: `'R 100' is not valid 'symbol'`},
+		{name: "Standard format", format: "date", data: `{"value":"yesterday"}`},
+		{name: "Valid custom format", format: "test_even_length", data: `{"value":"ab"}`},
+		{name: "Invalid custom format", format: "test_even_length", data: `{"value":"abc"}`, wantErr: `'abc' is not valid 'test_even_length'`},
+		{name: "Unknown format", format: "test_unknown", data: `{"value":"anything"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(&Config{"value": map[string]any{"type": "string", "format": tt.format}})
+			require.NoError(t, err)
+
+			_, err = v.Validate(context.Background(), []byte(tt.data))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var apiErr *core.APIError
+
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "InputValidationFailed", apiErr.Code)
+			assert.Contains(t, string(apiErr.Details), tt.wantErr)
+		})
+	}
+}
+
+func TestAssertStandardFormats(t *testing.T) {
+	t.Cleanup(func() { AssertStandardFormats(false) })
+
+	cfg := &Config{
+		"date":     map[string]any{"type": "string", "format": "date"},
+		"email":    map[string]any{"type": "string", "format": "email"},
+		"currency": map[string]any{"type": "string", "format": "currency"},
+	}
+
+	v, err := New(cfg)
+	require.NoError(t, err)
+
+	_, err = v.Validate(context.Background(), []byte(`{"date":"yesterday","email":"nobody","currency":"USD"}`))
+	assert.NoError(t, err, "standard formats are not asserted by default")
+
+	_, err = v.Validate(context.Background(), []byte(`{"date":"2024-01-01","email":"nobody@example.com","currency":"US"}`))
+	assert.Error(t, err, "bundled formats are asserted by default")
+
+	AssertStandardFormats(true)
+
+	v, err = New(cfg)
+	require.NoError(t, err)
+
+	_, err = v.Validate(context.Background(), []byte(`{"date":"yesterday","email":"nobody@example.com","currency":"USD"}`))
+
+	var apiErr *core.APIError
+
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, string(apiErr.Details), `'yesterday' is not valid 'date'`)
+
+	_, err = v.Validate(context.Background(), []byte(`{"date":"2024-01-01","email":"nobody@example.com","currency":"USD"}`))
+	assert.NoError(t, err)
+}
+
+func TestMatchString_NonString(t *testing.T) {
+	formats := registeredFormats()
+
+	for _, name := range []string{"loginid", "currency", "symbol"} {
+		assert.True(t, formats[name](42), name)
+	}
+}
@@ -0,0 +1,177 @@
+package validator
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed locales
+var localesFS embed.FS
+
+// Catalog is a message catalog of validation errors in a language.
+// Message is the message of the API error, and Keywords are templates of messages of fields keyed by the failed schema keyword.
+// Templates refer to values of the original message by their position, e.g. `{0}` for the limit of `minLength`.
+type Catalog struct {
+	Keywords map[string]string `json:"keywords"`
+	Message  string            `json:"message"`
+}
+
+var (
+	catalogsOnce sync.Once
+	catalogsMu   sync.RWMutex
+	catalogs     map[string]Catalog
+)
+
+// argRef matches references to values of the original message in templates of catalogs.
+var argRef = regexp.MustCompile(`\{\d+\}`)
+
+// messageArgs extracts values of messages of the schema validator which are available to templates of catalogs, keyed by the keyword.
+var messageArgs = map[string]*regexp.Regexp{
+	"type":                 regexp.MustCompile(`^expected (.+), but got (.+)$`),
+	"required":             regexp.MustCompile(`^missing properties: (.+)$`),
+	"additionalProperties": regexp.MustCompile(`^additionalProperties (.+) not allowed$`),
+	"enum":                 regexp.MustCompile(`^value must be (?:one of )?(.+)$`),
+	"const":                regexp.MustCompile(`^value must be (.+)$`),
+	"format":               regexp.MustCompile(`^(.+) is not valid (.+)$`),
+	"pattern":              regexp.MustCompile(`^does not match pattern (.+)$`),
+	"minLength":            regexp.MustCompile(`^length must be >= (\S+), but got (\S+)$`),
+	"maxLength":            regexp.MustCompile(`^length must be <= (\S+), but got (\S+)$`),
+	"minimum":              regexp.MustCompile(`^must be >= (\S+) but found (\S+)$`),
+	"maximum":              regexp.MustCompile(`^must be <= (\S+) but found (\S+)$`),
+	"exclusiveMinimum":     regexp.MustCompile(`^must be > (\S+) but found (\S+)$`),
+	"exclusiveMaximum":     regexp.MustCompile(`^must be < (\S+) but found (\S+)$`),
+	"multipleOf":           regexp.MustCompile(`^(\S+) not multipleOf (\S+)$`),
+	"minItems":             regexp.MustCompile(`^minimum (\d+) items required, but found (\d+) items$`),
+	"maxItems":             regexp.MustCompile(`^maximum (\d+) items required, but found (\d+) items$`),
+	"uniqueItems":          regexp.MustCompile(`^items at index (\d+) and (\d+) are equal$`),
+}
+
+// RegisterCatalog registers a message catalog of validation errors for the language.
+// It takes lang of type string, which is the language code as sent by clients in the `l` query parameter, e.g. "es" or "pt_BR", and catalog of type Catalog.
+// Messages of the catalog replace messages of the bundled or previously registered catalog of the language, and other messages are kept.
+func RegisterCatalog(lang string, catalog Catalog) {
+	loadCatalogs()
+
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	lang = strings.ToLower(lang)
+	c := catalogs[lang]
+
+	if catalog.Message != "" {
+		c.Message = catalog.Message
+	}
+
+	keywords := make(map[string]string, len(c.Keywords)+len(catalog.Keywords))
+
+	for k, v := range c.Keywords {
+		keywords[k] = v
+	}
+
+	for k, v := range catalog.Keywords {
+		keywords[k] = v
+	}
+
+	c.Keywords = keywords
+	catalogs[lang] = c
+}
+
+// loadCatalogs loads catalogs embedded into the binary once.
+// It panics if bundled catalogs can't be loaded, which indicates a bug in the bundled catalogs.
+func loadCatalogs() {
+	catalogsOnce.Do(func() {
+		loaded := make(map[string]Catalog)
+
+		err := fs.WalkDir(localesFS, "locales", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			data, err := localesFS.ReadFile(name)
+			if err != nil {
+				return err
+			}
+
+			var c Catalog
+			if err := json.Unmarshal(data, &c); err != nil {
+				return fmt.Errorf("invalid catalog %s: %w", name, err)
+			}
+
+			loaded[strings.TrimSuffix(path.Base(name), path.Ext(name))] = c
+
+			return nil
+		})
+		if err != nil {
+			panic(fmt.Sprintf("failed to load bundled catalogs: %v", err))
+		}
+
+		catalogs = loaded
+	})
+}
+
+// catalogFor returns the message catalog of the language.
+// It takes lang of type string, which is matched case-insensitively, and falls back to the base language, e.g. "pt" for "pt_BR".
+// It returns the catalog and false if there is no catalog for the language, in which case messages are kept in English.
+func catalogFor(lang string) (Catalog, bool) {
+	if lang == "" {
+		return Catalog{}, false
+	}
+
+	loadCatalogs()
+
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	lang = strings.ToLower(lang)
+	if c, ok := catalogs[lang]; ok {
+		return c, true
+	}
+
+	if base, _, ok := strings.Cut(strings.ReplaceAll(lang, "-", "_"), "_"); ok {
+		c, ok := catalogs[base]
+		return c, ok
+	}
+
+	return Catalog{}, false
+}
+
+// localize translates the message of the schema validator with the catalog.
+// It takes keyword of type string, which is the failed schema keyword, and msg of type string, which is the original message.
+// It returns the translated message, or the original message if the catalog has no template for the keyword
+// or the template refers to values the message doesn't have.
+func (c Catalog) localize(keyword, msg string) string {
+	tmpl, ok := c.Keywords[keyword]
+	if !ok {
+		return msg
+	}
+
+	var args []string
+
+	if re, ok := messageArgs[keyword]; ok {
+		m := re.FindStringSubmatch(msg)
+		if m == nil {
+			return msg
+		}
+
+		args = m[1:]
+	}
+
+	localized := tmpl
+
+	for i, arg := range args {
+		localized = strings.ReplaceAll(localized, "{"+strconv.Itoa(i)+"}", arg)
+	}
+
+	if argRef.MatchString(localized) {
+		return msg
+	}
+
+	return localized
+}
@@ -0,0 +1,120 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/wasabi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldValidator_Validate_Localized(t *testing.T) {
+	v, err := New(&Config{
+		"name":  map[string]any{"type": "string", "minLength": 3},
+		"count": map[string]any{"type": "integer", "maximum": 5},
+		"kind":  map[string]any{"type": "string", "format": "symbol"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		want        map[string]string
+		name        string
+		lang        string
+		wantMessage string
+	}{
+		{
+			name:        "Spanish",
+			lang:        "ES",
+			wantMessage: "La validación de los datos de entrada ha fallado",
+			want: map[string]string{
+				"params/name":  "la longitud debe ser >= 3, pero es 1",
+				"params/count": "debe ser <= 5, pero es 7",
+				"params/kind":  "'R 100' no tiene un formato 'symbol' válido",
+			},
+		},
+		{
+			name:        "Base language of regional variant",
+			lang:        "ru_RU",
+			wantMessage: "Ошибка проверки входных данных",
+			want: map[string]string{
+				"params/name":  "длина должна быть >= 3, получено 1",
+				"params/count": "должно быть <= 5, получено 7",
+				"params/kind":  "'R 100' не соответствует формату 'symbol'",
+			},
+		},
+		{
+			name:        "Unknown language",
+			lang:        "xx",
+			wantMessage: "Input validation failed",
+			want: map[string]string{
+				"params/name":  "length must be >= 3, but got 1",
+				"params/count": "must be <= 5 but found 7",
+				"params/kind":  "'R 100' is not valid 'symbol'",
+			},
+		},
+		{
+			name:        "No language",
+			wantMessage: "Input validation failed",
+			want: map[string]string{
+				"params/name":  "length must be >= 3, but got 1",
+				"params/count": "must be <= 5 but found 7",
+				"params/kind":  "'R 100' is not valid 'symbol'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConn := mocks.NewMockConnection(t)
+			mockConn.EXPECT().ID().Return("conn-1")
+			mockConn.EXPECT().Context().Return(core.ContextWithConnInfo(context.Background(), &core.ConnInfo{Lang: tt.lang}))
+
+			ctx := core.ContextWithConn(context.Background(), core.NewConnection(mockConn, func(string) {}))
+
+			_, err := v.Validate(ctx, []byte(`{"name":"a","count":7,"kind":"R 100"}`))
+
+			var apiErr *core.APIError
+
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "InputValidationFailed", apiErr.Code)
+			assert.Equal(t, tt.wantMessage, apiErr.Message)
+
+			var details map[string]string
+
+			require.NoError(t, json.Unmarshal(apiErr.Details, &details))
+			assert.Equal(t, tt.want, details)
+		})
+	}
+}
+
+func TestRegisterCatalog(t *testing.T) {
+	RegisterCatalog("test_de", Catalog{
+		Message:  "Eingabevalidierung fehlgeschlagen",
+		Keywords: map[string]string{"required": "fehlende Eigenschaften: {0}"},
+	})
+	RegisterCatalog("TEST_DE", Catalog{
+		Keywords: map[string]string{"maximum": "muss <= {0} sein, ist aber {1}"},
+	})
+
+	e := NewValidationError()
+	e.addViolation("params", "required", assert.AnError)
+	e.addViolation("params/count", "maximum", errors.New("must be <= 5 but found 7"))
+	e.addViolation("params/name", "required", errors.New("missing properties: 'name'"))
+	e.addViolation("params/kind", "minLength", errors.New("length must be >= 3, but got 1"))
+
+	var apiErr *core.APIError
+
+	require.ErrorAs(t, e.LocalizedAPIError("test_DE"), &apiErr)
+	assert.Equal(t, "InputValidationFailed", apiErr.Code)
+	assert.Equal(t, "Eingabevalidierung fehlgeschlagen", apiErr.Message)
+	assert.JSONEq(t, `{
+		"params": "assert.AnError general error for testing",
+		"params/count": "muss <= 5 sein, ist aber 7",
+		"params/name": "fehlende Eigenschaften: 'name'",
+		"params/kind": "length must be >= 3, but got 1"
+	}`, string(apiErr.Details))
+}
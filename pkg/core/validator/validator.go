@@ -1,15 +1,26 @@
 package validator
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 type Config map[string]any
 
+// combinators are keywords of schemas that declare properties in subschemas,
+// so undeclared properties of such schemas are allowed by default.
+var combinators = []string{"allOf", "oneOf", "anyOf", "$ref"}
+
 type schemaValidator interface {
 	Validate(data any) error
 }
@@ -20,56 +31,136 @@ type FieldSchema struct {
 
 type FieldValidator struct {
 	jsonSchema schemaValidator
+	schema     *jsonschema.Schema
+}
+
+type options struct {
+	definitions *Definitions
+}
+
+type Option func(*options)
+
+// WithDefinitions sets shared schemas that the schema of params can reference with $ref, e.g. {"$ref": "#/definitions/currency"}.
+// It takes defs of type *Definitions and returns an Option.
+func WithDefinitions(defs *Definitions) Option {
+	return func(o *options) {
+		o.definitions = defs
+	}
 }
 
 // New creates a new FieldValidator based on the provided configuration.
-// It takes cfg of type Config, which maps field names to their configurations.
+// It takes cfg of type Config, which is either a JSON schema of type object, or a map of param names to their schemas.
 // It returns a pointer to a FieldValidator and an error.
-// It returns an error if any field in the configuration has an unknown type.
-func New(cfg *Config) (*FieldValidator, error) {
+// It returns an error if the configuration is not a valid JSON schema.
+// In the map format, params are required unless they have a default value, and undeclared params are not allowed.
+// In the schema format, undeclared params are not allowed unless the schema sets additionalProperties,
+// or declares params in allOf, oneOf, anyOf or $ref subschemas.
+// Shared schemas set by WithDefinitions can be referenced as #/definitions/NAME.
+func New(cfg *Config, opts ...Option) (*FieldValidator, error) {
 	if cfg == nil {
 		cfg = &Config{}
 	}
 
-	required := make([]string, 0, len(*cfg))
+	o := &options{}
 
-	for field := range *cfg {
-		required = append(required, field)
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	schema := map[string]any{
-		"type":                 "object",
-		"properties":           cfg,
-		"additionalProperties": false,
-		"required":             required,
+	if o.definitions == nil {
+		defs, err := NewDefinitions(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		o.definitions = defs
 	}
 
-	schemaJSON, err := json.Marshal(schema)
+	schema, err := paramsSchema(*cfg, o.definitions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+		return nil, err
 	}
 
-	val, err := jsonschema.CompileString("", string(schemaJSON))
+	val, err := o.definitions.compile(schema)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compile schema: %w", err)
+		return nil, err
 	}
 
 	return &FieldValidator{
 		jsonSchema: val,
+		schema:     val,
+	}, nil
+}
+
+// paramsSchema converts the configuration of params into a JSON schema of an object.
+// It takes cfg of type Config and defs of type *Definitions, which are shared schemas that params can reference.
+// It returns the schema and an error if the configuration can't be marshaled.
+// The configuration is a schema if its type is "object", as types of params in the map format are objects.
+func paramsSchema(cfg Config, defs *Definitions) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	var fields map[string]any
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	if fields == nil {
+		fields = make(map[string]any)
+	}
+
+	if t, ok := fields["type"].(string); ok && t == "object" {
+		_, open := fields["additionalProperties"]
+
+		for _, key := range combinators {
+			_, combined := fields[key]
+			open = open || combined
+		}
+
+		if !open {
+			fields["additionalProperties"] = false
+		}
+
+		return fields, nil
+	}
+
+	required := make([]string, 0, len(fields))
+
+	for field, fieldSchema := range fields {
+		if !defs.hasDefault(fieldSchema) {
+			required = append(required, field)
+		}
+	}
+
+	slices.Sort(required)
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           fields,
+		"additionalProperties": false,
+		"required":             required,
 	}, nil
 }
 
-// Validate checks the provided data against the field configurations in the FieldValidator.
-// It takes a single parameter data of type map[string]any which represents the data to be validated.
-// It returns an error if there are validation errors, including missing required fields or fields that are not allowed.
-// If the data contains fields not defined in the validator or if required fields are missing, it adds these errors to the validation error.
-func (v *FieldValidator) Validate(data []byte) error {
+// Validate checks the provided params against the schema and fills in default values of missing params.
+// It takes a single parameter data of type []byte which represents the params to be validated.
+// It returns the params with default values, and an error if there are validation errors,
+// including missing required fields or fields that are not allowed.
+// If no default value is applied, the params are returned as is.
+// Messages of validation errors are localized by the language of the client connection in the context.
+func (v *FieldValidator) Validate(ctx context.Context, data []byte) (json.RawMessage, error) {
 	var p map[string]any
 
 	if data != nil {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
 		// TODO: Is it possible to find library to validate JSON schema against byte slice?
-		if err := json.Unmarshal(data, &p); err != nil {
-			return fmt.Errorf("failed to unmarshal params: %w", err)
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal params: %w", err)
 		}
 	} else {
 		p = make(map[string]any)
@@ -84,20 +175,180 @@ func (v *FieldValidator) Validate(data []byte) error {
 		e := NewValidationError()
 
 		for _, v := range errValidation.Causes {
-			e.AddError(
+			e.addViolation(
 				fmt.Sprintf("params%s", v.InstanceLocation),
+				path.Base(v.KeywordLocation),
 				fmt.Errorf("%s", v.Message),
 			)
 		}
 
 		if e.HasErrors() {
-			return e.APIError()
+			return nil, e.LocalizedAPIError(connLang(ctx))
 		}
 
-		return err
+		return nil, err
 	case err != nil:
-		return fmt.Errorf("failed to validate data: %w", err)
+		return nil, fmt.Errorf("failed to validate data: %w", err)
+	}
+
+	if !applyDefaults(v.schema, p) {
+		return data, nil
+	}
+
+	withDefaults, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+
+	return withDefaults, nil
+}
+
+// Types returns JSON types allowed for the value at the given path of params.
+// It takes path of type []string, where the first segment is the name of the param and numeric segments address array items.
+// It returns the allowed types, or nil if the path is not declared or its type is not restricted.
+// Types of oneOf and anyOf alternatives are combined.
+func (v *FieldValidator) Types(path []string) []string {
+	return schemaTypes(v.schema, path)
+}
+
+// CheckPath checks that the path can exist in params that pass validation.
+// It takes path of type []string, where the first segment is the name of the param and numeric segments address array items.
+// It returns an error if the param is not declared, or the path addresses a field or an item of a value that can't have it.
+// Paths into values without a restricted schema are not checked.
+func (v *FieldValidator) CheckPath(path []string) error {
+	s := v.schema
+
+	for i, segment := range path {
+		if s = deref(s); s == nil {
+			return nil
+		}
+
+		name := strings.Join(append([]string{"params"}, path[:i]...), ".")
+		types := schemaTypes(s, nil)
+
+		if _, err := strconv.Atoi(segment); err == nil {
+			if types != nil && !slices.Contains(types, "array") {
+				return fmt.Errorf("%s is %s and has no item %s", name, strings.Join(types, " or "), segment)
+			}
+
+			items, _ := s.Items.(*jsonschema.Schema)
+			if s.Items2020 != nil {
+				items = s.Items2020
+			}
+
+			s = items
+
+			continue
+		}
+
+		if prop := property(s, segment); prop != nil {
+			s = prop
+			continue
+		}
+
+		switch {
+		case i == 0:
+			return fmt.Errorf("param %s is not declared", segment)
+		case types != nil && !slices.Contains(types, "object"):
+			return fmt.Errorf("%s is %s and has no field %s", name, strings.Join(types, " or "), segment)
+		case s.AdditionalProperties == false:
+			return fmt.Errorf("%s has no field %s", name, segment)
+		default:
+			return nil
+		}
 	}
 
 	return nil
 }
+
+// property returns the schema of the property of an object, which is declared by the schema or by one of its
+// allOf, oneOf and anyOf alternatives, or nil if the property is not declared.
+func property(s *jsonschema.Schema, name string) *jsonschema.Schema {
+	if s = deref(s); s == nil {
+		return nil
+	}
+
+	if prop, ok := s.Properties[name]; ok {
+		return prop
+	}
+
+	for _, alts := range [][]*jsonschema.Schema{s.AllOf, s.OneOf, s.AnyOf} {
+		for _, alt := range alts {
+			if prop := property(alt, name); prop != nil {
+				return prop
+			}
+		}
+	}
+
+	return nil
+}
+
+// deref returns the schema referenced by the schema if it consists of the reference only.
+func deref(s *jsonschema.Schema) *jsonschema.Schema {
+	for s != nil && s.Ref != nil && len(s.Types) == 0 && len(s.Properties) == 0 {
+		s = s.Ref
+	}
+
+	return s
+}
+
+// schemaTypes returns JSON types allowed by the schema at the given path.
+// It takes s of type *jsonschema.Schema and path of type []string.
+// It returns the allowed types, or nil if the path is not declared or its type is not restricted.
+func schemaTypes(s *jsonschema.Schema, path []string) []string {
+	if s == nil {
+		return nil
+	}
+
+	if s.Ref != nil && len(s.Types) == 0 {
+		return schemaTypes(s.Ref, path)
+	}
+
+	if len(path) > 0 {
+		if _, err := strconv.Atoi(path[0]); err == nil {
+			items, _ := s.Items.(*jsonschema.Schema)
+			if s.Items2020 != nil {
+				items = s.Items2020
+			}
+
+			return schemaTypes(items, path[1:])
+		}
+
+		return schemaTypes(property(s, path[0]), path[1:])
+	}
+
+	if len(s.Types) > 0 {
+		return s.Types
+	}
+
+	alts := append(slices.Clone(s.OneOf), s.AnyOf...)
+	if len(alts) == 0 {
+		return nil
+	}
+
+	types := make([]string, 0, len(alts))
+
+	for _, alt := range alts {
+		altTypes := schemaTypes(alt, nil)
+		if altTypes == nil {
+			return nil
+		}
+
+		for _, t := range altTypes {
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+	}
+
+	return types
+}
+
+// connLang returns the language of the client connection in the context, or an empty string if there is no connection.
+func connLang(ctx context.Context) string {
+	if conn := core.ConnFromContext(ctx); conn != nil {
+		return conn.Info().Lang
+	}
+
+	return ""
+}
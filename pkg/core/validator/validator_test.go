@@ -1,10 +1,13 @@
 package validator
 
 import (
+	"context"
 	"testing"
 
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewFieldValidator(t *testing.T) {
@@ -104,7 +107,7 @@ func TestFieldValidator_Validate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validator.Validate(tt.data)
+			_, err := validator.Validate(context.Background(), tt.data)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -112,6 +115,152 @@ func TestFieldValidator_Validate(t *testing.T) {
 	}
 }
 
+func TestFieldValidator_Validate_Defaults(t *testing.T) {
+	tests := []struct {
+		config  *Config
+		name    string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "Map format with default",
+			config: &Config{
+				"symbol": map[string]any{"type": "string"},
+				"count":  map[string]any{"type": "number", "default": 10},
+			},
+			data: `{"symbol":"R_50"}`,
+			want: `{"symbol":"R_50","count":10}`,
+		},
+		{
+			name: "Map format keeps provided value",
+			config: &Config{
+				"symbol": map[string]any{"type": "string"},
+				"count":  map[string]any{"type": "number", "default": 10},
+			},
+			data: `{"symbol":"R_50","count":5}`,
+			want: `{"symbol":"R_50","count":5}`,
+		},
+		{
+			name: "Map format requires params without default",
+			config: &Config{
+				"symbol": map[string]any{"type": "string"},
+				"count":  map[string]any{"type": "number", "default": 10},
+			},
+			data:    `{"count":5}`,
+			wantErr: "missing properties: 'symbol'",
+		},
+		{
+			name: "Schema format with optional params",
+			config: &Config{
+				"type":     "object",
+				"required": []string{"symbol"},
+				"properties": map[string]any{
+					"symbol": map[string]any{"type": "string", "enum": []string{"R_50", "R_100"}},
+					"style":  map[string]any{"type": "string", "default": "ticks"},
+					"note":   map[string]any{"type": "string"},
+				},
+			},
+			data: `{"symbol":"R_50"}`,
+			want: `{"symbol":"R_50","style":"ticks"}`,
+		},
+		{
+			name: "Schema format rejects undeclared params",
+			config: &Config{
+				"type":       "object",
+				"properties": map[string]any{"symbol": map[string]any{"type": "string"}},
+			},
+			data:    `{"symbol":"R_50","extra":1}`,
+			wantErr: "additionalProperties 'extra' not allowed",
+		},
+		{
+			name: "Schema format validates enum",
+			config: &Config{
+				"type":       "object",
+				"properties": map[string]any{"symbol": map[string]any{"type": "string", "enum": []string{"R_50"}}},
+			},
+			data:    `{"symbol":"R_10"}`,
+			wantErr: "params/symbol",
+		},
+		{
+			name: "Nested defaults",
+			config: &Config{
+				"type": "object",
+				"properties": map[string]any{
+					"filter": map[string]any{
+						"type":    "object",
+						"default": map[string]any{},
+						"properties": map[string]any{
+							"limit": map[string]any{"type": "integer", "default": 50},
+						},
+					},
+					"items": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"type":       "object",
+							"properties": map[string]any{"qty": map[string]any{"type": "integer", "default": 1}},
+						},
+					},
+				},
+			},
+			data: `{"items":[{"qty":2},{}]}`,
+			want: `{"filter":{},"items":[{"qty":2},{"qty":1}]}`,
+		},
+		{
+			name: "Defaults of the matching oneOf alternative",
+			config: &Config{
+				"type": "object",
+				"oneOf": []any{
+					map[string]any{
+						"required": []string{"id"},
+						"properties": map[string]any{
+							"id":     map[string]any{"type": "integer"},
+							"fields": map[string]any{"type": "string", "default": "all"},
+						},
+					},
+					map[string]any{
+						"required":   []string{"email"},
+						"properties": map[string]any{"email": map[string]any{"type": "string"}},
+					},
+				},
+			},
+			data: `{"id":12345678901234567890}`,
+			want: `{"id":12345678901234567890,"fields":"all"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(tt.config)
+			require.NoError(t, err)
+
+			got, err := v.Validate(context.Background(), []byte(tt.data))
+			if tt.wantErr != "" {
+				var apiErr *core.APIError
+
+				require.ErrorAs(t, err, &apiErr)
+				assert.Contains(t, string(apiErr.Details), tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestFieldValidator_Validate_NoDefaults(t *testing.T) {
+	v, err := New(&Config{"name": map[string]any{"type": "string"}})
+	require.NoError(t, err)
+
+	data := []byte(`{ "name": "John" }`)
+
+	got, err := v.Validate(context.Background(), data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, []byte(got))
+}
+
 func TestFieldValidator_Validate_ErrorHandling(t *testing.T) {
 	expectedValidationErrNoCases := &jsonschema.ValidationError{}
 
@@ -145,7 +294,7 @@ func TestFieldValidator_Validate_ErrorHandling(t *testing.T) {
 			}
 
 			mockSchema.EXPECT().Validate(map[string]any{}).Return(tt.err)
-			err := val.Validate([]byte(`{}`))
+			_, err := val.Validate(context.Background(), []byte(`{}`))
 
 			if tt.wantErr != nil {
 				assert.ErrorIs(t, err, tt.wantErr)
@@ -155,3 +304,113 @@ func TestFieldValidator_Validate_ErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldValidator_Types(t *testing.T) {
+	validator, err := New(&Config{
+		"name": &FieldSchema{Type: "string"},
+		"ids": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "integer"},
+		},
+		"filter": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"status": map[string]any{"type": []any{"string", "null"}},
+			},
+		},
+		"amount": map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": "number"},
+				map[string]any{"type": "string"},
+			},
+		},
+		"any": map[string]any{},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		path []string
+		want []string
+	}{
+		{name: "scalar", path: []string{"name"}, want: []string{"string"}},
+		{name: "array", path: []string{"ids"}, want: []string{"array"}},
+		{name: "array item", path: []string{"ids", "0"}, want: []string{"integer"}},
+		{name: "nested property", path: []string{"filter", "status"}, want: []string{"string", "null"}},
+		{name: "oneOf", path: []string{"amount"}, want: []string{"number", "string"}},
+		{name: "unrestricted", path: []string{"any"}, want: nil},
+		{name: "undeclared", path: []string{"unknown"}, want: nil},
+		{name: "undeclared nested", path: []string{"name", "first"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validator.Types(tt.path))
+		})
+	}
+}
+
+func TestFieldValidator_CheckPath(t *testing.T) {
+	validator, err := New(&Config{
+		"name": &FieldSchema{Type: "string"},
+		"ids": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "integer"},
+		},
+		"filter": map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"status": map[string]any{"type": "string"}},
+			"additionalProperties": false,
+		},
+		"extra": map[string]any{"type": "object"},
+		"any":   map[string]any{},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		wantErr string
+		path    []string
+	}{
+		{name: "param", path: []string{"name"}},
+		{name: "array item", path: []string{"ids", "0"}},
+		{name: "nested field", path: []string{"filter", "status"}},
+		{name: "open object", path: []string{"extra", "key", "0"}},
+		{name: "unrestricted", path: []string{"any", "a", "b"}},
+		{name: "undeclared", path: []string{"unknown"}, wantErr: "param unknown is not declared"},
+		{name: "field of string", path: []string{"name", "first"}, wantErr: "params.name is string and has no field first"},
+		{name: "item of object", path: []string{"filter", "0"}, wantErr: "params.filter is object and has no item 0"},
+		{name: "field of array item", path: []string{"ids", "0", "id"}, wantErr: "params.ids.0 is integer and has no field id"},
+		{name: "closed object", path: []string{"filter", "state"}, wantErr: "params.filter has no field state"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.CheckPath(tt.path)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFieldValidator_CheckPath_Alternatives(t *testing.T) {
+	validator, err := New(&Config{
+		"type": "object",
+		"oneOf": []any{
+			map[string]any{"properties": map[string]any{"id": map[string]any{"type": "integer"}}},
+			map[string]any{"properties": map[string]any{"email": map[string]any{"type": "string"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, validator.CheckPath([]string{"id"}))
+	assert.NoError(t, validator.CheckPath([]string{"email"}))
+	assert.EqualError(t, validator.CheckPath([]string{"email", "domain"}), "params.email is string and has no field domain")
+	assert.EqualError(t, validator.CheckPath([]string{"name"}), "param name is not declared")
+	assert.Equal(t, []string{"integer"}, validator.Types([]string{"id"}))
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	httpmid "github.com/ksysoev/wasabi/middleware/http"
+)
+
+// NewConnInfoMiddleware creates a middleware that captures data of the client connection for request templates.
+// It takes headers and query of type []string, which are allowlists of request headers and query parameters.
+// It returns a function that takes an http.Handler and returns an http.Handler.
+// Only allowlisted headers and query parameters are captured, keyed by their names in the allowlist,
+// and missing ones are captured as empty strings.
+// The language of the client is taken from the `l` query parameter regardless of the allowlist.
+// Headers, query parameters and the client IP are taken from the headers, query parameters and client IP middlewares,
+// so it should be applied after them.
+func NewConnInfoMiddleware(headers, query []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := &core.ConnInfo{
+				Headers: make(map[string]string, len(headers)),
+				Query:   make(map[string]string, len(query)),
+			}
+
+			reqHeaders := HeadersFromContext(r.Context())
+
+			for _, name := range headers {
+				info.Headers[name] = reqHeaders.Get(name)
+			}
+
+			params := QueryParamsFromContext(r.Context())
+			info.Lang = params.Get("l")
+
+			for _, name := range query {
+				info.Query[name] = params.Get(name)
+			}
+
+			if ip, ok := r.Context().Value(httpmid.ClientIP).(string); ok {
+				info.IP = ip
+			}
+
+			next.ServeHTTP(w, r.WithContext(core.ContextWithConnInfo(r.Context(), info)))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	httpmid "github.com/ksysoev/wasabi/middleware/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConnInfoMiddleware(t *testing.T) {
+	var info *core.ConnInfo
+
+	handler := NewQueryParamsMiddleware()(NewHeadersMiddleware()(
+		NewConnInfoMiddleware([]string{"User-Agent", "Accept-Language"}, []string{"app_id", "l"})(
+			http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				info = core.ConnInfoFromContext(r.Context())
+			}),
+		),
+	))
+
+	req := httptest.NewRequest("GET", "http://example.com/?app_id=1089&token=secret&l=ES", http.NoBody)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Authorization", "Bearer secret")
+	req = req.WithContext(context.WithValue(req.Context(), httpmid.ClientIP, "1.2.3.4"))
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, info)
+	assert.Equal(t, &core.ConnInfo{
+		Headers: map[string]string{"User-Agent": "test-agent", "Accept-Language": ""},
+		Query:   map[string]string{"app_id": "1089", "l": "ES"},
+		IP:      "1.2.3.4",
+		Lang:    "ES",
+	}, info)
+}
+
+func TestNewConnInfoMiddleware_WithoutRequestMiddlewares(t *testing.T) {
+	var info *core.ConnInfo
+
+	handler := NewConnInfoMiddleware([]string{"User-Agent"}, []string{"app_id"})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			info = core.ConnInfoFromContext(r.Context())
+		}),
+	)
+
+	req := httptest.NewRequest("GET", "http://example.com/?app_id=1089&l=ES", http.NoBody)
+	req.Header.Set("User-Agent", "test-agent")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, info)
+	assert.Equal(t, &core.ConnInfo{
+		Headers: map[string]string{"User-Agent": ""},
+		Query:   map[string]string{"app_id": ""},
+	}, info)
+}
@@ -0,0 +1,219 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+const (
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+type Config struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`
+	HalfOpenRequests int           `mapstructure:"half_open_requests"`
+}
+
+// Breaker is a circuit breaker for a single upstream.
+// It opens after the configured number of consecutive failures and rejects requests until the open timeout passes.
+// Then it lets a limited number of probe requests through and closes if all of them succeed, or opens again otherwise.
+type Breaker struct {
+	openedAt  time.Time
+	now       func() time.Time
+	cfg       Config
+	state     State
+	failures  int
+	probes    int
+	successes int
+	mu        sync.Mutex
+}
+
+// Set holds circuit breakers of upstreams of a provider, creating them on demand, and exports their states as a metric.
+type Set struct {
+	breakers map[string]*Breaker
+	now      func() time.Time
+	provider string
+	cfg      Config
+	mu       sync.Mutex
+}
+
+// NewSet creates a new Set of circuit breakers.
+// It takes provider of type string which is used as a metric attribute and cfg of type Config.
+// It returns a pointer to Set, or nil if the failure threshold is not set, which disables circuit breakers.
+// It panics if the metric can't be initialized.
+func NewSet(provider string, cfg Config) *Set {
+	if cfg.FailureThreshold <= 0 {
+		return nil
+	}
+
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = defaultHalfOpenRequests
+	}
+
+	s := &Set{
+		breakers: make(map[string]*Breaker),
+		now:      time.Now,
+		provider: provider,
+		cfg:      cfg,
+	}
+
+	meter := otel.GetMeterProvider().Meter("circuit_breaker")
+
+	_, err := meter.Int64ObservableGauge(
+		"circuit_breaker_state",
+		metric.WithDescription("State of the circuit breaker: 0 - closed, 1 - half-open, 2 - open"),
+		metric.WithInt64Callback(s.observe),
+	)
+	if err != nil {
+		panic("failed to initialize metric" + err.Error())
+	}
+
+	return s
+}
+
+// Get returns the circuit breaker of the upstream, creating it if needed.
+// It takes upstream of type string.
+// It returns a pointer to Breaker.
+func (s *Set) Get(upstream string) *Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[upstream]
+	if !ok {
+		b = &Breaker{cfg: s.cfg, now: s.now}
+		s.breakers[upstream] = b
+	}
+
+	return b
+}
+
+// observe reports states of the circuit breakers to the metric.
+// It takes ctx of type context.Context and o of type metric.Int64Observer.
+// It returns nil.
+func (s *Set) observe(_ context.Context, o metric.Int64Observer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for upstream, b := range s.breakers {
+		o.Observe(int64(b.State()), metric.WithAttributes(
+			attribute.String("provider", s.provider),
+			attribute.String("upstream", upstream),
+		))
+	}
+
+	return nil
+}
+
+// Allow checks if a request to the upstream is allowed.
+// It returns a ServiceUnavailable core.APIError if the circuit is open or all probe requests of the half-open circuit are in flight.
+// Every allowed request must be followed by a call to Done or Ignore.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = StateHalfOpen
+		b.probes, b.successes = 0, 0
+	}
+
+	switch b.state {
+	case StateOpen:
+		return unavailable()
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return unavailable()
+		}
+
+		b.probes++
+	case StateClosed:
+	}
+
+	return nil
+}
+
+// Done records the result of an allowed request.
+// It takes success of type bool.
+func (b *Breaker) Done(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+
+		b.failures++
+
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	case StateHalfOpen:
+		if !success {
+			b.open()
+			return
+		}
+
+		b.successes++
+
+		if b.successes >= b.cfg.HalfOpenRequests {
+			b.state = StateClosed
+			b.failures = 0
+		}
+	case StateOpen:
+	}
+}
+
+// Ignore records an allowed request whose result says nothing about the health of the upstream,
+// e.g. because the client canceled it or its deadline exceeded.
+// The request is not counted as a success or a failure, and its probe slot of the half-open circuit is released.
+func (b *Breaker) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// State returns the current state of the circuit breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// open opens the circuit, the caller must hold the lock.
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
+
+// unavailable creates the error returned for rejected requests.
+// It returns an error of type *core.APIError.
+func unavailable() error {
+	return core.NewAPIError("ServiceUnavailable", "The service is temporarily unavailable, please try again later", nil)
+}
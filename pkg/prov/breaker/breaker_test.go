@@ -0,0 +1,156 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+type testObserver struct {
+	noop.Int64Observer
+	states map[string]int64
+}
+
+func (o *testObserver) Observe(value int64, opts ...metric.ObserveOption) {
+	attrs := metric.NewObserveConfig(opts).Attributes()
+	upstream, _ := attrs.Value(attribute.Key("upstream"))
+
+	o.states[upstream.AsString()] = value
+}
+
+func TestNewSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantCfg  Config
+		disabled bool
+	}{
+		{
+			name:     "Disabled",
+			cfg:      Config{},
+			disabled: true,
+		},
+		{
+			name:    "Defaults",
+			cfg:     Config{FailureThreshold: 3},
+			wantCfg: Config{FailureThreshold: 3, OpenTimeout: defaultOpenTimeout, HalfOpenRequests: defaultHalfOpenRequests},
+		},
+		{
+			name:    "Custom",
+			cfg:     Config{FailureThreshold: 3, OpenTimeout: time.Second, HalfOpenRequests: 2},
+			wantCfg: Config{FailureThreshold: 3, OpenTimeout: time.Second, HalfOpenRequests: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSet("test", tt.cfg)
+
+			if tt.disabled {
+				assert.Nil(t, s)
+				return
+			}
+
+			assert.Equal(t, tt.wantCfg, s.cfg)
+			assert.Same(t, s.Get("host"), s.Get("host"))
+			assert.NotSame(t, s.Get("host"), s.Get("other"))
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+
+	s := NewSet("test", Config{FailureThreshold: 2, OpenTimeout: time.Second, HalfOpenRequests: 2})
+	s.now = func() time.Time { return now }
+
+	b := s.Get("host")
+
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.NoError(t, b.Allow())
+	b.Done(true)
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.Equal(t, StateClosed, b.State(), "success resets consecutive failures")
+
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.Equal(t, StateOpen, b.State())
+
+	var apiErr *core.APIError
+
+	assert.ErrorAs(t, b.Allow(), &apiErr)
+	assert.Equal(t, "ServiceUnavailable", apiErr.Code)
+
+	now = now.Add(time.Second)
+
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+	assert.Error(t, b.Allow(), "only configured number of probes is allowed")
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	b.Done(true)
+	b.Done(false)
+	assert.Equal(t, StateOpen, b.State(), "failed probe opens the circuit")
+
+	now = now.Add(time.Second)
+
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+	b.Done(true)
+	assert.Equal(t, StateHalfOpen, b.State())
+	b.Done(true)
+	assert.Equal(t, StateClosed, b.State())
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_Ignore(t *testing.T) {
+	now := time.Now()
+
+	s := NewSet("test", Config{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenRequests: 1})
+	s.now = func() time.Time { return now }
+
+	b := s.Get("host")
+
+	assert.NoError(t, b.Allow())
+	b.Ignore()
+	assert.Equal(t, StateClosed, b.State(), "ignored request is not a failure")
+
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.Equal(t, StateOpen, b.State())
+
+	now = now.Add(time.Second)
+
+	assert.NoError(t, b.Allow())
+	assert.Error(t, b.Allow())
+
+	b.Ignore()
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.NoError(t, b.Allow(), "ignored probe releases its slot")
+
+	b.Done(true)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestSet_observe(t *testing.T) {
+	s := NewSet("test", Config{FailureThreshold: 1})
+
+	s.Get("closed")
+	open := s.Get("open")
+
+	assert.NoError(t, open.Allow())
+	open.Done(false)
+
+	o := &testObserver{states: make(map[string]int64)}
+
+	assert.NoError(t, s.observe(context.Background(), o))
+	assert.Equal(t, map[string]int64{"closed": int64(StateClosed), "open": int64(StateOpen)}, o.states)
+}
@@ -0,0 +1,291 @@
+package deriv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/ksysoev/wasabi"
+)
+
+type messageFactory func(r wasabi.Request) (wasabi.MessageType, []byte, error)
+
+type wsDialer func(ctx context.Context, baseURL string) (*websocket.Conn, error)
+
+// wsBackend forwards client requests to Deriv API using a dedicated upstream connection for each client.
+// Upstream connections are established lazily, kept alive with pings, and closed when they stay idle
+// for the configured period, while the client connection stays open. A new upstream connection is
+// established on the next request of the client.
+// Upstream connections that are authorized or have subscriptions are never closed as idle,
+// because their state would be lost on the new connection.
+type wsBackend struct {
+	dial         wsDialer
+	factory      messageFactory
+	clients      map[string]*wsClient
+	url          string
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+	idleTimeout  time.Duration
+	mu           sync.Mutex
+}
+
+type wsClient struct {
+	conn       wasabi.Connection
+	ws         *websocket.Conn
+	lastActive atomic.Int64
+	mu         sync.Mutex
+	stateful   bool
+}
+
+// statefulRequest contains fields of requests that create state of the upstream connection.
+type statefulRequest struct {
+	Authorize json.RawMessage `json:"authorize"`
+	Subscribe json.RawMessage `json:"subscribe"`
+}
+
+// newWSBackend creates a new wsBackend.
+// It takes cfg of type *Config, factory of type messageFactory which converts requests into upstream messages,
+// and dial of type wsDialer which establishes upstream connections.
+// It returns a pointer to wsBackend.
+// If the ping timeout is not set, the ping interval is used as a timeout.
+func newWSBackend(cfg *Config, factory messageFactory, dial wsDialer) *wsBackend {
+	pingTimeout := cfg.PingTimeout
+	if pingTimeout == 0 {
+		pingTimeout = cfg.PingInterval
+	}
+
+	return &wsBackend{
+		dial:         dial,
+		factory:      factory,
+		clients:      make(map[string]*wsClient),
+		url:          cfg.Endpoint,
+		pingInterval: cfg.PingInterval,
+		pingTimeout:  pingTimeout,
+		idleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+// Handle sends the request to the upstream connection of the client.
+// It takes conn of type wasabi.Connection and r of type wasabi.Request.
+// It returns an error if the message can't be created, the upstream connection can't be established or the message can't be sent.
+// Authorization and subscription requests mark the upstream connection as stateful, so it's not closed as idle.
+func (b *wsBackend) Handle(conn wasabi.Connection, r wasabi.Request) error {
+	msgType, data, err := b.factory(r)
+	if err != nil {
+		return err
+	}
+
+	ws, err := b.connect(b.client(conn), msgType == wasabi.MsgTypeText && isStateful(data))
+	if err != nil {
+		return err
+	}
+
+	return ws.Write(r.Context(), msgType, data)
+}
+
+// client returns the state of the client connection, registering it on the first request.
+// It takes conn of type wasabi.Connection.
+// It returns a pointer to wsClient.
+func (b *wsBackend) client(conn wasabi.Connection) *wsClient {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.clients[conn.ID()]
+	if !ok {
+		c = &wsClient{conn: conn}
+		b.clients[conn.ID()] = c
+
+		go b.watch(c)
+	}
+
+	return c
+}
+
+// connect returns the upstream connection of the client, establishing it if needed.
+// It takes c of type *wsClient and stateful of type bool which marks the connection as stateful.
+// It returns a pointer to websocket.Conn and an error if the connection can't be established.
+// The client is marked as active, so the connection is not closed as idle right before it's used.
+func (b *wsBackend) connect(c *wsClient, stateful bool) (*websocket.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.touch()
+
+	if c.ws != nil {
+		c.stateful = c.stateful || stateful
+		return c.ws, nil
+	}
+
+	ws, err := b.dial(c.conn.Context(), b.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to upstream: %w", err)
+	}
+
+	c.ws, c.stateful = ws, stateful
+	done := make(chan struct{})
+
+	go b.readLoop(c, ws, done)
+
+	if b.pingInterval > 0 || b.idleTimeout > 0 {
+		go b.monitor(c, ws, done)
+	}
+
+	return ws, nil
+}
+
+// watch waits for the client connection to be closed and releases its upstream connection.
+// It takes c of type *wsClient.
+func (b *wsBackend) watch(c *wsClient) {
+	<-c.conn.Context().Done()
+
+	b.mu.Lock()
+	delete(b.clients, c.conn.ID())
+	b.mu.Unlock()
+
+	if ws := c.detach(nil); ws != nil {
+		_ = ws.CloseNow()
+	}
+}
+
+// readLoop forwards messages from the upstream connection to the client until the upstream connection is closed.
+// It takes c of type *wsClient, ws of type *websocket.Conn and done of type chan struct{} which is closed on exit.
+// If the upstream connection is closed unexpectedly while the client is connected, the client connection is closed as well.
+func (b *wsBackend) readLoop(c *wsClient, ws *websocket.Conn, done chan struct{}) {
+	defer close(done)
+
+	ctx := c.conn.Context()
+
+	for {
+		msgType, data, err := ws.Read(ctx)
+		if err != nil {
+			break
+		}
+
+		c.touch()
+
+		if err := c.conn.Send(msgType, data); err != nil {
+			slog.Debug("Failed to send upstream message to client", slog.Any("error", err))
+		}
+	}
+
+	_ = ws.CloseNow()
+
+	if c.detach(ws) == nil || ctx.Err() != nil {
+		return
+	}
+
+	_ = c.conn.Close(websocket.StatusGoingAway, "upstream connection closed")
+}
+
+// monitor sends keepalive pings to the upstream connection and closes it when it's dead or idle.
+// It takes c of type *wsClient, ws of type *websocket.Conn and done of type <-chan struct{} which is closed when the connection is closed.
+// Checks are performed every ping interval, or every idle timeout if pings are disabled.
+func (b *wsBackend) monitor(c *wsClient, ws *websocket.Conn, done <-chan struct{}) {
+	interval := b.pingInterval
+	if interval == 0 {
+		interval = b.idleTimeout
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if b.idleTimeout > 0 && c.closeIfIdle(ws, b.idleTimeout) {
+			return
+		}
+
+		if b.pingInterval > 0 {
+			if err := ping(c.conn.Context(), ws, b.pingTimeout); err != nil {
+				if c.conn.Context().Err() != nil {
+					return
+				}
+
+				slog.Warn("Upstream connection is dead", slog.Any("error", err))
+				_ = ws.CloseNow()
+
+				return
+			}
+		}
+	}
+}
+
+// touch marks the client as active.
+func (c *wsClient) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// detach removes the upstream connection from the client.
+// It takes ws of type *websocket.Conn which is the connection to detach, or nil to detach any connection.
+// It returns the detached connection or nil if the client has a different connection or no connection at all.
+func (c *wsClient) detach(ws *websocket.Conn) *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ws == nil || ws != nil && c.ws != ws {
+		return nil
+	}
+
+	ws, c.ws, c.stateful = c.ws, nil, false
+
+	return ws
+}
+
+// closeIfIdle closes the upstream connection if the client has been inactive for the given period.
+// It takes ws of type *websocket.Conn and timeout of type time.Duration.
+// It returns true if the connection was closed.
+// The connection is detached before it's closed, so the client connection stays open.
+// Stateful connections are never closed as idle.
+func (c *wsClient) closeIfIdle(ws *websocket.Conn, timeout time.Duration) bool {
+	c.mu.Lock()
+
+	if c.ws != ws || c.stateful || time.Since(time.Unix(0, c.lastActive.Load())) < timeout {
+		c.mu.Unlock()
+		return false
+	}
+
+	c.ws = nil
+	c.mu.Unlock()
+
+	_ = ws.Close(websocket.StatusNormalClosure, "idle")
+
+	return true
+}
+
+// isStateful checks whether the request creates state of the upstream connection, i.e. authorizes it or subscribes to updates.
+// It takes data of type []byte which is the text message of the request.
+// It returns true if the request is an authorization or subscription request.
+func isStateful(data []byte) bool {
+	var req statefulRequest
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return false
+	}
+
+	return isSet(req.Authorize) || isSet(req.Subscribe) && string(req.Subscribe) != "0"
+}
+
+// isSet checks whether the field is present in the request and is not null.
+func isSet(field json.RawMessage) bool {
+	return len(field) > 0 && string(field) != "null"
+}
+
+// ping sends a ping to the upstream connection and waits for the pong.
+// It takes ctx of type context.Context, ws of type *websocket.Conn and timeout of type time.Duration.
+// It returns an error if the pong is not received within the timeout.
+func ping(ctx context.Context, ws *websocket.Conn, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return ws.Ping(ctx)
+}
@@ -0,0 +1,269 @@
+package deriv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/wasabi"
+	"github.com/ksysoev/wasabi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testUpstreamServer struct {
+	accepted chan struct{}
+	closed   chan struct{}
+	url      string
+}
+
+func newTestUpstreamServer(t *testing.T, echo bool) *testUpstreamServer {
+	t.Helper()
+
+	srv := &testUpstreamServer{
+		accepted: make(chan struct{}, 10),
+		closed:   make(chan struct{}, 10),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		srv.accepted <- struct{}{}
+
+		defer func() { srv.closed <- struct{}{} }()
+
+		if !echo {
+			<-r.Context().Done()
+			return
+		}
+
+		for {
+			msgType, data, err := c.Read(context.Background())
+			if err != nil {
+				return
+			}
+
+			if err := c.Write(context.Background(), msgType, data); err != nil {
+				return
+			}
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	srv.url = "ws://" + server.Listener.Addr().String()
+
+	return srv
+}
+
+func (s *testUpstreamServer) dial(ctx context.Context, baseURL string) (*websocket.Conn, error) {
+	c, _, err := websocket.Dial(ctx, baseURL, nil)
+	return c, err
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}, msg string) {
+	t.Helper()
+
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal(msg)
+	}
+}
+
+func newBackendTestConn(t *testing.T, ctx context.Context) (*mocks.MockConnection, chan []byte) {
+	t.Helper()
+
+	msgs := make(chan []byte, 10)
+
+	conn := mocks.NewMockConnection(t)
+	conn.EXPECT().ID().Return("conn1").Maybe()
+	conn.EXPECT().Context().Return(ctx).Maybe()
+	conn.EXPECT().Send(wasabi.MsgTypeText, mock.MatchedBy(func(data []byte) bool {
+		msgs <- data
+		return true
+	})).Return(nil).Maybe()
+
+	return conn, msgs
+}
+
+func TestWSBackend_IdleTimeout(t *testing.T) {
+	srv := newTestUpstreamServer(t, true)
+	s := &Service{}
+	b := newWSBackend(&Config{Endpoint: srv.url, IdleTimeout: 50 * time.Millisecond}, s.createMessage, srv.dial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, msgs := newBackendTestConn(t, ctx)
+
+	req := request.NewRequest(ctx, request.TextMessage, []byte(`{"ping":1}`))
+	require.NoError(t, b.Handle(conn, req))
+
+	waitFor(t, srv.accepted, "upstream connection is not established")
+	assert.Equal(t, []byte(`{"ping":1}`), <-msgs)
+
+	waitFor(t, srv.closed, "idle upstream connection is not closed")
+
+	require.NoError(t, b.Handle(conn, req))
+
+	waitFor(t, srv.accepted, "upstream connection is not reestablished")
+	assert.Equal(t, []byte(`{"ping":1}`), <-msgs)
+
+	cancel()
+
+	waitFor(t, srv.closed, "upstream connection is not closed after client disconnected")
+
+	assert.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		return len(b.clients) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWSBackend_IdleTimeout_Stateful(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		call  string
+	}{
+		{name: "Authorized", state: `{"authorize":"token"}`, call: `{"balance":1}`},
+		{name: "Subscribed", state: `{"proposal_open_contract":1,"subscribe":1}`, call: `{"portfolio":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestUpstreamServer(t, true)
+			s := &Service{}
+			b := newWSBackend(&Config{Endpoint: srv.url, IdleTimeout: 30 * time.Millisecond}, s.createMessage, srv.dial)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			conn, msgs := newBackendTestConn(t, ctx)
+
+			require.NoError(t, b.Handle(conn, request.NewRequest(ctx, request.TextMessage, []byte(tt.state))))
+
+			waitFor(t, srv.accepted, "upstream connection is not established")
+			assert.Equal(t, []byte(tt.state), <-msgs)
+
+			time.Sleep(150 * time.Millisecond)
+
+			assert.Empty(t, srv.closed, "stateful upstream connection is closed as idle")
+
+			require.NoError(t, b.Handle(conn, request.NewRequest(ctx, request.TextMessage, []byte(tt.call))))
+			assert.Equal(t, []byte(tt.call), <-msgs)
+			assert.Empty(t, srv.accepted, "upstream connection is reestablished")
+		})
+	}
+}
+
+func TestIsStateful(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{data: `{"authorize":"token"}`, want: true},
+		{data: `{"ticks":"R_50","subscribe":1}`, want: true},
+		{data: `{"ticks":"R_50","subscribe":0}`, want: false},
+		{data: `{"ticks":"R_50","subscribe":null}`, want: false},
+		{data: `{"ping":1}`, want: false},
+		{data: `not json`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			assert.Equal(t, tt.want, isStateful([]byte(tt.data)))
+		})
+	}
+}
+
+func TestWSBackend_DeadConnection(t *testing.T) {
+	srv := newTestUpstreamServer(t, false)
+	s := &Service{}
+	b := newWSBackend(&Config{Endpoint: srv.url, PingInterval: 20 * time.Millisecond}, s.createMessage, srv.dial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, _ := newBackendTestConn(t, ctx)
+
+	closed := make(chan struct{}, 1)
+
+	conn.EXPECT().Close(mock.MatchedBy(func(status websocket.StatusCode) bool {
+		select {
+		case closed <- struct{}{}:
+		default:
+		}
+
+		return status == websocket.StatusGoingAway
+	}), "upstream connection closed").Return(nil)
+
+	req := request.NewRequest(ctx, request.TextMessage, []byte(`{"ping":1}`))
+	require.NoError(t, b.Handle(conn, req))
+
+	waitFor(t, closed, "client connection is not closed after upstream connection died")
+}
+
+func TestWSBackend_KeepAlive(t *testing.T) {
+	srv := newTestUpstreamServer(t, true)
+	s := &Service{}
+	b := newWSBackend(&Config{Endpoint: srv.url, PingInterval: 10 * time.Millisecond}, s.createMessage, srv.dial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, msgs := newBackendTestConn(t, ctx)
+
+	req := request.NewRequest(ctx, request.TextMessage, []byte(`{"ping":1}`))
+	require.NoError(t, b.Handle(conn, req))
+	<-msgs
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Empty(t, srv.closed)
+
+	require.NoError(t, b.Handle(conn, req))
+	assert.Equal(t, []byte(`{"ping":1}`), <-msgs)
+	assert.Len(t, srv.accepted, 1)
+}
+
+func TestWSBackend_Errors(t *testing.T) {
+	s := &Service{}
+	b := newWSBackend(&Config{Endpoint: "ws://localhost:0", IdleTimeout: time.Second}, s.createMessage, s.dialer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, _ := newBackendTestConn(t, ctx)
+
+	err := b.Handle(conn, request.NewRequest(ctx, "unsupported", nil))
+	assert.ErrorContains(t, err, "unsupported request type")
+
+	err = b.Handle(conn, request.NewRequest(ctx, request.TextMessage, []byte(`{"ping":1}`)))
+	assert.ErrorContains(t, err, "app_id is required")
+}
+
+func TestNewService_KeepAlive(t *testing.T) {
+	service := NewService(&Config{
+		Endpoint:            "wss://example.com",
+		PingInterval:        time.Second,
+		SharedSubscriptions: []string{"ticks"},
+	})
+
+	b, ok := service.handler.(*wsBackend)
+	require.True(t, ok)
+
+	assert.Equal(t, time.Second, b.pingTimeout)
+	assert.Equal(t, time.Second, service.hub.pingInterval)
+	assert.Equal(t, time.Second, service.hub.pingTimeout)
+}
@@ -0,0 +1,797 @@
+package deriv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/middleware"
+	"github.com/ksysoev/wasabi"
+)
+
+const (
+	upstreamLostCode     = "SubscriptionTerminated"
+	upstreamLostMessage  = "Subscription is terminated by the upstream server"
+	upstreamWriteTimeout = 10 * time.Second
+)
+
+type dialFunc func(ctx context.Context, urlParams url.Values) (*websocket.Conn, error)
+
+// Hub deduplicates identical public subscriptions across client connections.
+// It holds a single upstream subscription for each unique request and fans out updates to all subscribers,
+// replacing req_id, passthrough, echo_req and subscription id with the values of each subscriber.
+// Upstream connections are owned by the hub, so they don't depend on the lifetime of the client that opened them.
+type Hub struct {
+	ctx          context.Context
+	cancel       context.CancelFunc
+	dial         dialFunc
+	calls        map[string]struct{}
+	upstreams    map[string]*upstream
+	conns        map[string]map[string]*subscriber
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+	mu           sync.Mutex
+}
+
+type upstream struct {
+	conn   *websocket.Conn
+	err    error
+	ready  chan struct{}
+	done   chan struct{}
+	topics map[string]*topic
+	reqs   map[int]*topic
+	key    string
+	nextID int
+	closed bool
+}
+
+type topic struct {
+	up      *upstream
+	subs    map[string]*subscriber
+	last    map[string]json.RawMessage
+	call    string
+	key     string
+	upSubID string
+	reqID   int
+	ready   bool
+}
+
+type subscriber struct {
+	conn        *core.Conn
+	topic       *topic
+	echoReq     json.RawMessage
+	reqID       json.RawMessage
+	passthrough json.RawMessage
+	id          string
+}
+
+type HubOption func(*Hub)
+
+// WithHubPing enables keepalive pings of upstream connections of the hub.
+// It takes interval of type time.Duration which is the period between pings,
+// and timeout of type time.Duration which is the period of waiting for the pong.
+// It returns a HubOption. If the timeout is not set, the ping interval is used as a timeout.
+func WithHubPing(interval, timeout time.Duration) HubOption {
+	return func(h *Hub) {
+		h.pingInterval = interval
+		h.pingTimeout = timeout
+	}
+}
+
+// NewHub creates a new Hub for the provided calls.
+// It takes calls of type []string which are the names of public calls that can be shared between clients,
+// dial of type dialFunc which is used to establish upstream connections, and opts of type ...HubOption.
+// It returns a pointer to Hub.
+func NewHub(calls []string, dial dialFunc, opts ...HubOption) *Hub {
+	set := make(map[string]struct{}, len(calls))
+	for _, call := range calls {
+		set[call] = struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := &Hub{
+		ctx:       ctx,
+		cancel:    cancel,
+		dial:      dial,
+		calls:     set,
+		upstreams: make(map[string]*upstream),
+		conns:     make(map[string]map[string]*subscriber),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.pingTimeout == 0 {
+		h.pingTimeout = h.pingInterval
+	}
+
+	return h
+}
+
+// Close closes all upstream connections of the hub and cancels pending dials.
+// Subscribers of the closed upstream connections are notified that their subscriptions are terminated.
+func (h *Hub) Close() {
+	h.cancel()
+
+	h.mu.Lock()
+
+	ups := make([]*upstream, 0, len(h.upstreams))
+	for _, up := range h.upstreams {
+		ups = append(ups, up)
+	}
+
+	h.mu.Unlock()
+
+	for _, up := range ups {
+		<-up.ready
+
+		if up.conn != nil {
+			_ = up.conn.CloseNow()
+		}
+	}
+}
+
+// Handle processes the raw client request if it's managed by the hub.
+// It takes conn of type *core.Conn and data of type []byte which is the raw request of the client.
+// It returns true if the request was handled by the hub and an error if the request fails.
+// It handles subscriptions to shared calls and forget requests for shared subscriptions. Requests to
+// forget_all remove matching shared subscriptions of the client, but are not handled by the hub, so they
+// are still forwarded to the client's own upstream connection.
+func (h *Hub) Handle(conn *core.Conn, data []byte) (bool, error) {
+	var msg map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return false, nil
+	}
+
+	if id, ok := msg["forget"]; ok {
+		return h.forget(conn, data, msg, id)
+	}
+
+	if calls, ok := msg["forget_all"]; ok {
+		h.forgetAll(conn, calls)
+		return false, nil
+	}
+
+	call, ok := h.sharedCall(msg)
+	if !ok {
+		return false, nil
+	}
+
+	return true, h.subscribe(conn, call, data, msg)
+}
+
+// sharedCall checks if the request is a subscription to one of the shared calls.
+// It takes msg of type map[string]json.RawMessage which is the parsed client request.
+// It returns the name of the call and true if the request can be shared, otherwise false.
+func (h *Hub) sharedCall(msg map[string]json.RawMessage) (string, bool) {
+	var subscribe int
+	if err := json.Unmarshal(msg["subscribe"], &subscribe); err != nil || subscribe != 1 {
+		return "", false
+	}
+
+	call := ""
+
+	for key := range msg {
+		if _, ok := h.calls[key]; !ok {
+			continue
+		}
+
+		if call != "" {
+			return "", false
+		}
+
+		call = key
+	}
+
+	return call, call != ""
+}
+
+// subscribe adds the client to the shared subscription, creating the upstream subscription if needed.
+// It takes conn of type *core.Conn, call of type string, data of type []byte and msg of type map[string]json.RawMessage.
+// It returns an error if the upstream connection can't be established or the subscription request can't be sent.
+// If the shared subscription already received updates, the latest one is sent to the client immediately.
+func (h *Hub) subscribe(conn *core.Conn, call string, data []byte, msg map[string]json.RawMessage) error {
+	key, err := topicKey(data)
+	if err != nil {
+		return err
+	}
+
+	sub := &subscriber{
+		conn:        conn,
+		echoReq:     data,
+		reqID:       msg["req_id"],
+		passthrough: msg["passthrough"],
+		id:          newSubscriptionID(),
+	}
+
+	for {
+		up, err := h.getUpstream(conn.Context(), middleware.QueryParamsFromContext(conn.Context()))
+		if err != nil {
+			return fmt.Errorf("failed to connect to upstream: %w", err)
+		}
+
+		h.mu.Lock()
+
+		if up.closed {
+			h.mu.Unlock()
+			continue
+		}
+
+		t, ok := up.topics[key]
+		if !ok {
+			up.nextID++
+			t = &topic{
+				up:    up,
+				subs:  make(map[string]*subscriber),
+				call:  call,
+				key:   key,
+				reqID: up.nextID,
+			}
+			up.topics[key] = t
+			up.reqs[t.reqID] = t
+		}
+
+		sub.topic = t
+		t.subs[sub.id] = sub
+		last, upSubID := t.last, t.upSubID
+
+		h.addConnLocked(conn, sub)
+		h.mu.Unlock()
+
+		if !ok {
+			return h.sendSubscribe(t, msg)
+		}
+
+		if last != nil {
+			h.send(sub, rewrite(last, sub, upSubID))
+		}
+
+		return nil
+	}
+}
+
+// sendSubscribe sends the subscription request of the topic to the upstream.
+// It takes t of type *topic and msg of type map[string]json.RawMessage which is the parsed client request.
+// It returns an error if the request can't be sent, in which case the topic is terminated.
+func (h *Hub) sendSubscribe(t *topic, msg map[string]json.RawMessage) error {
+	req := make(map[string]json.RawMessage, len(msg))
+
+	for k, v := range msg {
+		req[k] = v
+	}
+
+	delete(req, "passthrough")
+
+	req["req_id"] = json.RawMessage(fmt.Sprintf("%d", t.reqID))
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription request: %w", err)
+	}
+
+	if err := h.write(t.up, data); err != nil {
+		h.closeUpstream(t.up)
+		return fmt.Errorf("failed to send subscription request: %w", err)
+	}
+
+	return nil
+}
+
+// forget removes the shared subscription of the client and responds to the client.
+// It takes conn of type *core.Conn, data of type []byte, msg of type map[string]json.RawMessage and id of type json.RawMessage.
+// It returns true if the subscription belongs to the hub and an error if the response can't be sent.
+func (h *Hub) forget(conn *core.Conn, data []byte, msg map[string]json.RawMessage, id json.RawMessage) (bool, error) {
+	var subID string
+	if err := json.Unmarshal(id, &subID); err != nil {
+		return false, nil
+	}
+
+	h.mu.Lock()
+
+	sub, ok := h.conns[conn.ID()][subID]
+	if !ok {
+		h.mu.Unlock()
+		return false, nil
+	}
+
+	cleanup := h.removeLocked(sub)
+	h.mu.Unlock()
+
+	cleanup()
+
+	resp := map[string]json.RawMessage{
+		"echo_req": data,
+		"forget":   json.RawMessage("1"),
+		"msg_type": json.RawMessage(`"forget"`),
+	}
+
+	setOrDelete(resp, "req_id", msg["req_id"])
+	setOrDelete(resp, "passthrough", msg["passthrough"])
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return true, fmt.Errorf("failed to marshal forget response: %w", err)
+	}
+
+	return true, conn.Send(wasabi.MsgTypeText, out)
+}
+
+// forgetAll removes shared subscriptions of the client to the given calls.
+// It takes conn of type *core.Conn and calls of type json.RawMessage which is a call name or a list of call names.
+func (h *Hub) forgetAll(conn *core.Conn, calls json.RawMessage) {
+	var names []string
+
+	if err := json.Unmarshal(calls, &names); err != nil {
+		var name string
+		if err := json.Unmarshal(calls, &name); err != nil {
+			return
+		}
+
+		names = []string{name}
+	}
+
+	cleanups := make([]func(), 0)
+
+	h.mu.Lock()
+
+	for _, sub := range h.conns[conn.ID()] {
+		for _, name := range names {
+			if sub.topic.call == name {
+				cleanups = append(cleanups, h.removeLocked(sub))
+				break
+			}
+		}
+	}
+
+	h.mu.Unlock()
+
+	for _, cleanup := range cleanups {
+		cleanup()
+	}
+}
+
+// addConnLocked registers the subscriber for the client connection.
+// It takes conn of type *core.Conn and sub of type *subscriber.
+// On the first subscription of the connection, it starts watching the connection to remove its subscriptions once it's closed.
+// It must be called with the hub mutex held.
+func (h *Hub) addConnLocked(conn *core.Conn, sub *subscriber) {
+	subs, ok := h.conns[conn.ID()]
+	if !ok {
+		subs = make(map[string]*subscriber)
+		h.conns[conn.ID()] = subs
+
+		go h.watchConn(conn)
+	}
+
+	subs[sub.id] = sub
+}
+
+// watchConn waits for the client connection to be closed and removes all its subscriptions.
+// It takes conn of type *core.Conn.
+func (h *Hub) watchConn(conn *core.Conn) {
+	<-conn.Context().Done()
+
+	h.mu.Lock()
+
+	subs := h.conns[conn.ID()]
+	delete(h.conns, conn.ID())
+
+	cleanups := make([]func(), 0, len(subs))
+	for _, sub := range subs {
+		cleanups = append(cleanups, h.removeLocked(sub))
+	}
+
+	h.mu.Unlock()
+
+	for _, cleanup := range cleanups {
+		cleanup()
+	}
+}
+
+// removeLocked removes the subscriber from its topic.
+// It takes sub of type *subscriber.
+// It returns a cleanup function that must be called after the hub mutex is released. The cleanup forgets
+// the upstream subscription if the last subscriber left, or closes the upstream connection if it has no topics left.
+// Topics that haven't received the initial response yet are kept until it arrives to avoid duplicate upstream subscriptions.
+// It must be called with the hub mutex held.
+func (h *Hub) removeLocked(sub *subscriber) func() {
+	t := sub.topic
+
+	delete(t.subs, sub.id)
+
+	if subs, ok := h.conns[sub.conn.ID()]; ok {
+		delete(subs, sub.id)
+	}
+
+	if len(t.subs) > 0 || !t.ready {
+		return func() {}
+	}
+
+	return h.removeTopicLocked(t)
+}
+
+// removeTopicLocked removes the topic from its upstream.
+// It takes t of type *topic.
+// It returns a cleanup function that must be called after the hub mutex is released.
+// It must be called with the hub mutex held.
+func (h *Hub) removeTopicLocked(t *topic) func() {
+	up := t.up
+
+	if up.topics[t.key] == t {
+		delete(up.topics, t.key)
+	}
+
+	delete(up.reqs, t.reqID)
+
+	if len(up.topics) == 0 {
+		up.closed = true
+		delete(h.upstreams, up.key)
+
+		return func() {
+			_ = up.conn.Close(websocket.StatusNormalClosure, "")
+		}
+	}
+
+	if t.upSubID == "" {
+		return func() {}
+	}
+
+	req := fmt.Sprintf(`{"forget":%q}`, t.upSubID)
+
+	return func() {
+		if err := h.write(up, []byte(req)); err != nil {
+			slog.Warn("Failed to forget upstream subscription", slog.Any("error", err))
+		}
+	}
+}
+
+// write sends the message to the upstream connection.
+// It takes up of type *upstream and data of type []byte.
+// It returns an error if the message can't be sent within the write timeout, so a stuck upstream doesn't block its callers.
+func (h *Hub) write(up *upstream, data []byte) error {
+	ctx, cancel := context.WithTimeout(h.ctx, upstreamWriteTimeout)
+	defer cancel()
+
+	return up.conn.Write(ctx, websocket.MessageText, data)
+}
+
+// getUpstream returns the upstream connection for the given URL parameters, establishing it if needed.
+// It takes ctx of type context.Context and urlParams of type url.Values.
+// It returns a pointer to upstream and an error if the connection can't be established.
+// Connections are shared between clients with the same app_id and language. The context only limits waiting for
+// the connection, which is dialed with the context of the hub, so the upstream outlives the client that opened it.
+func (h *Hub) getUpstream(ctx context.Context, urlParams url.Values) (*upstream, error) {
+	key := urlParams.Get("app_id") + "|" + urlParams.Get("l")
+
+	h.mu.Lock()
+
+	up, ok := h.upstreams[key]
+	if !ok {
+		up = &upstream{
+			ready:  make(chan struct{}),
+			done:   make(chan struct{}),
+			topics: make(map[string]*topic),
+			reqs:   make(map[int]*topic),
+			key:    key,
+		}
+		h.upstreams[key] = up
+	}
+
+	h.mu.Unlock()
+
+	if !ok {
+		conn, err := h.dial(h.ctx, urlParams)
+
+		h.mu.Lock()
+
+		up.conn, up.err = conn, err
+		if err != nil {
+			up.closed = true
+			delete(h.upstreams, key)
+		}
+
+		h.mu.Unlock()
+		close(up.ready)
+
+		if err == nil {
+			go h.readLoop(up)
+
+			if h.pingInterval > 0 {
+				go h.keepAlive(up)
+			}
+		}
+	}
+
+	select {
+	case <-up.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if up.err != nil {
+		return nil, up.err
+	}
+
+	return up, nil
+}
+
+// readLoop reads messages from the upstream connection and dispatches them to subscribers until the connection is closed.
+// It takes up of type *upstream.
+func (h *Hub) readLoop(up *upstream) {
+	defer close(up.done)
+
+	for {
+		_, data, err := up.conn.Read(context.Background())
+		if err != nil {
+			h.closeUpstream(up)
+			return
+		}
+
+		h.dispatch(up, data)
+	}
+}
+
+// keepAlive sends pings to the upstream connection until it's closed.
+// It takes up of type *upstream.
+// If the pong is not received within the ping timeout, the connection is considered dead and closed,
+// which terminates all its topics.
+func (h *Hub) keepAlive(up *upstream) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-up.done:
+			return
+		case <-ticker.C:
+		}
+
+		if err := ping(h.ctx, up.conn, h.pingTimeout); err != nil {
+			slog.Warn("Shared upstream connection is dead", slog.Any("error", err))
+			_ = up.conn.CloseNow()
+
+			return
+		}
+	}
+}
+
+// dispatch delivers the upstream message to all subscribers of the topic it belongs to.
+// It takes up of type *upstream and data of type []byte which is the raw upstream message.
+// Error responses terminate the topic after they are delivered to its subscribers.
+func (h *Hub) dispatch(up *upstream, data []byte) {
+	var msg map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	var reqID int
+	if err := json.Unmarshal(msg["req_id"], &reqID); err != nil {
+		return
+	}
+
+	h.mu.Lock()
+
+	t, ok := up.reqs[reqID]
+	if !ok {
+		h.mu.Unlock()
+		return
+	}
+
+	subs := make([]*subscriber, 0, len(t.subs))
+	for _, sub := range t.subs {
+		subs = append(subs, sub)
+	}
+
+	cleanup := func() {}
+
+	_, isErr := msg["error"]
+
+	switch {
+	case isErr:
+		for _, sub := range subs {
+			delete(h.conns[sub.conn.ID()], sub.id)
+		}
+
+		t.subs = make(map[string]*subscriber)
+		cleanup = h.removeTopicLocked(t)
+	case !t.ready:
+		t.ready = true
+		t.upSubID = subscriptionID(msg)
+
+		if len(t.subs) == 0 {
+			cleanup = h.removeTopicLocked(t)
+		}
+	}
+
+	if !isErr {
+		t.last = msg
+	}
+
+	upSubID := t.upSubID
+
+	h.mu.Unlock()
+
+	cleanup()
+
+	for _, sub := range subs {
+		h.send(sub, rewrite(msg, sub, upSubID))
+	}
+}
+
+// closeUpstream terminates all topics of the upstream connection and notifies their subscribers.
+// It takes up of type *upstream.
+func (h *Hub) closeUpstream(up *upstream) {
+	h.mu.Lock()
+
+	if h.upstreams[up.key] == up {
+		delete(h.upstreams, up.key)
+	}
+
+	up.closed = true
+
+	subs := make([]*subscriber, 0)
+
+	for _, t := range up.topics {
+		for _, sub := range t.subs {
+			subs = append(subs, sub)
+			delete(h.conns[sub.conn.ID()], sub.id)
+		}
+	}
+
+	up.topics = make(map[string]*topic)
+	up.reqs = make(map[int]*topic)
+
+	h.mu.Unlock()
+
+	_ = up.conn.Close(websocket.StatusNormalClosure, "")
+
+	for _, sub := range subs {
+		h.send(sub, terminatedMessage(sub))
+	}
+}
+
+// send delivers the message that is rewritten for the subscriber.
+// It takes sub of type *subscriber and msg of type map[string]json.RawMessage.
+func (h *Hub) send(sub *subscriber, msg map[string]json.RawMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Warn("Failed to marshal subscription update", slog.Any("error", err))
+		return
+	}
+
+	if err := sub.conn.Send(wasabi.MsgTypeText, data); err != nil {
+		slog.Debug("Failed to send subscription update", slog.Any("error", err))
+	}
+}
+
+// rewrite creates a copy of the upstream message for the subscriber.
+// It takes msg of type map[string]json.RawMessage, sub of type *subscriber and upSubID of type string
+// which is the id of the upstream subscription.
+// It returns the message with req_id, passthrough, echo_req and subscription id of the subscriber.
+func rewrite(msg map[string]json.RawMessage, sub *subscriber, upSubID string) map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(msg)+1)
+
+	for k, v := range msg {
+		out[k] = v
+	}
+
+	out["echo_req"] = sub.echoReq
+
+	setOrDelete(out, "req_id", sub.reqID)
+	setOrDelete(out, "passthrough", sub.passthrough)
+
+	if upSubID == "" {
+		return out
+	}
+
+	subID, _ := json.Marshal(sub.id)
+
+	if _, ok := out["subscription"]; ok {
+		out["subscription"] = json.RawMessage(`{"id":` + string(subID) + `}`)
+	}
+
+	var msgType string
+	if err := json.Unmarshal(msg["msg_type"], &msgType); err != nil {
+		return out
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(msg[msgType], &body); err != nil {
+		return out
+	}
+
+	var id string
+	if err := json.Unmarshal(body["id"], &id); err != nil || id != upSubID {
+		return out
+	}
+
+	body["id"] = subID
+
+	if data, err := json.Marshal(body); err == nil {
+		out[msgType] = data
+	}
+
+	return out
+}
+
+// terminatedMessage creates an error message for the subscriber whose upstream subscription was terminated.
+// It takes sub of type *subscriber.
+// It returns the error message in the format of Deriv API.
+func terminatedMessage(sub *subscriber) map[string]json.RawMessage {
+	errData, _ := json.Marshal(map[string]string{
+		"code":    upstreamLostCode,
+		"message": upstreamLostMessage,
+	})
+
+	msgType, _ := json.Marshal(sub.topic.call)
+
+	return rewrite(map[string]json.RawMessage{
+		"error":    errData,
+		"msg_type": msgType,
+	}, sub, "")
+}
+
+// setOrDelete sets the key in the message to the value or deletes it if the value is empty.
+// It takes msg of type map[string]json.RawMessage, key of type string and value of type json.RawMessage.
+func setOrDelete(msg map[string]json.RawMessage, key string, value json.RawMessage) {
+	if len(value) == 0 {
+		delete(msg, key)
+		return
+	}
+
+	msg[key] = value
+}
+
+// subscriptionID extracts the subscription id from the upstream message.
+// It takes msg of type map[string]json.RawMessage.
+// It returns the subscription id or an empty string if the message doesn't contain it.
+func subscriptionID(msg map[string]json.RawMessage) string {
+	var sub struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.Unmarshal(msg["subscription"], &sub); err != nil {
+		return ""
+	}
+
+	return sub.ID
+}
+
+// topicKey creates a key that identifies identical subscription requests.
+// It takes data of type []byte which is the raw client request.
+// It returns the canonical representation of the request without client specific fields and an error.
+func topicKey(data []byte) (string, error) {
+	var req map[string]any
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return "", fmt.Errorf("failed to unmarshal subscription request: %w", err)
+	}
+
+	delete(req, "req_id")
+	delete(req, "passthrough")
+
+	key, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal subscription request: %w", err)
+	}
+
+	return string(key), nil
+}
+
+// newSubscriptionID generates a new subscription id in the format used by Deriv API.
+// It returns a string with 32 hexadecimal characters.
+func newSubscriptionID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
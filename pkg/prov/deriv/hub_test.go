@@ -0,0 +1,401 @@
+package deriv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/wasabi"
+	"github.com/ksysoev/wasabi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUpstream struct {
+	conns    chan *websocket.Conn
+	requests chan map[string]any
+	closed   chan struct{}
+}
+
+func newFakeUpstream(t *testing.T) (*fakeUpstream, dialFunc) {
+	t.Helper()
+
+	up := &fakeUpstream{
+		conns:    make(chan *websocket.Conn, 10),
+		requests: make(chan map[string]any, 10),
+		closed:   make(chan struct{}, 10),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		up.conns <- c
+
+		for {
+			_, data, err := c.Read(context.Background())
+			if err != nil {
+				up.closed <- struct{}{}
+				return
+			}
+
+			var req map[string]any
+			if err := json.Unmarshal(data, &req); err != nil {
+				continue
+			}
+
+			up.requests <- req
+
+			if sym, ok := req["ticks"].(string); ok {
+				_ = c.Write(context.Background(), websocket.MessageText, tickMessage(req["req_id"], sym, 1))
+			}
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	dial := func(ctx context.Context, _ url.Values) (*websocket.Conn, error) {
+		c, _, err := websocket.Dial(ctx, "ws://"+server.Listener.Addr().String(), nil)
+		return c, err
+	}
+
+	return up, dial
+}
+
+func tickMessage(reqID any, sym string, quote int) []byte {
+	return []byte(fmt.Sprintf(
+		`{"echo_req":{"ticks":%q,"subscribe":1},"msg_type":"tick","req_id":%v,"subscription":{"id":"up-%s"},"tick":{"id":"up-%s","quote":%d}}`,
+		sym, reqID, sym, sym, quote,
+	))
+}
+
+type testClient struct {
+	conn   *core.Conn
+	msgs   chan map[string]any
+	cancel context.CancelFunc
+}
+
+func newTestClient(t *testing.T, id string) *testClient {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	msgs := make(chan map[string]any, 10)
+
+	wsConn := mocks.NewMockConnection(t)
+	wsConn.EXPECT().ID().Return(id).Maybe()
+	wsConn.EXPECT().Context().Return(ctx).Maybe()
+	wsConn.EXPECT().Send(wasabi.MsgTypeText, mock.MatchedBy(func(data []byte) bool {
+		var msg map[string]any
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return false
+		}
+
+		msgs <- msg
+
+		return true
+	})).Return(nil).Maybe()
+
+	return &testClient{
+		conn:   core.NewConnection(wsConn, func(string) {}),
+		msgs:   msgs,
+		cancel: cancel,
+	}
+}
+
+func (c *testClient) next(t *testing.T) map[string]any {
+	t.Helper()
+
+	select {
+	case msg := <-c.msgs:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for message")
+		return nil
+	}
+}
+
+func TestHub_SharedSubscription(t *testing.T) {
+	up, dial := newFakeUpstream(t)
+	hub := NewHub([]string{"ticks"}, dial)
+
+	client1 := newTestClient(t, "conn1")
+	client2 := newTestClient(t, "conn2")
+
+	handled, err := hub.Handle(client1.conn, []byte(`{"ticks":"R_50","subscribe":1,"req_id":1,"passthrough":{"key":"value"}}`))
+	require.NoError(t, err)
+	assert.True(t, handled)
+
+	upReq := <-up.requests
+	assert.Equal(t, map[string]any{"ticks": "R_50", "subscribe": float64(1), "req_id": float64(1)}, upReq)
+
+	msg1 := client1.next(t)
+	assert.Equal(t, float64(1), msg1["req_id"])
+	assert.Equal(t, map[string]any{"key": "value"}, msg1["passthrough"])
+
+	sub1 := msg1["subscription"].(map[string]any)["id"]
+	assert.NotEqual(t, "up-R_50", sub1)
+	assert.Equal(t, sub1, msg1["tick"].(map[string]any)["id"])
+
+	handled, err = hub.Handle(client2.conn, []byte(`{"subscribe":1,"ticks":"R_50","req_id":7}`))
+	require.NoError(t, err)
+	assert.True(t, handled)
+
+	msg2 := client2.next(t)
+	assert.Equal(t, float64(7), msg2["req_id"])
+	assert.NotContains(t, msg2, "passthrough")
+	assert.Equal(t, map[string]any{"subscribe": float64(1), "ticks": "R_50", "req_id": float64(7)}, msg2["echo_req"])
+
+	sub2 := msg2["subscription"].(map[string]any)["id"]
+	assert.NotEqual(t, sub1, sub2)
+
+	upConn := <-up.conns
+	require.NoError(t, upConn.Write(context.Background(), websocket.MessageText, tickMessage(1, "R_50", 2)))
+
+	assert.Equal(t, float64(2), client1.next(t)["tick"].(map[string]any)["quote"])
+	assert.Equal(t, float64(2), client2.next(t)["tick"].(map[string]any)["quote"])
+
+	handled, err = hub.Handle(client1.conn, []byte(fmt.Sprintf(`{"forget":%q,"req_id":2}`, sub1)))
+	require.NoError(t, err)
+	assert.True(t, handled)
+
+	forgetResp := client1.next(t)
+	assert.Equal(t, "forget", forgetResp["msg_type"])
+	assert.Equal(t, float64(1), forgetResp["forget"])
+	assert.Equal(t, float64(2), forgetResp["req_id"])
+
+	client2.cancel()
+
+	select {
+	case <-up.closed:
+	case <-time.After(time.Second):
+		t.Fatal("upstream connection is not closed after the last subscriber left")
+	}
+
+	assert.Empty(t, up.requests)
+}
+
+func TestHub_ForgetUpstreamSubscription(t *testing.T) {
+	up, dial := newFakeUpstream(t)
+	hub := NewHub([]string{"ticks"}, dial)
+
+	client := newTestClient(t, "conn1")
+
+	_, err := hub.Handle(client.conn, []byte(`{"ticks":"R_50","subscribe":1}`))
+	require.NoError(t, err)
+
+	<-up.requests
+
+	sub1 := client.next(t)["subscription"].(map[string]any)["id"]
+
+	_, err = hub.Handle(client.conn, []byte(`{"ticks":"R_100","subscribe":1}`))
+	require.NoError(t, err)
+
+	<-up.requests
+	client.next(t)
+
+	handled, err := hub.Handle(client.conn, []byte(fmt.Sprintf(`{"forget":%q}`, sub1)))
+	require.NoError(t, err)
+	assert.True(t, handled)
+
+	assert.Equal(t, map[string]any{"forget": "up-R_50"}, <-up.requests)
+
+	handled, err = hub.Handle(client.conn, []byte(`{"forget_all":["ticks"]}`))
+	require.NoError(t, err)
+	assert.False(t, handled)
+
+	select {
+	case <-up.closed:
+	case <-time.After(time.Second):
+		t.Fatal("upstream connection is not closed after the last subscriber left")
+	}
+}
+
+func TestHub_UpstreamClosed(t *testing.T) {
+	up, dial := newFakeUpstream(t)
+	hub := NewHub([]string{"ticks"}, dial)
+
+	client := newTestClient(t, "conn1")
+
+	_, err := hub.Handle(client.conn, []byte(`{"ticks":"R_50","subscribe":1,"req_id":3}`))
+	require.NoError(t, err)
+
+	client.next(t)
+
+	upConn := <-up.conns
+	require.NoError(t, upConn.Close(websocket.StatusGoingAway, ""))
+
+	msg := client.next(t)
+	assert.Equal(t, "ticks", msg["msg_type"])
+	assert.Equal(t, float64(3), msg["req_id"])
+	assert.Equal(t, upstreamLostCode, msg["error"].(map[string]any)["code"])
+}
+
+func TestHub_UpstreamOutlivesFirstSubscriber(t *testing.T) {
+	up, dial := newFakeUpstream(t)
+
+	var dialCtx context.Context
+
+	hub := NewHub([]string{"ticks"}, func(ctx context.Context, urlParams url.Values) (*websocket.Conn, error) {
+		dialCtx = ctx
+		return dial(ctx, urlParams)
+	})
+	t.Cleanup(hub.Close)
+
+	client1 := newTestClient(t, "conn1")
+	client2 := newTestClient(t, "conn2")
+
+	_, err := hub.Handle(client1.conn, []byte(`{"ticks":"R_50","subscribe":1}`))
+	require.NoError(t, err)
+
+	<-up.requests
+	client1.next(t)
+
+	_, err = hub.Handle(client2.conn, []byte(`{"ticks":"R_50","subscribe":1}`))
+	require.NoError(t, err)
+
+	client2.next(t)
+	client1.cancel()
+
+	assert.NoError(t, dialCtx.Err())
+
+	upConn := <-up.conns
+	require.NoError(t, upConn.Write(context.Background(), websocket.MessageText, tickMessage(1, "R_50", 2)))
+
+	assert.Equal(t, float64(2), client2.next(t)["tick"].(map[string]any)["quote"])
+	assert.Empty(t, up.closed)
+
+	hub.Close()
+
+	assert.ErrorIs(t, dialCtx.Err(), context.Canceled)
+	assert.Equal(t, upstreamLostCode, client2.next(t)["error"].(map[string]any)["code"])
+}
+
+func TestHub_DialError(t *testing.T) {
+	hub := NewHub([]string{"ticks"}, func(_ context.Context, _ url.Values) (*websocket.Conn, error) {
+		return nil, fmt.Errorf("dial error")
+	})
+
+	client := newTestClient(t, "conn1")
+
+	handled, err := hub.Handle(client.conn, []byte(`{"ticks":"R_50","subscribe":1}`))
+	assert.True(t, handled)
+	assert.ErrorContains(t, err, "dial error")
+	assert.Empty(t, hub.upstreams)
+}
+
+func TestNewHub_Options(t *testing.T) {
+	hub := NewHub([]string{"ticks"}, nil)
+
+	assert.Zero(t, hub.pingInterval)
+	assert.Zero(t, hub.pingTimeout)
+
+	hub = NewHub([]string{"ticks"}, nil, WithHubPing(time.Minute, time.Second))
+
+	assert.Equal(t, time.Minute, hub.pingInterval)
+	assert.Equal(t, time.Second, hub.pingTimeout)
+}
+
+func TestHub_Handle_NotShared(t *testing.T) {
+	hub := NewHub([]string{"ticks"}, nil)
+
+	client := newTestClient(t, "conn1")
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "Invalid JSON", data: `not json`},
+		{name: "Not a subscription", data: `{"ticks":"R_50"}`},
+		{name: "Not a shared call", data: `{"proposal_open_contract":1,"subscribe":1}`},
+		{name: "Unknown forget", data: `{"forget":"unknown"}`},
+		{name: "Invalid forget", data: `{"forget":1}`},
+		{name: "Forget all", data: `{"forget_all":"ticks"}`},
+		{name: "Invalid forget all", data: `{"forget_all":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled, err := hub.Handle(client.conn, []byte(tt.data))
+
+			assert.NoError(t, err)
+			assert.False(t, handled)
+		})
+	}
+}
+
+func TestHub_SharedCall(t *testing.T) {
+	hub := NewHub([]string{"ticks", "website_status"}, nil)
+
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOk bool
+	}{
+		{name: "Shared call", data: `{"ticks":"R_50","subscribe":1}`, want: "ticks", wantOk: true},
+		{name: "Without subscribe", data: `{"ticks":"R_50"}`},
+		{name: "Subscribe is not 1", data: `{"ticks":"R_50","subscribe":0}`},
+		{name: "Not shared call", data: `{"balance":1,"subscribe":1}`},
+		{name: "Several shared calls", data: `{"ticks":"R_50","website_status":1,"subscribe":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg map[string]json.RawMessage
+
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &msg))
+
+			call, ok := hub.sharedCall(msg)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, call)
+		})
+	}
+}
+
+func TestTopicKey(t *testing.T) {
+	key1, err := topicKey([]byte(`{"ticks":"R_50","subscribe":1,"req_id":1,"passthrough":{"a":1}}`))
+	require.NoError(t, err)
+
+	key2, err := topicKey([]byte(`{"subscribe":1, "ticks":"R_50"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, key1, key2)
+
+	_, err = topicKey([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestRewrite(t *testing.T) {
+	var msg map[string]json.RawMessage
+
+	require.NoError(t, json.Unmarshal(tickMessage(5, "R_50", 1), &msg))
+
+	sub := &subscriber{
+		echoReq:     json.RawMessage(`{"ticks":"R_50","subscribe":1,"req_id":9}`),
+		reqID:       json.RawMessage(`9`),
+		passthrough: json.RawMessage(`{"a":1}`),
+		id:          "client-sub",
+	}
+
+	out := rewrite(msg, sub, "up-R_50")
+
+	assert.Equal(t, json.RawMessage(`9`), out["req_id"])
+	assert.Equal(t, json.RawMessage(`{"a":1}`), out["passthrough"])
+	assert.Equal(t, sub.echoReq, out["echo_req"])
+	assert.JSONEq(t, `{"id":"client-sub"}`, string(out["subscription"]))
+	assert.JSONEq(t, `{"id":"client-sub","quote":1}`, string(out["tick"]))
+	assert.Equal(t, json.RawMessage(`5`), msg["req_id"])
+}
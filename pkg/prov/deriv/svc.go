@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
 	"github.com/ksysoev/deriv-api-bff/pkg/middleware"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/breaker"
 	"github.com/ksysoev/wasabi"
 	"github.com/ksysoev/wasabi/backend"
 )
@@ -19,24 +21,47 @@ const (
 )
 
 type Config struct {
-	Endpoint string `mapstructure:"endpoint"`
+	Endpoint            string         `mapstructure:"endpoint"`
+	SchemaPath          string         `mapstructure:"schema_path"`
+	SharedSubscriptions []string       `mapstructure:"shared_subscriptions"`
+	PingInterval        time.Duration  `mapstructure:"ping_interval"`
+	PingTimeout         time.Duration  `mapstructure:"ping_timeout"`
+	IdleTimeout         time.Duration  `mapstructure:"idle_timeout"`
+	CircuitBreaker      breaker.Config `mapstructure:"circuit_breaker"`
 }
 
 type Service struct {
-	handler wasabi.RequestHandler
+	handler  wasabi.RequestHandler
+	hub      *Hub
+	breakers *breaker.Set
 }
 
 // NewService initializes and returns a new Service instance.
 // It takes cfg of type *Config which contains configuration settings.
 // It returns a pointer to a Service struct.
+// If keepalive pings or idle timeout are configured, upstream connections are managed by the service itself,
+// so idle upstream connections can be closed without closing client connections.
+// If the circuit breaker is configured, upstream connections fail fast while the upstream host keeps failing.
 func NewService(cfg *Config) *Service {
-	s := &Service{}
+	s := &Service{
+		breakers: breaker.NewSet("deriv", cfg.CircuitBreaker),
+	}
+
+	if cfg.PingInterval > 0 || cfg.IdleTimeout > 0 {
+		s.handler = newWSBackend(cfg, s.createMessage, s.dialer)
+	} else {
+		s.handler = backend.NewWSBackend(
+			cfg.Endpoint,
+			s.createMessage,
+			backend.WithWSDialler(s.dialer),
+		)
+	}
 
-	s.handler = backend.NewWSBackend(
-		cfg.Endpoint,
-		s.createMessage,
-		backend.WithWSDialler(s.dialer),
-	)
+	if len(cfg.SharedSubscriptions) > 0 {
+		s.hub = NewHub(cfg.SharedSubscriptions, func(ctx context.Context, urlParams url.Values) (*websocket.Conn, error) {
+			return s.dial(ctx, cfg.Endpoint, urlParams, nil)
+		}, WithHubPing(cfg.PingInterval, cfg.PingTimeout))
+	}
 
 	return s
 }
@@ -44,7 +69,14 @@ func NewService(cfg *Config) *Service {
 // Handle processes a request using the provided connection and request objects.
 // It takes conn of type *core.Conn and req of type *core.Request.
 // It returns an error if the handler fails to process the request.
+// If shared subscriptions are enabled, subscriptions to shared calls are served by the hub instead of the client's own upstream connection.
 func (s *Service) Handle(conn *core.Conn, req *request.Request) error {
+	if s.hub != nil && req.RoutingKey() == request.TextMessage {
+		if handled, err := s.hub.Handle(conn, req.Data()); handled {
+			return err
+		}
+	}
+
 	return s.handler.Handle(conn, req)
 }
 
@@ -78,6 +110,7 @@ func (s *Service) dialer(ctx context.Context, baseURL string) (*websocket.Conn,
 // It takes ctx of type context.Context, baseURL of type string, urlParams of type url.Values, and headers of type http.Header.
 // It returns a pointer to a websocket.Conn and an error.
 // It returns an error if the app_id parameter is missing or if the WebSocket connection fails.
+// If the circuit of the upstream host is open, it returns a ServiceUnavailable core.APIError without dialing.
 func (s *Service) dial(ctx context.Context, baseURL string, urlParams url.Values, headers http.Header) (*websocket.Conn, error) {
 	if urlParams != nil {
 		if appID := urlParams.Get("app_id"); appID != "" {
@@ -93,9 +126,28 @@ func (s *Service) dial(ctx context.Context, baseURL string, urlParams url.Values
 		return nil, fmt.Errorf("app_id is required")
 	}
 
+	var b *breaker.Breaker
+
+	if s.breakers != nil {
+		b = s.breakers.Get(upstreamHost(baseURL))
+
+		if err := b.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
 	c, resp, err := websocket.Dial(ctx, baseURL, &websocket.DialOptions{
 		HTTPHeader: headers,
 	})
+
+	switch {
+	case b == nil:
+	case err != nil && ctx.Err() != nil:
+		b.Ignore()
+	default:
+		b.Done(err == nil)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -108,3 +160,14 @@ func (s *Service) dial(ctx context.Context, baseURL string, urlParams url.Values
 
 	return c, nil
 }
+
+// upstreamHost returns the host of the upstream URL, which identifies its circuit breaker.
+// It takes baseURL of type string.
+// It returns the host, or the URL itself if it can't be parsed.
+func upstreamHost(baseURL string) string {
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+
+	return baseURL
+}
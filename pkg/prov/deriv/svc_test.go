@@ -8,10 +8,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/breaker"
 	"github.com/ksysoev/wasabi"
 	"github.com/ksysoev/wasabi/backend"
 	"github.com/ksysoev/wasabi/mocks"
@@ -279,3 +281,52 @@ func TestService_dial(t *testing.T) {
 		})
 	}
 }
+
+func TestService_dial_CircuitBreaker(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	s := &Service{
+		breakers: breaker.NewSet("deriv", breaker.Config{FailureThreshold: 2, OpenTimeout: time.Minute}),
+	}
+
+	baseURL := "ws://" + server.Listener.Addr().String()
+	urlParams := url.Values{"app_id": []string{"123"}}
+
+	for i := 0; i < 2; i++ {
+		_, err := s.dial(context.Background(), baseURL, urlParams, nil)
+		assert.ErrorContains(t, err, "failed to WebSocket dial")
+	}
+
+	_, err := s.dial(context.Background(), baseURL, urlParams, nil)
+
+	var apiErr *core.APIError
+
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, breaker.StateOpen, s.breakers.Get(server.Listener.Addr().String()).State())
+}
+
+func TestService_Handle_SharedSubscriptions(t *testing.T) {
+	service := NewService(&Config{
+		Endpoint:            "wss://example.com",
+		SharedSubscriptions: []string{"ticks"},
+	})
+
+	assert.NotNil(t, service.hub)
+
+	mockHandler := mocks.NewMockBackend(t)
+	service.handler = mockHandler
+
+	client := newTestClient(t, "conn1")
+	req := request.NewRequest(context.Background(), request.TextMessage, []byte(`{"ping":1}`))
+
+	mockHandler.EXPECT().Handle(client.conn, req).Return(nil)
+
+	err := service.Handle(client.conn, req)
+	assert.NoError(t, err)
+
+	req = request.NewRequest(context.Background(), request.TextMessage, []byte(`{"ticks":"R_50","subscribe":1}`))
+
+	err = service.Handle(client.conn, req)
+	assert.ErrorContains(t, err, "failed to connect to upstream")
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefreshBefore = 30 * time.Second
+	maxTokenResponseSize = 1 << 20
+)
+
+type AuthConfig struct {
+	Params           map[string]string `mapstructure:"params"`
+	TokenURL         string            `mapstructure:"token_url"`
+	ClientID         string            `mapstructure:"client_id"`
+	ClientSecretEnv  string            `mapstructure:"client_secret_env"`
+	ClientSecretFile string            `mapstructure:"client_secret_file"`
+	Scopes           []string          `mapstructure:"scopes"`
+	RefreshBefore    time.Duration     `mapstructure:"refresh_before"`
+}
+
+// tokenSource obtains OAuth2 access tokens with the client credentials grant and caches them until they're about to expire.
+type tokenSource struct {
+	expires time.Time
+	client  *http.Client
+	now     func() time.Time
+	cfg     AuthConfig
+	token   string
+	mu      sync.Mutex
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// newTokenSource creates a new tokenSource.
+// It takes cfg of type AuthConfig and client of type *http.Client which is used to request tokens.
+// It returns a pointer to tokenSource and an error if the configuration is invalid or the client secret can't be read.
+// The client secret is read from the environment variable or the file, so it never appears in the configuration itself.
+func newTokenSource(cfg AuthConfig, client *http.Client) (*tokenSource, error) {
+	if cfg.TokenURL == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("token_url and client_id must be set")
+	}
+
+	if (cfg.ClientSecretEnv == "") == (cfg.ClientSecretFile == "") {
+		return nil, fmt.Errorf("exactly one of client_secret_env and client_secret_file must be set")
+	}
+
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = defaultRefreshBefore
+	}
+
+	ts := &tokenSource{
+		client: client,
+		now:    time.Now,
+		cfg:    cfg,
+	}
+
+	if _, err := ts.secret(); err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// Token returns a valid access token, requesting a new one if the cached token is missing or about to expire.
+// It takes ctx of type context.Context.
+// It returns the access token and an error if the token can't be obtained.
+// Concurrent callers wait for a single token request.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && (ts.expires.IsZero() || ts.now().Before(ts.expires.Add(-ts.cfg.RefreshBefore))) {
+		return ts.token, nil
+	}
+
+	resp, err := ts.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ts.token = resp.AccessToken
+	ts.expires = time.Time{}
+
+	if resp.ExpiresIn > 0 {
+		ts.expires = ts.now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	return ts.token, nil
+}
+
+// Invalidate drops the cached access token if it's the provided one, so the next call of Token requests a new token.
+// It takes token of type string which is the token rejected by the upstream.
+// Tokens that were already replaced by concurrent requests are kept, so a burst of rejected requests refreshes the token once.
+func (ts *tokenSource) Invalidate(token string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token == token {
+		ts.token = ""
+		ts.expires = time.Time{}
+	}
+}
+
+// fetch requests a new access token from the token endpoint.
+// It takes ctx of type context.Context.
+// It returns a pointer to tokenResponse and an error if the request fails or the response doesn't contain a bearer token.
+// Client credentials are sent with HTTP basic authentication.
+func (ts *tokenSource) fetch(ctx context.Context) (*tokenResponse, error) {
+	secret, err := ts.secret()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+
+	if len(ts.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(ts.cfg.Scopes, " "))
+	}
+
+	for key, value := range ts.cfg.Params {
+		form.Set(key, value)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(ts.cfg.ClientID), url.QueryEscape(secret))
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp tokenResponse
+
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("access token not found in token response")
+	}
+
+	if tokenResp.TokenType != "" && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", tokenResp.TokenType)
+	}
+
+	return &tokenResp, nil
+}
+
+// secret reads the client secret.
+// It returns the client secret and an error if it's empty or can't be read.
+// The secret is read on every token request, so rotated secrets are picked up without a restart.
+func (ts *tokenSource) secret() (string, error) {
+	return readSecret(ts.cfg.ClientSecretEnv, ts.cfg.ClientSecretFile)
+}
+
+// readSecret reads the secret from the environment variable or, if its name is empty, from the file.
+// It takes envName of type string and file of type string.
+// It returns the secret with surrounding whitespace of the file trimmed and an error if it's empty or can't be read.
+func readSecret(envName, file string) (string, error) {
+	if envName != "" {
+		secret := os.Getenv(envName)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", envName)
+		}
+
+		return secret, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", file)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenServer(t *testing.T, calls *atomic.Int32, status int, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" || r.FormValue("audience") != "api" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNewTokenSource(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("secret\n"), 0o600))
+
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0o600))
+
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		wantErr string
+		cfg     AuthConfig
+	}{
+		{
+			name: "Secret from env",
+			cfg:  AuthConfig{TokenURL: "http://localhost/token", ClientID: "client", ClientSecretEnv: "TEST_CLIENT_SECRET"},
+		},
+		{
+			name: "Secret from file",
+			cfg:  AuthConfig{TokenURL: "http://localhost/token", ClientID: "client", ClientSecretFile: secretFile},
+		},
+		{
+			name:    "Missing token URL",
+			cfg:     AuthConfig{ClientID: "client", ClientSecretEnv: "TEST_CLIENT_SECRET"},
+			wantErr: "token_url and client_id must be set",
+		},
+		{
+			name:    "Missing secret",
+			cfg:     AuthConfig{TokenURL: "http://localhost/token", ClientID: "client"},
+			wantErr: "exactly one of client_secret_env and client_secret_file must be set",
+		},
+		{
+			name: "Both secrets",
+			cfg: AuthConfig{
+				TokenURL: "http://localhost/token", ClientID: "client", ClientSecretEnv: "TEST_CLIENT_SECRET", ClientSecretFile: secretFile,
+			},
+			wantErr: "exactly one of client_secret_env and client_secret_file must be set",
+		},
+		{
+			name:    "Unset env",
+			cfg:     AuthConfig{TokenURL: "http://localhost/token", ClientID: "client", ClientSecretEnv: "TEST_MISSING_SECRET"},
+			wantErr: "environment variable TEST_MISSING_SECRET is not set",
+		},
+		{
+			name:    "Missing file",
+			cfg:     AuthConfig{TokenURL: "http://localhost/token", ClientID: "client", ClientSecretFile: secretFile + ".missing"},
+			wantErr: "failed to read secret file",
+		},
+		{
+			name:    "Empty file",
+			cfg:     AuthConfig{TokenURL: "http://localhost/token", ClientID: "client", ClientSecretFile: emptyFile},
+			wantErr: "is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := newTokenSource(tt.cfg, http.DefaultClient)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, ts)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, defaultRefreshBefore, ts.cfg.RefreshBefore)
+		})
+	}
+}
+
+func TestTokenSource_Token(t *testing.T) {
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	var calls atomic.Int32
+
+	server := newTokenServer(t, &calls, http.StatusOK, `{"access_token":"token","token_type":"Bearer","expires_in":120}`)
+
+	ts, err := newTokenSource(AuthConfig{
+		TokenURL:        server.URL,
+		ClientID:        "client",
+		ClientSecretEnv: "TEST_CLIENT_SECRET",
+		Scopes:          []string{"read", "write"},
+		Params:          map[string]string{"audience": "api"},
+		RefreshBefore:   time.Minute,
+	}, server.Client())
+	require.NoError(t, err)
+
+	now := time.Now()
+	ts.now = func() time.Time { return now }
+
+	token, err := ts.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	now = now.Add(59 * time.Second)
+
+	_, err = ts.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "cached token is used until it's about to expire")
+
+	now = now.Add(time.Second)
+
+	_, err = ts.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "token is refreshed before expiry")
+}
+
+func TestTokenSource_Invalidate(t *testing.T) {
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	var calls atomic.Int32
+
+	server := newTokenServer(t, &calls, http.StatusOK, `{"access_token":"token","token_type":"bearer","expires_in":3600}`)
+
+	ts, err := newTokenSource(AuthConfig{
+		TokenURL:        server.URL,
+		ClientID:        "client",
+		ClientSecretEnv: "TEST_CLIENT_SECRET",
+		Scopes:          []string{"read", "write"},
+		Params:          map[string]string{"audience": "api"},
+	}, server.Client())
+	require.NoError(t, err)
+
+	_, err = ts.Token(context.Background())
+	require.NoError(t, err)
+
+	ts.Invalidate("stale")
+
+	_, err = ts.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "token is kept if another token is rejected")
+
+	ts.Invalidate("token")
+
+	_, err = ts.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "rejected token is refreshed")
+}
+
+func TestTokenSource_Token_Errors(t *testing.T) {
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+		status  int
+	}{
+		{
+			name:    "Error status",
+			status:  http.StatusForbidden,
+			body:    `{"error":"invalid_client"}`,
+			wantErr: "token endpoint returned status 403",
+		},
+		{
+			name:    "Invalid JSON",
+			status:  http.StatusOK,
+			body:    `{`,
+			wantErr: "failed to decode token response",
+		},
+		{
+			name:    "Missing access token",
+			status:  http.StatusOK,
+			body:    `{"token_type":"Bearer"}`,
+			wantErr: "access token not found in token response",
+		},
+		{
+			name:    "Unsupported token type",
+			status:  http.StatusOK,
+			body:    `{"access_token":"token","token_type":"mac"}`,
+			wantErr: `unsupported token type "mac"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			server := newTokenServer(t, &calls, tt.status, tt.body)
+
+			ts, err := newTokenSource(AuthConfig{
+				TokenURL:        server.URL,
+				ClientID:        "client",
+				ClientSecretEnv: "TEST_CLIENT_SECRET",
+				Scopes:          []string{"read", "write"},
+				Params:          map[string]string{"audience": "api"},
+			}, server.Client())
+			require.NoError(t, err)
+
+			_, err = ts.Token(context.Background())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/breaker"
+	"github.com/ksysoev/wasabi"
+)
+
+type requestFactory func(r wasabi.Request) (*http.Request, error)
+
+// unauthorizedHandler is called when the upstream rejects the request with 401 Unauthorized.
+// It returns true if credentials of the request were refreshed, so the request should be sent again.
+type unauthorizedHandler func(r wasabi.Request, req *http.Request) bool
+
+// httpBackend sends requests to HTTP backends using the configured HTTP client
+// and forwards responses to the connection wrapped into response.HTTPResp envelopes.
+type httpBackend struct {
+	client       *http.Client
+	factory      requestFactory
+	unauthorized unauthorizedHandler
+	breakers     *breaker.Set
+}
+
+// newHTTPBackend creates a new httpBackend.
+// It takes client of type *http.Client, factory of type requestFactory which converts requests into HTTP requests,
+// breakers of type *breaker.Set which may be nil to disable circuit breakers,
+// and unauthorized of type unauthorizedHandler which may be nil to forward 401 responses without retries.
+// It returns a pointer to httpBackend.
+func newHTTPBackend(client *http.Client, factory requestFactory, breakers *breaker.Set, unauthorized unauthorizedHandler) *httpBackend {
+	return &httpBackend{
+		client:       client,
+		factory:      factory,
+		unauthorized: unauthorized,
+		breakers:     breakers,
+	}
+}
+
+// Handle sends the request to the HTTP backend and sends the response to the connection.
+// It takes conn of type wasabi.Connection and r of type wasabi.Request.
+// It returns an error if the request can't be created, the request fails or the response can't be read.
+// Responses with error status codes are forwarded as well, so processors can map them to API errors.
+// If the circuit of the backend host is open, it returns a ServiceUnavailable core.APIError without sending the request.
+// Transport errors, except canceled requests, and responses with 5xx status codes are counted as failures by the circuit breaker.
+// If the upstream responds with 401 Unauthorized and the credentials of the request are refreshed, the request is sent once more.
+func (b *httpBackend) Handle(conn wasabi.Connection, r wasabi.Request) error {
+	req, err := b.factory(r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send http request: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && b.unauthorized != nil && b.unauthorized(r, req) {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if req, err = b.factory(r); err != nil {
+			return err
+		}
+
+		if resp, err = b.do(req); err != nil {
+			return fmt.Errorf("failed to send http request: %w", err)
+		}
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read http response: %w", err)
+	}
+
+	data, err := response.NewHTTPResp(resp.StatusCode, resp.Header, body).Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode http response: %w", err)
+	}
+
+	return conn.Send(wasabi.MsgTypeText, data)
+}
+
+// do sends the request through the circuit breaker of the request host.
+// It takes req of type *http.Request.
+// It returns a pointer to http.Response and an error if the circuit is open or the request fails.
+// Requests that fail because their context is canceled or its deadline exceeded are not counted by the circuit breaker,
+// so clients that disconnect or time out don't open the circuit of a healthy upstream.
+func (b *httpBackend) do(req *http.Request) (*http.Response, error) {
+	if b.breakers == nil {
+		return b.client.Do(req)
+	}
+
+	cb := b.breakers.Get(req.URL.Host)
+
+	if err := cb.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.Do(req)
+
+	if err != nil && req.Context().Err() != nil {
+		cb.Ignore()
+		return resp, err
+	}
+
+	cb.Done(err == nil && resp.StatusCode < http.StatusInternalServerError)
+
+	return resp, err
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/core"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/core/response"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/breaker"
+	"github.com/ksysoev/wasabi"
+	"github.com/ksysoev/wasabi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPBackend_Handle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/missing":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+		}
+
+		_, _ = w.Write([]byte(`{"result":"ok"}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	service := &Service{}
+	ctx := context.Background()
+
+	tests := []struct {
+		req     *request.HTTPReq
+		name    string
+		wantErr string
+		status  int
+		wantMsg bool
+	}{
+		{
+			name:    "Successful request",
+			req:     request.NewHTTPReq(ctx, "GET", server.URL, nil, "1"),
+			status:  http.StatusOK,
+			wantMsg: true,
+		},
+		{
+			name:    "Error status",
+			req:     request.NewHTTPReq(ctx, "GET", server.URL+"/missing", nil, "1"),
+			status:  http.StatusNotFound,
+			wantMsg: true,
+		},
+		{
+			name:    "Client timeout",
+			req:     request.NewHTTPReq(ctx, "GET", server.URL+"/slow", nil, "1"),
+			wantErr: "failed to send http request",
+		},
+		{
+			name:    "Invalid request",
+			req:     request.NewHTTPReq(ctx, "/invalid", "test", nil, "1"),
+			wantErr: "failed to create http request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newClient(&Config{Timeout: 50 * time.Millisecond})
+			assert.NoError(t, err)
+
+			conn := mocks.NewMockConnection(t)
+			if tt.wantMsg {
+				conn.EXPECT().Send(wasabi.MsgTypeText, mock.MatchedBy(func(data []byte) bool {
+					resp, err := response.DecodeHTTPResp(data)
+
+					return err == nil && resp.StatusCode() == tt.status && string(resp.Body()) == `{"result":"ok"}` &&
+						resp.Headers()["Content-Type"][0] == "application/json"
+				})).Return(nil)
+			}
+
+			b := newHTTPBackend(client, service.requestFactory, nil, nil)
+
+			err = b.Handle(conn, tt.req)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHTTPBackend_Handle_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+
+	status := atomic.Int32{}
+	status.Store(http.StatusInternalServerError)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+
+	t.Cleanup(server.Close)
+
+	client, err := newClient(&Config{})
+	assert.NoError(t, err)
+
+	service := &Service{}
+	breakers := breaker.NewSet("http", breaker.Config{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	b := newHTTPBackend(client, service.requestFactory, breakers, nil)
+
+	conn := mocks.NewMockConnection(t)
+	conn.EXPECT().Send(wasabi.MsgTypeText, mock.Anything).Return(nil)
+
+	req := request.NewHTTPReq(context.Background(), "GET", server.URL, nil, "1")
+
+	assert.NoError(t, b.Handle(conn, req))
+	assert.NoError(t, b.Handle(conn, req))
+
+	var apiErr *core.APIError
+
+	err = b.Handle(conn, req)
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, int32(2), calls.Load())
+
+	status.Store(http.StatusOK)
+	time.Sleep(60 * time.Millisecond)
+
+	assert.NoError(t, b.Handle(conn, req))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, breaker.StateClosed, breakers.Get(strings.TrimPrefix(server.URL, "http://")).State())
+}
+
+func TestHTTPBackend_Handle_CircuitBreaker_Canceled(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-r.Context().Done()
+	}))
+
+	t.Cleanup(server.Close)
+
+	client, err := newClient(&Config{})
+	assert.NoError(t, err)
+
+	service := &Service{}
+	breakers := breaker.NewSet("http", breaker.Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	b := newHTTPBackend(client, service.requestFactory, breakers, nil)
+
+	conn := mocks.NewMockConnection(t)
+
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+		err = b.Handle(conn, request.NewHTTPReq(ctx, "GET", server.URL, nil, "1"))
+
+		cancel()
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, breaker.StateClosed, breakers.Get(strings.TrimPrefix(server.URL, "http://")).State())
+}
+
+func TestHTTPBackend_Handle_Unauthorized(t *testing.T) {
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	var tokens, calls atomic.Int32
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, tokens.Add(1))
+	}))
+
+	t.Cleanup(tokenServer.Close)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"result":"ok"}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	service, err := NewService(&Config{Auth: map[string]AuthConfig{
+		"billing": {TokenURL: tokenServer.URL, ClientID: "client", ClientSecretEnv: "TEST_CLIENT_SECRET"},
+	}})
+	require.NoError(t, err)
+
+	conn := mocks.NewMockConnection(t)
+	conn.EXPECT().Send(wasabi.MsgTypeText, mock.Anything).Return(nil).Times(3)
+
+	req := request.NewHTTPReq(context.Background(), "GET", server.URL, nil, "1")
+	req.SetAuth("billing")
+
+	assert.NoError(t, service.handler.Handle(conn, req))
+	assert.Equal(t, int32(2), tokens.Load(), "token rejected with 401 is refreshed")
+	assert.Equal(t, int32(2), calls.Load())
+
+	assert.NoError(t, service.handler.Handle(conn, req))
+	assert.Equal(t, int32(2), tokens.Load(), "refreshed token is cached")
+	assert.Equal(t, int32(3), calls.Load())
+
+	noAuth := request.NewHTTPReq(context.Background(), "GET", server.URL, nil, "2")
+
+	assert.NoError(t, service.handler.Handle(conn, noAuth))
+	assert.Equal(t, int32(4), calls.Load(), "requests without auth are not retried")
+}
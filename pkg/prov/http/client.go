@@ -0,0 +1,160 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/breaker"
+)
+
+type Config struct {
+	TLS                   TLSConfig               `mapstructure:"tls"`
+	Proxy                 string                  `mapstructure:"proxy"`
+	Timeout               time.Duration           `mapstructure:"timeout"`
+	DialTimeout           time.Duration           `mapstructure:"dial_timeout"`
+	KeepAlive             time.Duration           `mapstructure:"keep_alive"`
+	TLSHandshakeTimeout   time.Duration           `mapstructure:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration           `mapstructure:"response_header_timeout"`
+	IdleConnTimeout       time.Duration           `mapstructure:"idle_conn_timeout"`
+	MaxIdleConns          int                     `mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost   int                     `mapstructure:"max_idle_conns_per_host"`
+	MaxConnsPerHost       int                     `mapstructure:"max_conns_per_host"`
+	DisableHTTP2          bool                    `mapstructure:"disable_http2"`
+	CircuitBreaker        breaker.Config          `mapstructure:"circuit_breaker"`
+	Auth                  map[string]AuthConfig   `mapstructure:"auth"`
+	Signers               map[string]SignerConfig `mapstructure:"signers"`
+}
+
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// newClient creates a new HTTP client based on the provided configuration.
+// It takes cfg of type *Config.
+// It returns a pointer to http.Client and an error.
+// It returns an error if the proxy URL is invalid or TLS material can't be loaded.
+// Zero values keep the defaults of http.DefaultTransport, and the proxy is taken from the environment if it's not set.
+func newClient(cfg *Config) (*http.Client, error) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected default transport type %T", http.DefaultTransport)
+	}
+
+	transport = transport.Clone()
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.DialTimeout > 0 || cfg.KeepAlive > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+		if cfg.DialTimeout > 0 {
+			dialer.Timeout = cfg.DialTimeout
+		}
+
+		if cfg.KeepAlive > 0 {
+			dialer.KeepAlive = cfg.KeepAlive
+		}
+
+		transport.DialContext = dialer.DialContext
+	}
+
+	if cfg.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+
+	if cfg.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+
+	if cfg.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+
+	tlsCfg, err := newTLSConfig(&cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.TLSClientConfig = tlsCfg
+
+	if cfg.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}, nil
+}
+
+// newTLSConfig creates a TLS configuration for connections to HTTP backends.
+// It takes cfg of type *TLSConfig.
+// It returns a pointer to tls.Config and an error.
+// It returns an error if the CA file can't be read or has no certificates, or if the client key pair can't be loaded.
+// Client certificate and key files must be set together to enable mutual TLS.
+func newTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // explicitly enabled in configuration
+	}
+
+	if cfg.CAFile != "" {
+		caData, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+
+		tlsCfg.RootCAs = pool
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, fmt.Errorf("both cert_file and key_file must be set for mutual TLS")
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, name, blockType string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0o600))
+
+	return path
+}
+
+func createKeyPair(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyData, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return writePEM(t, "client.crt", "CERTIFICATE", cert), writePEM(t, "client.key", "EC PRIVATE KEY", keyData)
+}
+
+func TestNewClient(t *testing.T) {
+	cfg := &Config{
+		Proxy:                 "http://proxy.local:3128",
+		Timeout:               5 * time.Second,
+		DialTimeout:           time.Second,
+		TLSHandshakeTimeout:   2 * time.Second,
+		ResponseHeaderTimeout: 3 * time.Second,
+		IdleConnTimeout:       time.Minute,
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   5,
+		MaxConnsPerHost:       20,
+		DisableHTTP2:          true,
+		TLS: TLSConfig{
+			ServerName:         "backend.local",
+			InsecureSkipVerify: true,
+		},
+	}
+
+	client, err := newClient(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5*time.Second, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+
+	assert.Equal(t, 2*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 3*time.Second, transport.ResponseHeaderTimeout)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, 10, transport.MaxIdleConns)
+	assert.Equal(t, 5, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 20, transport.MaxConnsPerHost)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+	assert.Equal(t, "backend.local", transport.TLSClientConfig.ServerName)
+	assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", http.NoBody)
+
+	proxyURL, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.local:3128", proxyURL.String())
+}
+
+func TestNewClient_Defaults(t *testing.T) {
+	client, err := newClient(&Config{})
+	require.NoError(t, err)
+
+	assert.Zero(t, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+
+	assert.Equal(t, defaultTransport.MaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, defaultTransport.IdleConnTimeout, transport.IdleConnTimeout)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+}
+
+func TestNewClient_TLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+
+	t.Cleanup(server.Close)
+
+	caFile := writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+	certFile, keyFile := createKeyPair(t)
+
+	client, err := newClient(&Config{TLS: TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}})
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	client, err = newClient(&Config{})
+	require.NoError(t, err)
+
+	_, err = client.Get(server.URL) //nolint:bodyclose // request is expected to fail
+	assert.Error(t, err)
+}
+
+func TestNewClient_Errors(t *testing.T) {
+	certFile, keyFile := createKeyPair(t)
+	invalidPEM := filepath.Join(t.TempDir(), "invalid.pem")
+
+	require.NoError(t, os.WriteFile(invalidPEM, []byte("invalid"), 0o600))
+
+	tests := []struct {
+		name    string
+		wantErr string
+		cfg     Config
+	}{
+		{
+			name:    "Invalid proxy URL",
+			cfg:     Config{Proxy: "://invalid"},
+			wantErr: "invalid proxy URL",
+		},
+		{
+			name:    "Missing CA file",
+			cfg:     Config{TLS: TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.crt")}},
+			wantErr: "failed to read CA file",
+		},
+		{
+			name:    "CA file without certificates",
+			cfg:     Config{TLS: TLSConfig{CAFile: invalidPEM}},
+			wantErr: "no certificates found in CA file",
+		},
+		{
+			name:    "Cert file without key file",
+			cfg:     Config{TLS: TLSConfig{CertFile: certFile}},
+			wantErr: "both cert_file and key_file must be set for mutual TLS",
+		},
+		{
+			name:    "Invalid key pair",
+			cfg:     Config{TLS: TLSConfig{CertFile: certFile, KeyFile: invalidPEM}},
+			wantErr: "failed to load client certificate",
+		},
+		{
+			name: "Valid key pair",
+			cfg:  Config{TLS: TLSConfig{CertFile: certFile, KeyFile: keyFile}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newClient(&tt.cfg)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, client)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, client)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignerHMAC  = "hmac_sha256"
+	SignerSigV4 = "sigv4"
+
+	defaultSignatureHeader = "X-Signature"
+	defaultTimestampHeader = "X-Timestamp"
+	defaultCanonical       = "{method}\n{path}\n{timestamp}\n{body}"
+
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+type SignerConfig struct {
+	Type            string `mapstructure:"type"`
+	SecretEnv       string `mapstructure:"secret_env"`
+	SecretFile      string `mapstructure:"secret_file"`
+	SignatureHeader string `mapstructure:"signature_header"`
+	TimestampHeader string `mapstructure:"timestamp_header"`
+	Canonical       string `mapstructure:"canonical"`
+	Encoding        string `mapstructure:"encoding"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	Region          string `mapstructure:"region"`
+	Service         string `mapstructure:"service"`
+}
+
+// Signer signs HTTP requests right before they're sent to backends.
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// hmacSigner signs requests with HMAC-SHA256 over a configurable canonical string.
+type hmacSigner struct {
+	now             func() time.Time
+	secretEnv       string
+	secretFile      string
+	signatureHeader string
+	timestampHeader string
+	canonical       string
+	encoding        string
+}
+
+// sigV4Signer signs requests in the style of AWS Signature Version 4.
+type sigV4Signer struct {
+	now         func() time.Time
+	secretEnv   string
+	secretFile  string
+	accessKeyID string
+	region      string
+	service     string
+}
+
+// newSigner creates a new Signer based on the provided configuration.
+// It takes cfg of type SignerConfig.
+// It returns a Signer and an error if the configuration is invalid or the secret can't be read.
+// The secret is read from the environment variable or the file, so it never appears in the configuration itself.
+func newSigner(cfg SignerConfig) (Signer, error) {
+	if (cfg.SecretEnv == "") == (cfg.SecretFile == "") {
+		return nil, fmt.Errorf("exactly one of secret_env and secret_file must be set")
+	}
+
+	if _, err := readSecret(cfg.SecretEnv, cfg.SecretFile); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case SignerHMAC:
+		return newHMACSigner(cfg)
+	case SignerSigV4:
+		if cfg.AccessKeyID == "" || cfg.Region == "" || cfg.Service == "" {
+			return nil, fmt.Errorf("access_key_id, region and service must be set for sigv4 signer")
+		}
+
+		return &sigV4Signer{
+			now:         time.Now,
+			secretEnv:   cfg.SecretEnv,
+			secretFile:  cfg.SecretFile,
+			accessKeyID: cfg.AccessKeyID,
+			region:      cfg.Region,
+			service:     cfg.Service,
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid signer type %q", cfg.Type)
+	}
+}
+
+// newHMACSigner creates a new HMAC-SHA256 signer.
+// It takes cfg of type SignerConfig.
+// It returns a pointer to hmacSigner and an error if the signature encoding is invalid.
+// The signature is hex-encoded by default, and the default canonical string consists of the method, path, timestamp and body.
+func newHMACSigner(cfg SignerConfig) (*hmacSigner, error) {
+	s := &hmacSigner{
+		now:             time.Now,
+		secretEnv:       cfg.SecretEnv,
+		secretFile:      cfg.SecretFile,
+		signatureHeader: cfg.SignatureHeader,
+		timestampHeader: cfg.TimestampHeader,
+		canonical:       cfg.Canonical,
+		encoding:        cfg.Encoding,
+	}
+
+	if s.signatureHeader == "" {
+		s.signatureHeader = defaultSignatureHeader
+	}
+
+	if s.timestampHeader == "" {
+		s.timestampHeader = defaultTimestampHeader
+	}
+
+	if s.canonical == "" {
+		s.canonical = defaultCanonical
+	}
+
+	switch s.encoding {
+	case "":
+		s.encoding = "hex"
+	case "hex", "base64":
+	default:
+		return nil, fmt.Errorf("invalid signature encoding %q", cfg.Encoding)
+	}
+
+	return s, nil
+}
+
+// Sign sets the timestamp and signature headers of the request.
+// It takes req of type *http.Request and body of type []byte.
+// It returns an error if the secret can't be read.
+// Placeholders {method}, {host}, {path}, {query}, {timestamp}, {body} and {body_sha256} of the canonical string
+// are replaced with values of the request, where the timestamp is in Unix seconds.
+func (s *hmacSigner) Sign(req *http.Request, body []byte) error {
+	secret, err := readSecret(s.secretEnv, s.secretFile)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	canonical := strings.NewReplacer(
+		"{method}", req.Method,
+		"{host}", req.URL.Host,
+		"{path}", req.URL.EscapedPath(),
+		"{query}", req.URL.RawQuery,
+		"{timestamp}", timestamp,
+		"{body}", string(body),
+		"{body_sha256}", sha256Hex(body),
+	).Replace(s.canonical)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	req.Header.Set(s.timestampHeader, timestamp)
+	req.Header.Set(s.signatureHeader, signature)
+
+	return nil
+}
+
+// Sign sets the X-Amz-Date and Authorization headers of the request.
+// It takes req of type *http.Request and body of type []byte.
+// It returns an error if the secret can't be read.
+// The host, X-Amz-* and Content-Type headers are signed.
+func (s *sigV4Signer) Sign(req *http.Request, body []byte) error {
+	secret, err := readSecret(s.secretEnv, s.secretFile)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "content-type" {
+			headers[name] = strings.Join(values, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + s.service + "/aws4_request"
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKeyID, scope, signedHeaders, signature,
+	))
+
+	return nil
+}
+
+// canonicalQuery encodes the query parameters sorted by name and value, with spaces encoded as %20.
+// It takes query of type url.Values.
+// It returns the canonical query string.
+func canonicalQuery(query url.Values) string {
+	escaped := make(map[string][]string, len(query))
+	names := make([]string, 0, len(query))
+
+	for name, values := range query {
+		name = sigV4Escape(name)
+		names = append(names, name)
+
+		for _, value := range values {
+			escaped[name] = append(escaped[name], sigV4Escape(value))
+		}
+	}
+
+	slices.Sort(names)
+
+	pairs := make([]string, 0, len(query))
+
+	for _, name := range names {
+		values := escaped[name]
+		slices.Sort(values)
+
+		for _, value := range values {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape escapes the string as required for canonical requests.
+// It takes s of type string.
+// It returns the escaped string.
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// sha256Hex returns the hex-encoded SHA-256 hash of the data.
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSigner(t *testing.T) {
+	t.Setenv("TEST_SIGNING_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		wantErr string
+		cfg     SignerConfig
+	}{
+		{
+			name: "HMAC",
+			cfg:  SignerConfig{Type: SignerHMAC, SecretEnv: "TEST_SIGNING_SECRET"},
+		},
+		{
+			name: "SigV4",
+			cfg:  SignerConfig{Type: SignerSigV4, SecretEnv: "TEST_SIGNING_SECRET", AccessKeyID: "AKID", Region: "eu-west-1", Service: "execute-api"},
+		},
+		{
+			name:    "Missing secret",
+			cfg:     SignerConfig{Type: SignerHMAC},
+			wantErr: "exactly one of secret_env and secret_file must be set",
+		},
+		{
+			name:    "Unset env",
+			cfg:     SignerConfig{Type: SignerHMAC, SecretEnv: "TEST_MISSING_SECRET"},
+			wantErr: "environment variable TEST_MISSING_SECRET is not set",
+		},
+		{
+			name:    "Invalid type",
+			cfg:     SignerConfig{Type: "rsa", SecretEnv: "TEST_SIGNING_SECRET"},
+			wantErr: `invalid signer type "rsa"`,
+		},
+		{
+			name:    "Invalid encoding",
+			cfg:     SignerConfig{Type: SignerHMAC, SecretEnv: "TEST_SIGNING_SECRET", Encoding: "base32"},
+			wantErr: `invalid signature encoding "base32"`,
+		},
+		{
+			name:    "SigV4 without region",
+			cfg:     SignerConfig{Type: SignerSigV4, SecretEnv: "TEST_SIGNING_SECRET", AccessKeyID: "AKID", Service: "execute-api"},
+			wantErr: "access_key_id, region and service must be set for sigv4 signer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newSigner(tt.cfg)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, signer)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, signer)
+		})
+	}
+}
+
+func TestHMACSigner_Sign(t *testing.T) {
+	t.Setenv("TEST_SIGNING_SECRET", "secret")
+
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1}`)
+
+	tests := []struct {
+		cfg             SignerConfig
+		name            string
+		wantCanonical   string
+		signatureHeader string
+		timestampHeader string
+		base64          bool
+	}{
+		{
+			name:            "Defaults",
+			cfg:             SignerConfig{Type: SignerHMAC, SecretEnv: "TEST_SIGNING_SECRET"},
+			wantCanonical:   "POST\n/v1/orders\n1700000000\n{\"id\":1}",
+			signatureHeader: "X-Signature",
+			timestampHeader: "X-Timestamp",
+		},
+		{
+			name: "Custom canonical string and headers",
+			cfg: SignerConfig{
+				Type:            SignerHMAC,
+				SecretEnv:       "TEST_SIGNING_SECRET",
+				Canonical:       "{timestamp}.{method}.{host}{path}?{query}.{body_sha256}",
+				SignatureHeader: "X-Partner-Signature",
+				TimestampHeader: "X-Partner-Time",
+				Encoding:        "base64",
+			},
+			wantCanonical:   "1700000000.POST.example.com/v1/orders?page=2." + sha256Hex(body),
+			signatureHeader: "X-Partner-Signature",
+			timestampHeader: "X-Partner-Time",
+			base64:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newSigner(tt.cfg)
+			require.NoError(t, err)
+
+			hmacSigner, ok := signer.(*hmacSigner)
+			require.True(t, ok)
+
+			hmacSigner.now = func() time.Time { return now }
+
+			req, err := http.NewRequest(http.MethodPost, "https://example.com/v1/orders?page=2", nil)
+			require.NoError(t, err)
+
+			require.NoError(t, signer.Sign(req, body))
+
+			mac := hmac.New(sha256.New, []byte("secret"))
+			mac.Write([]byte(tt.wantCanonical))
+
+			want := hex.EncodeToString(mac.Sum(nil))
+			if tt.base64 {
+				want = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			}
+
+			assert.Equal(t, "1700000000", req.Header.Get(tt.timestampHeader))
+			assert.Equal(t, want, req.Header.Get(tt.signatureHeader))
+		})
+	}
+}
+
+func TestSigV4Signer_Sign(t *testing.T) {
+	t.Setenv("TEST_AWS_SECRET", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+
+	signer, err := newSigner(SignerConfig{
+		Type:        SignerSigV4,
+		SecretEnv:   "TEST_AWS_SECRET",
+		AccessKeyID: "AKIDEXAMPLE",
+		Region:      "us-east-1",
+		Service:     "service",
+	})
+	require.NoError(t, err)
+
+	sigV4, ok := signer.(*sigV4Signer)
+	require.True(t, ok)
+
+	sigV4.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "Vanilla",
+			url:  "https://example.amazonaws.com/",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name: "Query order",
+			url:  "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+
+			require.NoError(t, signer.Sign(req, nil))
+
+			assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+			assert.Equal(t, tt.want, req.Header.Get("Authorization"))
+		})
+	}
+}
@@ -3,27 +3,57 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
 	"github.com/ksysoev/deriv-api-bff/pkg/core/request"
+	"github.com/ksysoev/deriv-api-bff/pkg/prov/breaker"
 	"github.com/ksysoev/wasabi"
-	"github.com/ksysoev/wasabi/backend"
 	"github.com/ksysoev/wasabi/channel"
 )
 
 type Service struct {
 	handler wasabi.RequestHandler
+	auth    map[string]*tokenSource
+	signers map[string]Signer
 }
 
 // NewService initializes and returns a new instance of Service.
+// It takes cfg of type *Config which configures the HTTP client and circuit breakers used for all HTTP backends.
 // It sets up the handler with a new backend using the requestFactory.
-// It returns a pointer to the newly created Service instance.
-func NewService() *Service {
-	s := &Service{}
+// It returns a pointer to the newly created Service instance and an error if the HTTP client, authentication providers or signers can't be created.
+func NewService(cfg *Config) (*Service, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
+	s := &Service{
+		auth:    make(map[string]*tokenSource, len(cfg.Auth)),
+		signers: make(map[string]Signer, len(cfg.Signers)),
+	}
+
+	for name, authCfg := range cfg.Auth {
+		ts, err := newTokenSource(authCfg, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth %s: %w", name, err)
+		}
+
+		s.auth[strings.ToLower(name)] = ts
+	}
 
-	s.handler = backend.NewBackend(s.requestFactory)
+	for name, signerCfg := range cfg.Signers {
+		signer, err := newSigner(signerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer %s: %w", name, err)
+		}
+
+		s.signers[strings.ToLower(name)] = signer
+	}
+
+	s.handler = newHTTPBackend(client, s.requestFactory, breaker.NewSet("http", cfg.CircuitBreaker), s.unauthorized)
 
-	return s
+	return s, nil
 }
 
 func (s *Service) Handle(conn *core.Conn, req *request.HTTPReq) error {
@@ -36,6 +66,9 @@ func (s *Service) Handle(conn *core.Conn, req *request.HTTPReq) error {
 // It takes a parameter r of type wasabi.Request.
 // It returns a pointer to an http.Request and an error.
 // It returns an error if the request type is invalid or if the HTTP request creation fails.
+// If the request refers to an authentication provider, the Authorization header is set to its bearer token.
+// If the request refers to a signer, the request is signed after all headers are set.
+// Names of authentication providers and signers are case-insensitive, because configuration keys are.
 func (s *Service) requestFactory(r wasabi.Request) (*http.Request, error) {
 	req, ok := r.(*request.HTTPReq)
 	if !ok {
@@ -47,9 +80,53 @@ func (s *Service) requestFactory(r wasabi.Request) (*http.Request, error) {
 		return nil, fmt.Errorf("failed to create http request: %w", err)
 	}
 
+	if name := req.Auth(); name != "" {
+		ts, ok := s.auth[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("auth %s is not configured", name)
+		}
+
+		token, err := ts.Token(httpReq.Context())
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain token for auth %s: %w", name, err)
+		}
+
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if name := req.Signer(); name != "" {
+		signer, ok := s.signers[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("signer %s is not configured", name)
+		}
+
+		if err := signer.Sign(httpReq, req.Data()); err != nil {
+			return nil, fmt.Errorf("failed to sign request with signer %s: %w", name, err)
+		}
+	}
+
 	return httpReq, nil
 }
 
+// unauthorized invalidates the token of the authentication provider of the request rejected with 401 Unauthorized.
+// It takes r of type wasabi.Request and httpReq of type *http.Request which is the rejected HTTP request.
+// It returns true if the request refers to an authentication provider, so it should be sent again with a new token.
+func (s *Service) unauthorized(r wasabi.Request, httpReq *http.Request) bool {
+	req, ok := r.(*request.HTTPReq)
+	if !ok || req.Auth() == "" {
+		return false
+	}
+
+	ts, ok := s.auth[strings.ToLower(req.Auth())]
+	if !ok {
+		return false
+	}
+
+	ts.Invalidate(strings.TrimPrefix(httpReq.Header.Get("Authorization"), "Bearer "))
+
+	return true
+}
+
 func sendWrapper(conn *core.Conn, req *request.HTTPReq) channel.SendWrapper {
 	return func(_ wasabi.Connection, _ wasabi.MessageType, msg []byte) error {
 		if ok := conn.DoneRequest(req.ID(), msg); !ok {
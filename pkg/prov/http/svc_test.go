@@ -2,6 +2,8 @@ package http
 
 import (
 	"context"
+	"net/http"
+	"sync/atomic"
 	"testing"
 
 	"github.com/ksysoev/deriv-api-bff/pkg/core"
@@ -10,15 +12,91 @@ import (
 	"github.com/ksysoev/wasabi/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewService(t *testing.T) {
-	service := NewService()
+	service, err := NewService(&Config{})
 
+	assert.NoError(t, err)
 	assert.NotNil(t, service)
 	assert.NotNil(t, service.handler)
 }
 
+func TestNewService_InvalidConfig(t *testing.T) {
+	service, err := NewService(&Config{Proxy: "://invalid"})
+
+	assert.ErrorContains(t, err, "failed to create http client")
+	assert.Nil(t, service)
+}
+
+func TestNewService_InvalidAuth(t *testing.T) {
+	service, err := NewService(&Config{Auth: map[string]AuthConfig{"billing": {TokenURL: "http://localhost/token"}}})
+
+	assert.ErrorContains(t, err, "invalid auth billing")
+	assert.Nil(t, service)
+}
+
+func TestRequestFactory_Auth(t *testing.T) {
+	t.Setenv("TEST_CLIENT_SECRET", "secret")
+
+	var calls atomic.Int32
+
+	server := newTokenServer(t, &calls, http.StatusOK, `{"access_token":"token","token_type":"bearer","expires_in":3600}`)
+
+	service, err := NewService(&Config{Auth: map[string]AuthConfig{
+		"Billing": {
+			TokenURL:        server.URL,
+			ClientID:        "client",
+			ClientSecretEnv: "TEST_CLIENT_SECRET",
+			Scopes:          []string{"read", "write"},
+			Params:          map[string]string{"audience": "api"},
+		},
+	}})
+	require.NoError(t, err)
+
+	req := request.NewHTTPReq(context.Background(), "GET", "http://localhost/", nil, "1")
+	req.SetAuth("billing")
+
+	httpReq, err := service.requestFactory(req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", httpReq.Header.Get("Authorization"))
+
+	req.SetAuth("unknown")
+
+	_, err = service.requestFactory(req)
+	assert.ErrorContains(t, err, "auth unknown is not configured")
+}
+
+func TestRequestFactory_Signer(t *testing.T) {
+	t.Setenv("TEST_SIGNING_SECRET", "secret")
+
+	service, err := NewService(&Config{Signers: map[string]SignerConfig{
+		"Partner": {Type: SignerHMAC, SecretEnv: "TEST_SIGNING_SECRET"},
+	}})
+	require.NoError(t, err)
+
+	req := request.NewHTTPReq(context.Background(), "POST", "http://localhost/orders", []byte(`{"id":1}`), "1")
+	req.SetSigner("partner")
+
+	httpReq, err := service.requestFactory(req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, httpReq.Header.Get("X-Signature"))
+	assert.NotEmpty(t, httpReq.Header.Get("X-Timestamp"))
+
+	req.SetSigner("unknown")
+
+	_, err = service.requestFactory(req)
+	assert.ErrorContains(t, err, "signer unknown is not configured")
+}
+
+func TestNewService_InvalidSigner(t *testing.T) {
+	service, err := NewService(&Config{Signers: map[string]SignerConfig{"partner": {Type: SignerHMAC}}})
+
+	assert.ErrorContains(t, err, "invalid signer partner")
+	assert.Nil(t, service)
+}
+
 func TestRequestFactory(t *testing.T) {
 	service := &Service{}
 	ctx := context.Background()
@@ -58,7 +136,7 @@ func TestRequestFactory(t *testing.T) {
 }
 
 func TestService_Handle(t *testing.T) {
-	service := NewService()
+	service := &Service{}
 	ctx := context.Background()
 	mockConn := mocks.NewMockConnection(t)
 	conn := core.NewConnection(mockConn, func(_ string) {})
@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// RedactHandler is a slog.Handler that redacts secrets from messages and attributes of log records.
+type RedactHandler struct {
+	next     slog.Handler
+	provider *Provider
+}
+
+// NewRedactHandler creates a new RedactHandler.
+// It takes next of type slog.Handler, which handles redacted records, and provider of type *Provider.
+// It returns a pointer to RedactHandler.
+func NewRedactHandler(next slog.Handler, provider *Provider) *RedactHandler {
+	return &RedactHandler{
+		next:     next,
+		provider: provider,
+	}
+}
+
+// Enabled reports whether the next handler handles records at the given level.
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record and passes it to the next handler.
+// It takes ctx of type context.Context and r of type slog.Record.
+// It returns an error if the next handler fails.
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redactedRec := slog.NewRecord(r.Time, r.Level, h.provider.Redact(r.Message), r.PC)
+
+	r.Attrs(func(a slog.Attr) bool {
+		redactedRec.AddAttrs(h.redactAttr(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, redactedRec)
+}
+
+// WithAttrs returns a handler with the redacted attributes.
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redactAttr(a)
+	}
+
+	return NewRedactHandler(h.next.WithAttrs(redactedAttrs), h.provider)
+}
+
+// WithGroup returns a handler with the group.
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return NewRedactHandler(h.next.WithGroup(name), h.provider)
+}
+
+// redactAttr redacts string and error values of the attribute, including values of nested groups.
+// Other values, e.g. structs and maps, are redacted in their %+v fmt form, and are logged as strings only if they contain known values.
+func (h *RedactHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.provider.Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]any, len(group))
+
+		for i, ga := range group {
+			attrs[i] = h.redactAttr(ga)
+		}
+
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		str := fmt.Sprintf("%+v", v.Any())
+
+		if msg := h.provider.Redact(str); msg != str {
+			return slog.String(a.Key, msg)
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactHandler(t *testing.T) {
+	t.Setenv("BFF_TOKEN", "token-value")
+
+	p, err := New(&Config{})
+	require.NoError(t, err)
+
+	_, err = p.Env("BFF_TOKEN")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	logger := slog.New(NewRedactHandler(slog.NewTextHandler(buf, nil), p)).With(slog.String("url", "/items?token=token-value"))
+
+	logger.WithGroup("req").ErrorContext(context.Background(), "Request token-value failed",
+		slog.Any("error", errors.New("failed to send token-value")),
+		slog.Group("details", slog.String("header", "Bearer token-value")),
+		slog.Int("status", 500),
+		slog.Any("headers", map[string]string{"Authorization": "Bearer token-value"}),
+		slog.Any("req", struct{ Token string }{Token: "token-value"}),
+		slog.Any("params", map[string]int{"limit": 10}),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "token-value")
+	assert.Contains(t, out, `msg="Request [REDACTED] failed"`)
+	assert.Contains(t, out, `url="/items?token=[REDACTED]"`)
+	assert.Contains(t, out, `req.error="failed to send [REDACTED]"`)
+	assert.Contains(t, out, `req.details.header="Bearer [REDACTED]"`)
+	assert.Contains(t, out, "req.status=500")
+	assert.Contains(t, out, `req.headers="map[Authorization:Bearer [REDACTED]]"`)
+	assert.Contains(t, out, `req.req={Token:[REDACTED]}`)
+	assert.Contains(t, out, "req.params=map[limit:10]")
+}
@@ -0,0 +1,242 @@
+package secrets
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReloadInterval = 30 * time.Second
+	defaultEnvPrefix      = "BFF_"
+	minRedactLength       = 4
+	redacted              = "[REDACTED]"
+)
+
+type Config struct {
+	Dir            string        `mapstructure:"dir"`
+	EnvPrefix      string        `mapstructure:"env_prefix"`
+	EnvAllow       []string      `mapstructure:"env_allow"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// Provider resolves secrets from files in a directory and allowed environment variables.
+// Only environment variables with the configured prefix or explicitly allowed ones can be resolved,
+// so API call definitions can't read unrelated variables of the process, e.g. cloud credentials.
+// Files are reloaded periodically, so rotated secrets, e.g. mounted Kubernetes secrets, are picked up without a restart.
+// All values it has resolved are remembered, so they can be redacted from logs.
+type Provider struct {
+	files     map[string]string
+	known     map[string]struct{}
+	envAllow  map[string]struct{}
+	redactSeq []string
+	dir       string
+	envPrefix string
+	interval  time.Duration
+	mu        sync.RWMutex
+}
+
+// New creates a new Provider.
+// It takes cfg of type *Config.
+// It returns a pointer to Provider and an error if the secrets directory can't be read.
+// If the directory is not set, no secrets are available. If the environment prefix is not set, it defaults to BFF_.
+func New(cfg *Config) (*Provider, error) {
+	p := &Provider{
+		files:     make(map[string]string),
+		known:     make(map[string]struct{}),
+		envAllow:  make(map[string]struct{}, len(cfg.EnvAllow)),
+		dir:       cfg.Dir,
+		envPrefix: cfg.EnvPrefix,
+		interval:  cfg.ReloadInterval,
+	}
+
+	if p.interval <= 0 {
+		p.interval = defaultReloadInterval
+	}
+
+	if p.envPrefix == "" {
+		p.envPrefix = defaultEnvPrefix
+	}
+
+	for _, name := range cfg.EnvAllow {
+		p.envAllow[name] = struct{}{}
+	}
+
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Run reloads secrets from the directory until the context is canceled.
+// It takes ctx of type context.Context.
+// It returns nil when the context is canceled.
+// If secrets can't be reloaded, previously loaded values are kept.
+func (p *Provider) Run(ctx context.Context) error {
+	if p.dir == "" {
+		return nil
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.load(); err != nil {
+				slog.Warn("Failed to reload secrets", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Secret returns the value of the secret.
+// It takes name of type string.
+// It returns the value and an error if there is no such secret in the directory.
+func (p *Provider) Secret(name string) (string, error) {
+	p.mu.RLock()
+	value, ok := p.files[name]
+	p.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("secret %s is not found", name)
+	}
+
+	return value, nil
+}
+
+// Env returns the value of the environment variable.
+// It takes name of type string.
+// It returns the value and an error if the variable is not allowed or not set.
+func (p *Provider) Env(name string) (string, error) {
+	if !p.envAllowed(name) {
+		return "", fmt.Errorf("environment variable %s is not allowed, only variables with prefix %s or listed in secrets.env_allow can be referenced", name, p.envPrefix)
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	p.remember(value)
+
+	return value, nil
+}
+
+// envAllowed checks if the environment variable can be resolved.
+// It takes name of type string.
+// It returns true if the name has the configured prefix or is explicitly allowed.
+func (p *Provider) envAllowed(name string) bool {
+	if strings.HasPrefix(name, p.envPrefix) {
+		return true
+	}
+
+	_, ok := p.envAllow[name]
+
+	return ok
+}
+
+// Redact replaces values resolved by the provider in the string.
+// It takes s of type string.
+// It returns the string where the values are replaced with [REDACTED].
+// Longer values are replaced first, so values that contain other values are redacted entirely.
+func (p *Provider) Redact(s string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, value := range p.redactSeq {
+		s = strings.ReplaceAll(s, value, redacted)
+	}
+
+	return s
+}
+
+// remember adds the value to the values that are redacted, keeping them sorted from the longest to the shortest.
+// Values shorter than 4 characters are not redacted, as they would mangle unrelated parts of logs.
+func (p *Provider) remember(value string) {
+	if len(value) < minRedactLength {
+		return
+	}
+
+	p.mu.RLock()
+	_, ok := p.known[value]
+	p.mu.RUnlock()
+
+	if ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.known[value]; ok {
+		return
+	}
+
+	p.known[value] = struct{}{}
+	p.redactSeq = append(p.redactSeq, value)
+
+	slices.SortFunc(p.redactSeq, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+}
+
+// load reads secrets from files in the directory, where the name of the file is the name of the secret.
+// It returns an error if the directory or any of its files can't be read.
+// Hidden files and directories are skipped, and values are trimmed of surrounding whitespace.
+func (p *Provider) load() error {
+	if p.dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets directory: %w", err)
+	}
+
+	files := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(p.dir, entry.Name())
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s: %w", entry.Name(), err)
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s: %w", entry.Name(), err)
+		}
+
+		files[entry.Name()] = strings.TrimSpace(string(data))
+	}
+
+	p.mu.Lock()
+	p.files = files
+	p.mu.Unlock()
+
+	for _, value := range files {
+		p.remember(value)
+	}
+
+	return nil
+}
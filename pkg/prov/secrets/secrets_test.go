@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_Secret(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "API_KEY"), []byte("file-key\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("hidden"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0o700))
+
+	t.Setenv("API_KEY", "env-key")
+	t.Setenv("PARTNER_KEY", "partner-key")
+
+	p, err := New(&Config{Dir: dir})
+	require.NoError(t, err)
+
+	value, err := p.Secret("API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "file-key", value)
+
+	_, err = p.Secret("PARTNER_KEY")
+	assert.EqualError(t, err, "secret PARTNER_KEY is not found")
+
+	_, err = p.Secret(".hidden")
+	assert.EqualError(t, err, "secret .hidden is not found")
+
+	_, err = p.Secret("MISSING_KEY")
+	assert.EqualError(t, err, "secret MISSING_KEY is not found")
+}
+
+func TestProvider_Env(t *testing.T) {
+	t.Setenv("BFF_REGION", "eu-west-1")
+
+	p, err := New(&Config{})
+	require.NoError(t, err)
+
+	value, err := p.Env("BFF_REGION")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", value)
+
+	_, err = p.Env("BFF_MISSING")
+	assert.EqualError(t, err, "environment variable BFF_MISSING is not set")
+}
+
+func TestProvider_Env_Restricted(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	t.Setenv("PARTNER_REGION", "eu-west-1")
+	t.Setenv("APP_TIMEOUT", "10s")
+	t.Setenv("BFF_REGION", "us-east-1")
+
+	p, err := New(&Config{EnvPrefix: "APP_", EnvAllow: []string{"PARTNER_REGION"}})
+	require.NoError(t, err)
+
+	_, err = p.Env("AWS_SECRET_ACCESS_KEY")
+	assert.EqualError(t, err, "environment variable AWS_SECRET_ACCESS_KEY is not allowed, only variables with prefix APP_ or listed in secrets.env_allow can be referenced")
+
+	_, err = p.Env("BFF_REGION")
+	assert.ErrorContains(t, err, "is not allowed")
+
+	value, err := p.Env("PARTNER_REGION")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", value)
+
+	value, err = p.Env("APP_TIMEOUT")
+	require.NoError(t, err)
+	assert.Equal(t, "10s", value)
+
+	assert.Equal(t, "aws-secret", p.Redact("aws-secret"))
+}
+
+func TestNew_InvalidDir(t *testing.T) {
+	_, err := New(&Config{Dir: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorContains(t, err, "failed to read secrets directory")
+}
+
+func TestProvider_Run(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "API_KEY")
+	require.NoError(t, os.WriteFile(path, []byte("old-key"), 0o600))
+
+	p, err := New(&Config{Dir: dir, ReloadInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- p.Run(ctx)
+	}()
+
+	require.NoError(t, os.WriteFile(path, []byte("new-key"), 0o600))
+
+	assert.Eventually(t, func() bool {
+		value, err := p.Secret("API_KEY")
+		return err == nil && value == "new-key"
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, "[REDACTED] and [REDACTED]", p.Redact("old-key and new-key"))
+}
+
+func TestProvider_Redact(t *testing.T) {
+	t.Setenv("BFF_TOKEN", "token-value")
+	t.Setenv("BFF_FLAG", "on")
+
+	p, err := New(&Config{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "GET /items?token=token-value", p.Redact("GET /items?token=token-value"))
+
+	_, err = p.Env("BFF_TOKEN")
+	require.NoError(t, err)
+
+	_, err = p.Env("BFF_FLAG")
+	require.NoError(t, err)
+
+	assert.Equal(t, "GET /items?token=[REDACTED]&flag=on", p.Redact("GET /items?token=token-value&flag=on"))
+}
+
+func TestProvider_Redact_Overlapping(t *testing.T) {
+	t.Setenv("BFF_PASSWORD", "pass")
+	t.Setenv("BFF_PASSPHRASE", "passphrase")
+
+	p, err := New(&Config{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"BFF_PASSWORD", "BFF_PASSPHRASE"} {
+		_, err = p.Env(name)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "key=[REDACTED] pwd=[REDACTED]", p.Redact("key=passphrase pwd=pass"))
+}
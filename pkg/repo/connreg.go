@@ -22,9 +22,10 @@ func NewConnectionRegistry() *ConnectionRegistry {
 }
 
 // GetConnection retrieves an existing connection or creates a new one if it doesn't exist.
-// It takes a clientConn of type wasabi.Connection.
+// It takes a clientConn of type wasabi.Connection and wrap of type core.ConnWrapper, which can be nil.
 // It returns a pointer to a core.Conn.
-func (c *ConnectionRegistry) GetConnection(clientConn wasabi.Connection) *core.Conn {
+// The client connection is wrapped only once, when the connection is created.
+func (c *ConnectionRegistry) GetConnection(clientConn wasabi.Connection, wrap core.ConnWrapper) *core.Conn {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -32,6 +33,10 @@ func (c *ConnectionRegistry) GetConnection(clientConn wasabi.Connection) *core.C
 		return conn
 	}
 
+	if wrap != nil {
+		clientConn = wrap(clientConn)
+	}
+
 	conn := core.NewConnection(clientConn, c.removeConnection)
 	c.connections[clientConn.ID()] = conn
 
@@ -3,6 +3,7 @@ package repo
 import (
 	"testing"
 
+	"github.com/ksysoev/wasabi"
 	"github.com/ksysoev/wasabi/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -19,12 +20,12 @@ func TestGetConnection(t *testing.T) {
 
 	clientConn.EXPECT().ID().Return("test-conn")
 
-	conn := registry.GetConnection(clientConn)
+	conn := registry.GetConnection(clientConn, nil)
 	assert.NotNil(t, conn)
 	assert.Equal(t, clientConn.ID(), conn.ID())
 
 	// Test if the same connection is returned for the same client connection
-	sameConn := registry.GetConnection(clientConn)
+	sameConn := registry.GetConnection(clientConn, nil)
 	assert.Equal(t, conn, sameConn)
 }
 
@@ -34,13 +35,32 @@ func TestRemoveConnection(t *testing.T) {
 
 	clientConn.EXPECT().ID().Return("test-conn")
 
-	conn := registry.GetConnection(clientConn)
+	conn := registry.GetConnection(clientConn, nil)
 	assert.NotNil(t, conn)
 
 	// Remove the connection
 	registry.removeConnection(clientConn.ID())
 
 	// Ensure the connection is removed
-	removedConn := registry.GetConnection(clientConn)
+	removedConn := registry.GetConnection(clientConn, nil)
 	assert.NotEqual(t, conn, removedConn)
 }
+
+func TestGetConnection_Wrap(t *testing.T) {
+	registry := NewConnectionRegistry()
+	clientConn := mocks.NewMockConnection(t)
+
+	clientConn.EXPECT().ID().Return("test-conn")
+
+	wrapped := 0
+	wrap := func(c wasabi.Connection) wasabi.Connection {
+		wrapped++
+		return c
+	}
+
+	conn := registry.GetConnection(clientConn, wrap)
+	sameConn := registry.GetConnection(clientConn, wrap)
+
+	assert.Same(t, conn, sameConn)
+	assert.Equal(t, 1, wrapped)
+}
@@ -123,10 +123,15 @@ func (s *testSuite) startAppWithConfig(cfgYAML string) (url string, err error) {
 		return "", fmt.Errorf("failed to create handlers: %w", err)
 	}
 
+	httpAPI, err := httpprov.NewService(&httpprov.Config{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create http service: %w", err)
+	}
+
 	derivAPI := deriv.NewService(&deriv.Config{Endpoint: s.echoWSURL()})
 	connRegistry := repo.NewConnectionRegistry()
 	calls := repo.NewCallsRepository()
-	beRouter := router.New(derivAPI, httpprov.NewService())
+	beRouter := router.New(derivAPI, httpAPI)
 	requestHandler := core.NewService(calls, beRouter, connRegistry)
 
 	requestHandler.UpdateHandlers(handlers)